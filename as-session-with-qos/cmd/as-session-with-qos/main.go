@@ -38,7 +38,7 @@ func main() {
 	log.Printf("current git commit: %s", gitCommit)
 	app, err := asSessionApp.InitApplication("as-session-with-qos", getConfigPath())
 	if err != nil {
-		log.Printf("cannot initialize application")
+		log.Printf("cannot initialize application: %s", err)
 		return
 	}
 
@@ -0,0 +1,63 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ------------------------------------------------------------------------------
+// SendAfNotification posts a notification to the AF notificationDestination.
+func (c *Connector) SendAfNotification(notificationUri string, v any) error {
+	log.Printf("sending notification to %s", notificationUri)
+	bData, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	r, err := http.NewRequest("POST", notificationUri, bytes.NewBuffer(bData))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	r.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			log.Printf("could not close response body correctly")
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to send notification, status code: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GroupStore resolves an external group identifier into the SUPIs of its members
+type GroupStore interface {
+	GetGroupMembers(extGroupId string) ([]string, error)
+}
+
+// RedisGroupStore keeps each group as a set of IMSIs under group:<extGroupId>,
+// beside the user:<imsi> entries of the UE profiles.
+type RedisGroupStore struct {
+	redisClient *redis.Client
+	ctx         context.Context
+}
+
+func NewRedisGroupStore(addr string) *RedisGroupStore {
+	return &RedisGroupStore{
+		ctx: context.Background(),
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (r *RedisGroupStore) GetGroupMembers(extGroupId string) ([]string, error) {
+	key := fmt.Sprintf("group:%s", extGroupId)
+	members, err := r.redisClient.SMembers(r.ctx, key).Result()
+	if err != nil {
+		log.Printf("GetGroupMembers: Redis query failed for key=%s, error=%v", key, err)
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	return members, nil
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) GetGroupMembers(extGroupId string) ([]string, error) {
+	if c.groupStore == nil {
+		return nil, fmt.Errorf("no group store configured")
+	}
+	return c.groupStore.GetGroupMembers(extGroupId)
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) HasGroupStore() bool {
+	return c.groupStore != nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// LookupGpsi resolves a GPSI (msisdn- or extid- form) of the AF into the UE
+// SUPI with the identity service
+func (c *Connector) LookupGpsi(afId string, gpsi string) (string, error) {
+	return c.identity.LookupGpsi(afId, gpsi)
+}
+
+// ------------------------------------------------------------------------------
+// GetUeProfile retrieves the live UE profile (PDU sessions, location) of a SUPI
+func (c *Connector) GetUeProfile(supi string) (*models.UeProfile, error) {
+	ueProfile := &models.UeProfile{}
+	if err := c.identity.GetUeProfile(supi, ueProfile); err != nil {
+		return nil, err
+	}
+	return ueProfile, nil
+}
@@ -21,6 +21,7 @@ package contexts
 
 import (
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/google/uuid"
@@ -34,6 +35,7 @@ type AppFunctionCtx struct {
 	Mu      sync.RWMutex
 	lastId  uint64
 	TransId uint64
+	store   SubscriptionStore
 }
 
 func NewAf(afId string) *AppFunctionCtx {
@@ -58,14 +60,29 @@ func (afCtx *AppFunctionCtx) GetAfSubscription(subId string) *AfSubscriptionCtx
 	}
 }
 
+// GetIdempotentSubscription returns the subscription created with the given
+// idempotency key, nil if none
+func (afCtx *AppFunctionCtx) GetIdempotentSubscription(key string) *AfSubscriptionCtx {
+	if len(key) == 0 {
+		return nil
+	}
+	for _, sub := range afCtx.subs {
+		if sub.IdempotencyKey == key {
+			return sub
+		}
+	}
+	return nil
+}
+
 func (afCtx *AppFunctionCtx) NewAfSubscription(data *models.AsSessionWithQoSSubscription) (string, *AfSubscriptionCtx) {
 
 	subId := afCtx.newSubscriptionId()
 	loc := createAsSessionWithQosLocation(afCtx.afId, subId)
 	sub := &AfSubscriptionCtx{
-		subId: subId,
-		Data:  data,
-		loc:   loc,
+		subId:      subId,
+		Data:       data,
+		loc:        loc,
+		NotifToken: uuid.NewString(),
 	}
 	data.Self = subId
 	afCtx.subs[subId] = sub
@@ -76,12 +93,52 @@ func (afCtx *AppFunctionCtx) DeleteAfscription(subId string) error {
 	_, ok := afCtx.subs[subId]
 	if ok {
 		delete(afCtx.subs, subId)
+		if afCtx.store != nil {
+			if err := afCtx.store.Delete(afCtx.afId, subId); err != nil {
+				log.Printf("could not delete stored subscription %s: %s", subId, err)
+			}
+		}
 		return nil
 	} else {
 		return fmt.Errorf("could not find traffic influence subscription")
 	}
 }
 
+// SaveAfSubscription persists the current state of the subscription, to be
+// called once the subscription and its app sessions are set up or modified.
+func (afCtx *AppFunctionCtx) SaveAfSubscription(sub *AfSubscriptionCtx) error {
+	if afCtx.store == nil {
+		return nil
+	}
+	return afCtx.store.Save(&SubscriptionRecord{
+		AfId:             afCtx.afId,
+		SubId:            sub.subId,
+		AppSessId:        sub.AppSessId,
+		MemberAppSessIds: sub.MemberAppSessIds,
+		NotifToken:       sub.NotifToken,
+		ExpiryTime:       sub.ExpiryTime,
+		IdempotencyKey:   sub.IdempotencyKey,
+		RequestHash:      sub.RequestHash,
+		Data:             sub.Data,
+	})
+}
+
+func (afCtx *AppFunctionCtx) restoreAfSubscription(record *SubscriptionRecord) *AfSubscriptionCtx {
+	sub := &AfSubscriptionCtx{
+		subId:            record.SubId,
+		Data:             record.Data,
+		loc:              createAsSessionWithQosLocation(afCtx.afId, record.SubId),
+		AppSessId:        record.AppSessId,
+		MemberAppSessIds: record.MemberAppSessIds,
+		NotifToken:       record.NotifToken,
+		ExpiryTime:       record.ExpiryTime,
+		IdempotencyKey:   record.IdempotencyKey,
+		RequestHash:      record.RequestHash,
+	}
+	afCtx.subs[record.SubId] = sub
+	return sub
+}
+
 func (afCtx *AppFunctionCtx) newSubscriptionId() string {
 	return uuid.NewString()
 }
@@ -20,6 +20,8 @@
 package contexts
 
 import (
+	"time"
+
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
 )
 
@@ -29,4 +31,42 @@ type AfSubscriptionCtx struct {
 	loc       string
 	AppSessId string //for pcf
 	TrInflId  string //for udr
+
+	NotifToken string //random part of the PCF notification uri, so that it cannot be guessed from the subscription id
+
+	MemberAppSessIds map[string]string //for group subscriptions, pcf app session per member supi
+	ExpiryTime       time.Time         //end of the qosDuration, zero when unbounded
+
+	IdempotencyKey string //Idempotency-Key of the creation request, if any
+	RequestHash    string //digest of the creation request body, to detect conflicting retries
+}
+
+func (sub *AfSubscriptionCtx) Location() string {
+	return sub.loc
+}
+
+// AppSessIds returns the PCF app sessions backing the subscription, one per member for group subscriptions
+func (sub *AfSubscriptionCtx) AppSessIds() []string {
+	if len(sub.AppSessId) > 0 {
+		return []string{sub.AppSessId}
+	}
+	appSessIds := []string{}
+	for _, appSessId := range sub.MemberAppSessIds {
+		appSessIds = append(appSessIds, appSessId)
+	}
+	return appSessIds
+}
+
+// RemoveAppSession drops a PCF app session from the subscription and tells
+// whether the subscription is still backed by any other app session.
+func (sub *AfSubscriptionCtx) RemoveAppSession(appSessId string) bool {
+	if sub.AppSessId == appSessId {
+		sub.AppSessId = ""
+	}
+	for supi, memberAppSessId := range sub.MemberAppSessIds {
+		if memberAppSessId == appSessId {
+			delete(sub.MemberAppSessIds, supi)
+		}
+	}
+	return len(sub.AppSessIds()) > 0
 }
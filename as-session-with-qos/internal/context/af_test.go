@@ -157,3 +157,50 @@ func TestDeleteSubscription(t *testing.T) {
 	}
 
 }
+
+func TestGroupSubscriptionAppSessions(t *testing.T) {
+	afId := "af-test"
+	af := NewAf(afId)
+
+	/* suscription data */
+	data := &models.AsSessionWithQoSSubscription{ExtGroupId: "group-test"}
+
+	_, subCtx := af.NewAfSubscription(data)
+	subCtx.MemberAppSessIds = map[string]string{
+		"001010000000001": "app-sess-1",
+		"001010000000002": "app-sess-2",
+	}
+
+	got := len(subCtx.AppSessIds())
+	if got != 2 {
+		t.Errorf("got number of app sessions %d, wanted %d", got, 2)
+	}
+
+	if !subCtx.RemoveAppSession("app-sess-1") {
+		t.Errorf("subscription should still be backed by app-sess-2")
+	}
+	if subCtx.RemoveAppSession("app-sess-2") {
+		t.Errorf("subscription should not be backed by any app session")
+	}
+	if len(subCtx.AppSessIds()) != 0 {
+		t.Errorf("got app sessions %v, wanted none", subCtx.AppSessIds())
+	}
+}
+
+func TestGetIdempotentSubscription(t *testing.T) {
+	af := NewAf("af-test")
+
+	_, subCtx := af.NewAfSubscription(&models.AsSessionWithQoSSubscription{})
+	subCtx.IdempotencyKey = "key-1"
+	af.NewAfSubscription(&models.AsSessionWithQoSSubscription{})
+
+	if got := af.GetIdempotentSubscription("key-1"); got != subCtx {
+		t.Errorf("got subscription %v, wanted %v", got, subCtx)
+	}
+	if got := af.GetIdempotentSubscription("key-2"); got != nil {
+		t.Errorf("got subscription %v for an unknown key, wanted nil", got)
+	}
+	if got := af.GetIdempotentSubscription(""); got != nil {
+		t.Errorf("got subscription %v for an empty key, wanted nil", got)
+	}
+}
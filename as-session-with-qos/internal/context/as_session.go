@@ -25,11 +25,13 @@ type app interface {
 type AsSessionAppCtx struct {
 	app
 	AppFunc map[string]*AppFunctionCtx
+	store   SubscriptionStore
 }
 
-func NewAsSessionAppCtx(app app) *AsSessionAppCtx {
+func NewAsSessionAppCtx(app app, store SubscriptionStore) *AsSessionAppCtx {
 	ctx := &AsSessionAppCtx{app: app,
 		AppFunc: make(map[string]*AppFunctionCtx),
+		store:   store,
 	}
 	return ctx
 }
@@ -49,7 +51,27 @@ func (c *AsSessionAppCtx) AddAf(id string) *AppFunctionCtx {
 		return nil
 	} else {
 		newCtx := NewAf(id)
+		newCtx.store = c.store
 		c.AppFunc[id] = newCtx
 		return newCtx
 	}
 }
+
+// Restore reloads the subscriptions persisted in the store
+func (c *AsSessionAppCtx) Restore() error {
+	if c.store == nil {
+		return nil
+	}
+	records, err := c.store.LoadAll()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		af := c.GetAf(record.AfId)
+		if af == nil {
+			af = c.AddAf(record.AfId)
+		}
+		af.restoreAfSubscription(record)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package contexts
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+)
+
+func TestRestoreSubscriptions(t *testing.T) {
+	afId := "af-test"
+	store := NewMemoryStore()
+	ctx := NewAsSessionAppCtx(nil, store)
+	af := ctx.AddAf(afId)
+
+	/* suscription data */
+	data := &models.AsSessionWithQoSSubscription{QosReference: "qos1"}
+
+	loc, subCtx := af.NewAfSubscription(data)
+	subCtx.AppSessId = "app-sess-1"
+	if err := af.SaveAfSubscription(subCtx); err != nil {
+		t.Fatalf("error while saving subscription: %v", err)
+	}
+
+	/* a new context on the same store, as after a restart */
+	restored := NewAsSessionAppCtx(nil, store)
+	if err := restored.Restore(); err != nil {
+		t.Fatalf("error while restoring subscriptions: %v", err)
+	}
+	restoredAf := restored.GetAf(afId)
+	if restoredAf == nil {
+		t.Fatalf("af %s not restored", afId)
+	}
+	restoredSub := restoredAf.GetAfSubscription(subCtx.subId)
+	if restoredSub == nil {
+		t.Fatalf("subscription %s not restored", subCtx.subId)
+	}
+	if restoredSub.Location() != loc {
+		t.Errorf("got location %q, wanted %q", restoredSub.Location(), loc)
+	}
+	if restoredSub.AppSessId != "app-sess-1" || restoredSub.Data.QosReference != "qos1" {
+		t.Errorf("got restored subscription %+v, wanted app-sess-1/qos1", restoredSub)
+	}
+
+	/* deleted subscriptions are not restored anymore */
+	if err := restoredAf.DeleteAfscription(subCtx.subId); err != nil {
+		t.Errorf("error while deleting subscription: %v", err)
+	}
+	records, _ := store.LoadAll()
+	if len(records) != 0 {
+		t.Errorf("got %d stored subscriptions, wanted 0", len(records))
+	}
+}
@@ -155,6 +155,12 @@ type AsSessionWithQoSSubscription struct {
 
 	// Identifies the list of UE addresses subject for Consolidated Data Rate monitoring.
 	ListUeConsDtRt []IpAddr `json:"listUeConsDtRt,omitempty"`
+
+	// Read only, not part of TS 29.122. The QoS reference currently in force: qosReference, or one of the alternative QoS references once the PCF fell back to it.
+	AppliedQosRef string `json:"appliedQosRef,omitempty"`
+
+	// Read only, not part of TS 29.122. The members of the extGroupId whose PCF app session could not be created.
+	FailedMembers []InvalidParam `json:"failedMembers,omitempty"`
 }
 
 //TODO See what to do with those assertions... code does not seem correct
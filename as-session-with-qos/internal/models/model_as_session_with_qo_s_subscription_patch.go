@@ -28,6 +28,8 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 )
 
@@ -119,6 +121,46 @@ type AsSessionWithQoSSubscriptionPatch struct {
 
 	// Identifies the list of UE addresses subject for Consolidated Data Rate monitoring.
 	ListUeConsDtRt []IpAddr `json:"listUeConsDtRt,omitempty"`
+
+	// attributes present in the decoded patch, null ones included
+	present map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the patch and records its attributes, so that the
+// attributes set to null, false or zero can be told from the absent ones when
+// merging it (RFC 7396)
+func (obj *AsSessionWithQoSSubscriptionPatch) UnmarshalJSON(data []byte) error {
+	type patch AsSessionWithQoSSubscriptionPatch
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode((*patch)(obj)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &obj.present)
+}
+
+// Provided tells whether the attribute is present in the patch, set to null
+// included. A patch not decoded from JSON provides the attributes it sets.
+func (obj *AsSessionWithQoSSubscriptionPatch) Provided(attr string, set bool) bool {
+	if obj.present == nil {
+		return set
+	}
+	_, ok := obj.present[attr]
+	return ok
+}
+
+// ProvidedIn tells whether the attribute of the object attribute is present in
+// the patch, set to null included
+func (obj *AsSessionWithQoSSubscriptionPatch) ProvidedIn(object string, attr string, set bool) bool {
+	if obj.present == nil {
+		return set
+	}
+	var attrs map[string]json.RawMessage
+	if err := json.Unmarshal(obj.present[object], &attrs); err != nil {
+		return false
+	}
+	_, ok := attrs[attr]
+	return ok
 }
 
 // AssertAsSessionWithQoSSubscriptionPatchRequired checks if the required fields are not zero-ed
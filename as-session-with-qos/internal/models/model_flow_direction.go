@@ -28,8 +28,14 @@
 package models
 
 // FlowDirection - Indicates the direction of the service data flow.   Possible values are: - DOWNLINK: The corresponding filter applies for traffic to the UE. - UPLINK: The corresponding filter applies for traffic from the UE. - BIDIRECTIONAL: The corresponding filter applies for traffic both to and from the UE. - UNSPECIFIED: The corresponding filter applies for traffic to the UE (downlink), but has no specific direction declared. The service data flow detection shall apply the filter for uplink traffic as if the filter was bidirectional. The PCF shall not use the value UNSPECIFIED in filters created by the network in NW-initiated procedures. The PCF shall only include the value UNSPECIFIED in filters in UE-initiated procedures if the same value is received from the SMF.
-type FlowDirection struct {
-}
+type FlowDirection string
+
+const (
+	FlowDirectionDownlink      FlowDirection = "DOWNLINK"
+	FlowDirectionUplink        FlowDirection = "UPLINK"
+	FlowDirectionBidirectional FlowDirection = "BIDIRECTIONAL"
+	FlowDirectionUnspecified   FlowDirection = "UNSPECIFIED"
+)
 
 // AssertFlowDirectionRequired checks if the required fields are not zero-ed
 func AssertFlowDirectionRequired(obj FlowDirection) error {
@@ -28,8 +28,13 @@
 package models
 
 // ReportingFrequency - Indicates the frequency for the reporting.
-type ReportingFrequency struct {
-}
+type ReportingFrequency string
+
+const (
+	ReportingFrequencyEventTriggered ReportingFrequency = "EVENT_TRIGGERED"
+	ReportingFrequencyPeriodic       ReportingFrequency = "PERIODIC"
+	ReportingFrequencySessionRelease ReportingFrequency = "SESSION_RELEASE"
+)
 
 // AssertReportingFrequencyRequired checks if the required fields are not zero-ed
 func AssertReportingFrequencyRequired(obj ReportingFrequency) error {
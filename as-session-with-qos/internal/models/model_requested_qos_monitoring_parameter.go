@@ -28,8 +28,17 @@
 package models
 
 // RequestedQosMonitoringParameter - Indicates the requested QoS monitoring parameters to be measured.
-type RequestedQosMonitoringParameter struct {
-}
+type RequestedQosMonitoringParameter string
+
+const (
+	RequestedQosMonitoringParameterDownlink           RequestedQosMonitoringParameter = "DOWNLINK"
+	RequestedQosMonitoringParameterUplink             RequestedQosMonitoringParameter = "UPLINK"
+	RequestedQosMonitoringParameterRoundTrip          RequestedQosMonitoringParameter = "ROUND_TRIP"
+	RequestedQosMonitoringParameterDownlinkDataRate   RequestedQosMonitoringParameter = "DOWNLINK_DATA_RATE"
+	RequestedQosMonitoringParameterUplinkDataRate     RequestedQosMonitoringParameter = "UPLINK_DATA_RATE"
+	RequestedQosMonitoringParameterDownlinkCongestion RequestedQosMonitoringParameter = "DOWNLINK_CONGESTION"
+	RequestedQosMonitoringParameterUplinkCongestion   RequestedQosMonitoringParameter = "UPLINK_CONGESTION"
+)
 
 // AssertRequestedQosMonitoringParameterRequired checks if the required fields are not zero-ed
 func AssertRequestedQosMonitoringParameterRequired(obj RequestedQosMonitoringParameter) error {
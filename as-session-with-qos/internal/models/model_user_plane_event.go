@@ -28,8 +28,27 @@
 package models
 
 // UserPlaneEvent - Represents the user plane event.   Possible values are: - SESSION_TERMINATION: Indicates that Rx session is terminated. - LOSS_OF_BEARER : Indicates a loss of a bearer. - RECOVERY_OF_BEARER: Indicates a recovery of a bearer. - RELEASE_OF_BEARER: Indicates a release of a bearer. - USAGE_REPORT: Indicates the usage report event. - FAILED_RESOURCES_ALLOCATION: Indicates the resource allocation is failed. - QOS_GUARANTEED: The QoS targets of one or more SDFs are guaranteed again. - QOS_NOT_GUARANTEED: The QoS targets of one or more SDFs are not being guaranteed. - QOS_MONITORING: Indicates a QoS monitoring event. - SUCCESSFUL_RESOURCES_ALLOCATION: Indicates the resource allocation is successful. - ACCESS_TYPE_CHANGE: Indicates an Access type change. - PLMN_CHG: Indicates a PLMN change. - L4S_NOT_AVAILABLE: The ECN marking for L4S of one or more SDFs is not available. - L4S_AVAILABLE: The ECN marking for L4S of one or more SDFs is available again. - BAT_OFFSET_INFO: Indicates the network provided BAT offset and the optionally adjusted periodicity. - RT_DELAY_TWO_QOS_FLOWS: Indicates round-trip delay on UL and DL flows over two QoS flows. - PACK_DELAY_VAR: Indicates Packet Delay Variation is enabled for the SDF.
-type UserPlaneEvent struct {
-}
+type UserPlaneEvent string
+
+const (
+	UserPlaneEventSessionTermination            UserPlaneEvent = "SESSION_TERMINATION"
+	UserPlaneEventLossOfBearer                  UserPlaneEvent = "LOSS_OF_BEARER"
+	UserPlaneEventRecoveryOfBearer              UserPlaneEvent = "RECOVERY_OF_BEARER"
+	UserPlaneEventReleaseOfBearer               UserPlaneEvent = "RELEASE_OF_BEARER"
+	UserPlaneEventUsageReport                   UserPlaneEvent = "USAGE_REPORT"
+	UserPlaneEventFailedResourcesAllocation     UserPlaneEvent = "FAILED_RESOURCES_ALLOCATION"
+	UserPlaneEventQosGuaranteed                 UserPlaneEvent = "QOS_GUARANTEED"
+	UserPlaneEventQosNotGuaranteed              UserPlaneEvent = "QOS_NOT_GUARANTEED"
+	UserPlaneEventQosMonitoring                 UserPlaneEvent = "QOS_MONITORING"
+	UserPlaneEventSuccessfulResourcesAllocation UserPlaneEvent = "SUCCESSFUL_RESOURCES_ALLOCATION"
+	UserPlaneEventAccessTypeChange              UserPlaneEvent = "ACCESS_TYPE_CHANGE"
+	UserPlaneEventPlmnChg                       UserPlaneEvent = "PLMN_CHG"
+	UserPlaneEventL4sNotAvailable               UserPlaneEvent = "L4S_NOT_AVAILABLE"
+	UserPlaneEventL4sAvailable                  UserPlaneEvent = "L4S_AVAILABLE"
+	UserPlaneEventBatOffsetInfo                 UserPlaneEvent = "BAT_OFFSET_INFO"
+	UserPlaneEventRtDelayTwoQosFlows            UserPlaneEvent = "RT_DELAY_TWO_QOS_FLOWS"
+	UserPlaneEventPackDelayVar                  UserPlaneEvent = "PACK_DELAY_VAR"
+)
 
 // AssertUserPlaneEventRequired checks if the required fields are not zero-ed
 func AssertUserPlaneEventRequired(obj UserPlaneEvent) error {
@@ -0,0 +1,56 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+type pduSessionInfo struct {
+	Id       int32
+	Ipv4     string
+	Snssai   Snssai
+	DlStatus string
+	Dnn      string
+}
+
+type UeProfile struct {
+	Imsi               string
+	Gpsi               string
+	RegistrationStatus string
+	ConnectionStatus   string
+	Tac                string
+	NrCellId           string
+	PduSessions        map[int32]*pduSessionInfo
+}
+
+// GetSessionIpv4 returns the IPv4 address of the PDU session established on
+// the given dnn and slice, an empty dnn matches any session.
+func (ue *UeProfile) GetSessionIpv4(dnn string, snssai Snssai) string {
+	for _, session := range ue.PduSessions {
+		if session == nil || len(session.Ipv4) == 0 {
+			continue
+		}
+		if len(dnn) > 0 && session.Dnn != dnn {
+			continue
+		}
+		if snssai.Sst > 0 && (session.Snssai.Sst != snssai.Sst || session.Snssai.Sd != snssai.Sd) {
+			continue
+		}
+		return session.Ipv4
+	}
+	return ""
+}
@@ -32,6 +32,7 @@ import (
 	"net/http"
 
 	models "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 )
 
 // ASSessionWithRequiredQoSSubscriptionsAPIRouter defines the required methods for binding the api requests to a responses for the ASSessionWithRequiredQoSSubscriptionsAPI
@@ -58,7 +59,7 @@ type IndividualASSessionWithRequiredQoSSubscriptionAPIRouter interface {
 // and updated with the logic required for the API.
 type ASSessionWithRequiredQoSSubscriptionsAPIServicer interface {
 	FetchAllASSessionWithQoSSubscriptions(context.Context, string, []string, string, []string) (models.ImplResponse, error)
-	CreateASSessionWithQoSSubscription(context.Context, string, models.AsSessionWithQoSSubscription) (models.ImplResponse, error)
+	CreateASSessionWithQoSSubscription(context.Context, string, string, models.AsSessionWithQoSSubscription) (models.ImplResponse, error)
 }
 
 // IndividualASSessionWithRequiredQoSSubscriptionAPIServicer defines the api actions for the IndividualASSessionWithRequiredQoSSubscriptionAPI service
@@ -71,3 +72,17 @@ type IndividualASSessionWithRequiredQoSSubscriptionAPIServicer interface {
 	DeleteIndASSessionWithQoSSubscription(context.Context, string, string) (models.ImplResponse, error)
 	ModifyIndASSessionWithQoSSubscription(context.Context, string, string, models.AsSessionWithQoSSubscriptionPatch) (models.ImplResponse, error)
 }
+
+// PcfNotificationAPIRouter defines the required methods for binding the Npcf_PolicyAuthorization notifications sent by the PCF
+// The PcfNotificationAPIRouter implementation should parse the PCF request and pass it to a PcfNotificationAPIServicer,
+// which relays it to the AF notificationDestination.
+type PcfNotificationAPIRouter interface {
+	NotifyPcfEvents(http.ResponseWriter, *http.Request)
+	TerminatePcfAppSession(http.ResponseWriter, *http.Request)
+}
+
+// PcfNotificationAPIServicer defines the api actions for the PCF notifications received by the NEF
+type PcfNotificationAPIServicer interface {
+	NotifyPcfEvents(context.Context, string, string, string, pcfclient.EventsNotification) (models.ImplResponse, error)
+	TerminatePcfAppSession(context.Context, string, string, string, pcfclient.TerminationInfo) (models.ImplResponse, error)
+}
@@ -34,6 +34,8 @@ import (
 	"strings"
 
 	models "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/northbound/service"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 
 	"github.com/gorilla/mux"
 )
@@ -145,7 +147,8 @@ func (c *ASSessionWithRequiredQoSSubscriptionsAPIController) CreateASSessionWith
 		c.errorHandler(w, r, err, nil)
 		return
 	}
-	result, err := c.service.CreateASSessionWithQoSSubscription(r.Context(), scsAsIdParam, asSessionWithQoSSubscriptionParam)
+	idempotencyKeyParam := r.Header.Get("Idempotency-Key")
+	result, err := c.service.CreateASSessionWithQoSSubscription(r.Context(), scsAsIdParam, idempotencyKeyParam, asSessionWithQoSSubscriptionParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -333,3 +336,77 @@ func (c *IndividualASSessionWithRequiredQoSSubscriptionAPIController) ModifyIndA
 	// If no error, encode the body and the result code
 	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
 }
+
+// PcfNotificationAPIController binds the PCF notifications to an api service and writes the service results to the http response
+type PcfNotificationAPIController struct {
+	service      PcfNotificationAPIServicer
+	errorHandler models.ErrorHandler
+}
+
+// NewPcfNotificationAPIController creates a default api controller
+func NewPcfNotificationAPIController(s PcfNotificationAPIServicer) *PcfNotificationAPIController {
+	return &PcfNotificationAPIController{
+		service:      s,
+		errorHandler: models.DefaultErrorHandler,
+	}
+}
+
+// Routes returns all the api routes for the PcfNotificationAPIController
+func (c *PcfNotificationAPIController) Routes() models.Routes {
+	return models.Routes{
+		"NotifyPcfEvents": models.Route{
+			Method:      strings.ToUpper("Post"),
+			Pattern:     service.PcfNotificationsPath + "/{scsAsId}/subscriptions/{subscriptionId}/{notifToken}/notify",
+			HandlerFunc: c.NotifyPcfEvents,
+		},
+		"TerminatePcfAppSession": models.Route{
+			Method:      strings.ToUpper("Post"),
+			Pattern:     service.PcfNotificationsPath + "/{scsAsId}/subscriptions/{subscriptionId}/{notifToken}/terminate",
+			HandlerFunc: c.TerminatePcfAppSession,
+		},
+	}
+}
+
+// NotifyPcfEvents - Receives the app session events reported by the PCF.
+func (c *PcfNotificationAPIController) NotifyPcfEvents(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	scsAsIdParam := params["scsAsId"]
+	subscriptionIdParam := params["subscriptionId"]
+	notifTokenParam := params["notifToken"]
+	eventsNotificationParam := pcfclient.EventsNotification{}
+	d := json.NewDecoder(r.Body)
+	if err := d.Decode(&eventsNotificationParam); err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.NotifyPcfEvents(r.Context(), scsAsIdParam, subscriptionIdParam, notifTokenParam, eventsNotificationParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// TerminatePcfAppSession - Receives the PCF request to terminate an app session.
+func (c *PcfNotificationAPIController) TerminatePcfAppSession(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	scsAsIdParam := params["scsAsId"]
+	subscriptionIdParam := params["subscriptionId"]
+	notifTokenParam := params["notifToken"]
+	terminationInfoParam := pcfclient.TerminationInfo{}
+	d := json.NewDecoder(r.Body)
+	if err := d.Decode(&terminationInfoParam); err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.TerminatePcfAppSession(r.Context(), scsAsIdParam, subscriptionIdParam, notifTokenParam, terminationInfoParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
@@ -30,10 +30,11 @@ package northbound
 import (
 	"context"
 	"errors"
-	"net/http"
+	"fmt"
 
 	models "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/northbound/service"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 )
 
 type serverCtx interface {
@@ -46,6 +47,9 @@ type ASSessionWithRequiredQoSSubscriptionsAPIService struct {
 type IndividualASSessionWithRequiredQoSSubscriptionAPIService struct {
 	serverCtx
 }
+type PcfNotificationAPIService struct {
+	serverCtx
+}
 
 // NewASSessionWithRequiredQoSSubscriptionsAPIService creates a default api service
 func NewASSessionWithRequiredQoSSubscriptionsAPIService(srv serverCtx) *ASSessionWithRequiredQoSSubscriptionsAPIService {
@@ -57,17 +61,26 @@ func NewIndividualASSessionWithRequiredQoSSubscriptionAPIService(srv serverCtx)
 	return &IndividualASSessionWithRequiredQoSSubscriptionAPIService{serverCtx: srv}
 }
 
+// NewPcfNotificationAPIService creates a default api service
+func NewPcfNotificationAPIService(srv serverCtx) *PcfNotificationAPIService {
+	return &PcfNotificationAPIService{serverCtx: srv}
+}
+
 // FetchAllASSessionWithQoSSubscriptions - Read all or queried active subscriptions for the SCS/AS.
 func (s *ASSessionWithRequiredQoSSubscriptionsAPIService) FetchAllASSessionWithQoSSubscriptions(ctx context.Context, scsAsId string, ipAddrs []string, ipDomain string, macAddrs []string) (models.ImplResponse, error) {
-	return models.Response(http.StatusNotImplemented, nil, ""), errors.New("FetchAllASSessionWithQoSSubscriptions method not implemented")
+	subs, status, err := s.Service().FetchAllSessionWithQoSSubscriptions(scsAsId, ipAddrs, ipDomain, macAddrs)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, subs, ""), nil
 }
 
 // CreateASSessionWithQoSSubscription - Creates a new subscription resource.
-func (s *ASSessionWithRequiredQoSSubscriptionsAPIService) CreateASSessionWithQoSSubscription(ctx context.Context, scsAsId string, asSessionWithQoSSubscription models.AsSessionWithQoSSubscription) (models.ImplResponse, error) {
-	loc, status, err := s.Service().PostSessionWithQoSSubscription(scsAsId, &asSessionWithQoSSubscription)
+func (s *ASSessionWithRequiredQoSSubscriptionsAPIService) CreateASSessionWithQoSSubscription(ctx context.Context, scsAsId string, idempotencyKey string, asSessionWithQoSSubscription models.AsSessionWithQoSSubscription) (models.ImplResponse, error) {
+	loc, status, err := s.Service().PostSessionWithQoSSubscription(scsAsId, idempotencyKey, &asSessionWithQoSSubscription)
 
 	if err != nil {
-		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+		return models.Response(status, nil, ""), fmt.Errorf("Error: %w", err)
 	}
 
 	return models.Response(status, asSessionWithQoSSubscription, loc), nil
@@ -84,8 +97,11 @@ func (s *IndividualASSessionWithRequiredQoSSubscriptionAPIService) FetchIndASSes
 
 // UpdateIndASSessionWithQoSSubscription - Updates/replaces an existing subscription resource.
 func (s *IndividualASSessionWithRequiredQoSSubscriptionAPIService) UpdateIndASSessionWithQoSSubscription(ctx context.Context, scsAsId string, subscriptionId string, asSessionWithQoSSubscription models.AsSessionWithQoSSubscription) (models.ImplResponse, error) {
-
-	return models.Response(http.StatusNotImplemented, nil, ""), errors.New("UpdateIndASSessionWithQoSSubscription method not implemented")
+	sub, status, err := s.Service().UpdateSessionWithQoSSubscription(scsAsId, subscriptionId, &asSessionWithQoSSubscription)
+	if err != nil {
+		return models.Response(status, nil, ""), fmt.Errorf("Error: %w", err)
+	}
+	return models.Response(status, sub, ""), nil
 }
 
 // DeleteIndASSessionWithQoSSubscription - Deletes an already existing subscription.
@@ -96,6 +112,27 @@ func (s *IndividualASSessionWithRequiredQoSSubscriptionAPIService) DeleteIndASSe
 
 // ModifyIndASSessionWithQoSSubscription - Updates/replaces an existing subscription resource.
 func (s *IndividualASSessionWithRequiredQoSSubscriptionAPIService) ModifyIndASSessionWithQoSSubscription(ctx context.Context, scsAsId string, subscriptionId string, asSessionWithQoSSubscriptionPatch models.AsSessionWithQoSSubscriptionPatch) (models.ImplResponse, error) {
+	sub, status, err := s.Service().ModifySessionWithQoSSubscription(scsAsId, subscriptionId, &asSessionWithQoSSubscriptionPatch)
+	if err != nil {
+		return models.Response(status, nil, ""), fmt.Errorf("Error: %w", err)
+	}
+	return models.Response(status, sub, ""), nil
+}
+
+// NotifyPcfEvents - Relays the PCF app session events to the AF.
+func (s *PcfNotificationAPIService) NotifyPcfEvents(ctx context.Context, scsAsId string, subscriptionId string, notifToken string, eventsNotification pcfclient.EventsNotification) (models.ImplResponse, error) {
+	status, err := s.Service().HandlePcfEventsNotification(scsAsId, subscriptionId, notifToken, &eventsNotification)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, nil, ""), nil
+}
 
-	return models.Response(http.StatusNotImplemented, nil, ""), errors.New("ModifyIndASSessionWithQoSSubscription method not implemented")
+// TerminatePcfAppSession - Handles a PCF initiated app session termination.
+func (s *PcfNotificationAPIService) TerminatePcfAppSession(ctx context.Context, scsAsId string, subscriptionId string, notifToken string, terminationInfo pcfclient.TerminationInfo) (models.ImplResponse, error) {
+	status, err := s.Service().HandlePcfTermination(scsAsId, subscriptionId, notifToken, &terminationInfo)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, nil, ""), nil
 }
@@ -0,0 +1,414 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+)
+
+// PcfNotificationsPath is the NEF endpoint the PCF reports app session events
+// and terminations to, served on the sbi listener only
+const PcfNotificationsPath = "/3gpp-as-session-with-qos/v1/pcf-notifications"
+
+// ------------------------------------------------------------------------------
+// HandlePcfEventsNotification relays an Npcf_PolicyAuthorization_Notify to the
+// AF notificationDestination of the subscription as UserPlaneNotificationData.
+func (s *Service) HandlePcfEventsNotification(afId string, subId string, token string, notif *pcfclient.EventsNotification) (int, error) {
+
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil || !validNotifToken(sub, token) {
+		return http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+
+	if appliedQosRef, ok := appliedQosReference(sub.Data, notif); ok && appliedQosRef != sub.Data.AppliedQosRef {
+		log.Printf("QoS in force for %s: %q", sub.Location(), appliedQosRef)
+		/*the fetched subscriptions are encoded outside of the AF lock, the
+		 * stored data is replaced and never modified in place*/
+		data := *sub.Data
+		data.AppliedQosRef = appliedQosRef
+		sub.Data = &data
+		if err := af.SaveAfSubscription(sub); err != nil {
+			log.Printf("could not store subscription %s: %s", subId, err)
+		}
+	}
+
+	upNotif := eventsNotification2UserPlane(sub.Location(), sub.Data, notif)
+	if len(upNotif.EventReports) == 0 {
+		log.Printf("no subscribed event in PCF notification for %s", sub.Location())
+		return http.StatusNoContent, nil
+	}
+
+	notificationUri := sub.Data.NotificationDestination
+	go func() {
+		if err := s.Connector().SendAfNotification(notificationUri, upNotif); err != nil {
+			log.Printf("%s", err)
+		}
+	}()
+	return http.StatusNoContent, nil
+}
+
+// ------------------------------------------------------------------------------
+// HandlePcfTermination handles a PCF initiated app session termination: the
+// subscription is released, the AF informed with a SESSION_TERMINATION event
+// and the app session removed from the PCF as required by TS 29.514. For group
+// subscriptions only the terminated member is released.
+func (s *Service) HandlePcfTermination(afId string, subId string, token string, info *pcfclient.TerminationInfo) (int, error) {
+
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil || !validNotifToken(sub, token) {
+		return http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+
+	appSessId := info.ResUri[strings.LastIndex(info.ResUri, "/")+1:]
+	if !slices.Contains(sub.AppSessIds(), appSessId) {
+		return http.StatusNotFound, fmt.Errorf("could not find app session %s", appSessId)
+	}
+	if info.TermCause.String != nil {
+		log.Printf("PCF terminated app session %s (%s)", appSessId, *info.TermCause.String)
+	}
+
+	if sub.RemoveAppSession(appSessId) {
+		/*other group members are still provisioned, only release this one*/
+		if err := af.SaveAfSubscription(sub); err != nil {
+			log.Printf("could not store subscription %s: %s", subId, err)
+		}
+		go func() {
+			if err := s.Connector().RemovePolicyAuthzSubscription(appSessId); err != nil {
+				log.Printf("%s", err)
+			}
+		}()
+		return http.StatusNoContent, nil
+	}
+
+	if err := af.DeleteAfscription(subId); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	s.lifecycle.cancel(sub.Location())
+
+	notificationUri := sub.Data.NotificationDestination
+	upNotif := sessionTerminationNotification(sub)
+	go func() {
+		if err := s.Connector().RemovePolicyAuthzSubscription(appSessId); err != nil {
+			log.Printf("%s", err)
+		}
+		if err := s.Connector().SendAfNotification(notificationUri, upNotif); err != nil {
+			log.Printf("%s", err)
+		}
+	}()
+	return http.StatusNoContent, nil
+}
+
+// ------------------------------------------------------------------------------
+// pcfNotifUri is the sbi listener uri the PCF notifies the app sessions of the
+// subscription to, carrying the random token of the subscription
+func (s *Service) pcfNotifUri(afId string, sub *contexts.AfSubscriptionCtx) string {
+	base := s.Cfg().Sbi.CallbackUri
+	if len(base) == 0 {
+		base = fmt.Sprintf("http://%s:%d", s.Cfg().Nbi.Fqdn, s.Cfg().Sbi.Port)
+	}
+	return base + PcfNotificationsPath + "/" + afId + "/subscriptions/" + sub.Data.Self + "/" + sub.NotifToken
+}
+
+// ------------------------------------------------------------------------------
+// validNotifToken tells whether a PCF callback carries the token of the subscription
+func validNotifToken(sub *contexts.AfSubscriptionCtx, token string) bool {
+	return len(sub.NotifToken) > 0 && subtle.ConstantTimeCompare([]byte(sub.NotifToken), []byte(token)) == 1
+}
+
+// ------------------------------------------------------------------------------
+// sessionWithQoS2EventsSubsc builds the PCF events subscription matching the
+// subscription events, nil when none of them needs a PCF subscription.
+func sessionWithQoS2EventsSubsc(notifUri string, data *models.AsSessionWithQoSSubscription) *pcfclient.EventsSubscReqData {
+
+	events := []pcfclient.AfEventSubscription{}
+	subscribed := map[string]bool{}
+	nefEvents := data.Events
+	if len(data.AltQoSReferences) > 0 || len(data.AltQosReqs) > 0 {
+		/*the NEF tracks which alternative QoS the PCF falls back to*/
+		nefEvents = append(slices.Clone(nefEvents),
+			models.UserPlaneEventQosNotGuaranteed, models.UserPlaneEventSuccessfulResourcesAllocation)
+	}
+	for _, ev := range nefEvents {
+		afEvent := userPlaneEvent2AfEvent(ev)
+		if len(afEvent) == 0 || subscribed[afEvent] {
+			continue
+		}
+		subscribed[afEvent] = true
+
+		evSubsc := pcfclient.AfEventSubscription{
+			Event:       pcfclient.AfEvent{String: pcfclient.PtrString(afEvent)},
+			NotifMethod: &pcfclient.AfNotifMethod{String: pcfclient.PtrString("EVENT_DETECTION")},
+		}
+		if afEvent == "QOS_MONITORING" {
+			qosMonNotifMethod(&evSubsc, data.QosMonInfo)
+		}
+		events = append(events, evSubsc)
+	}
+	if len(events) == 0 {
+		return nil
+	}
+
+	req := &pcfclient.EventsSubscReqData{
+		Events:   events,
+		NotifUri: pcfclient.PtrString(notifUri + "/notify"),
+	}
+	if subscribed["USAGE_REPORT"] && data.UsageThreshold != (models.UsageThreshold{}) {
+		req.UsgThres = &pcfclient.UsageThreshold{}
+		if data.UsageThreshold.Duration > 0 {
+			req.UsgThres.SetDuration(data.UsageThreshold.Duration)
+		}
+		if data.UsageThreshold.TotalVolume > 0 {
+			req.UsgThres.SetTotalVolume(data.UsageThreshold.TotalVolume)
+		}
+		if data.UsageThreshold.DownlinkVolume > 0 {
+			req.UsgThres.SetDownlinkVolume(data.UsageThreshold.DownlinkVolume)
+		}
+		if data.UsageThreshold.UplinkVolume > 0 {
+			req.UsgThres.SetUplinkVolume(data.UsageThreshold.UplinkVolume)
+		}
+	}
+	if subscribed["QOS_MONITORING"] {
+		for _, param := range data.QosMonInfo.ReqQosMonParams {
+			req.ReqQosMonParams = append(req.ReqQosMonParams,
+				pcfclient.RequestedQosMonitoringParameter{String: pcfclient.PtrString(string(param))})
+		}
+		req.QosMon = &pcfclient.QosMonitoringInformation{}
+		if data.QosMonInfo.RepThreshDl > 0 {
+			req.QosMon.SetRepThreshDl(data.QosMonInfo.RepThreshDl)
+		}
+		if data.QosMonInfo.RepThreshUl > 0 {
+			req.QosMon.SetRepThreshUl(data.QosMonInfo.RepThreshUl)
+		}
+		if data.QosMonInfo.RepThreshRp > 0 {
+			req.QosMon.SetRepThreshRp(data.QosMonInfo.RepThreshRp)
+		}
+	}
+	return req
+}
+
+// ------------------------------------------------------------------------------
+func qosMonNotifMethod(evSubsc *pcfclient.AfEventSubscription, qosMon models.QosMonitoringInformation) {
+	for _, freq := range qosMon.RepFreqs {
+		switch freq {
+		case models.ReportingFrequencyPeriodic:
+			evSubsc.NotifMethod = &pcfclient.AfNotifMethod{String: pcfclient.PtrString("PERIODIC")}
+			if qosMon.RepPeriod > 0 {
+				evSubsc.SetRepPeriod(qosMon.RepPeriod)
+			}
+		case models.ReportingFrequencySessionRelease:
+			evSubsc.NotifMethod = &pcfclient.AfNotifMethod{String: pcfclient.PtrString("ONE_TIME")}
+		}
+	}
+	if qosMon.WaitTime > 0 {
+		evSubsc.SetWaitTime(qosMon.WaitTime)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// userPlaneEvent2AfEvent maps a NEF user plane event to the Npcf AfEvent
+// reporting it, empty when the event is not subscribed at the PCF.
+func userPlaneEvent2AfEvent(ev models.UserPlaneEvent) string {
+	switch ev {
+	case models.UserPlaneEventQosGuaranteed, models.UserPlaneEventQosNotGuaranteed:
+		return "QOS_NOTIF"
+	case models.UserPlaneEventL4sAvailable, models.UserPlaneEventL4sNotAvailable:
+		return "L4S_SUPP"
+	case models.UserPlaneEventPackDelayVar:
+		return "PACK_DEL_VAR"
+	case models.UserPlaneEventUsageReport,
+		models.UserPlaneEventFailedResourcesAllocation,
+		models.UserPlaneEventSuccessfulResourcesAllocation,
+		models.UserPlaneEventQosMonitoring,
+		models.UserPlaneEventAccessTypeChange,
+		models.UserPlaneEventPlmnChg,
+		models.UserPlaneEventBatOffsetInfo,
+		models.UserPlaneEventRtDelayTwoQosFlows:
+		return string(ev)
+	default:
+		/*SESSION_TERMINATION is always reported through the app session notifUri*/
+		return ""
+	}
+}
+
+// ------------------------------------------------------------------------------
+// eventsNotification2UserPlane translates an Npcf EventsNotification into the
+// NEF notification format, keeping only the events subscribed by the AF.
+func eventsNotification2UserPlane(transaction string, data *models.AsSessionWithQoSSubscription, notif *pcfclient.EventsNotification) *models.UserPlaneNotificationData {
+
+	upNotif := &models.UserPlaneNotificationData{
+		Transaction:  transaction,
+		EventReports: []models.UserPlaneEventReport{},
+	}
+	addReport := func(report models.UserPlaneEventReport) {
+		if slices.Contains(data.Events, report.Event) {
+			upNotif.EventReports = append(upNotif.EventReports, report)
+		}
+	}
+
+	for _, evNotif := range notif.EvNotifs {
+		if evNotif.Event.String == nil {
+			continue
+		}
+		switch *evNotif.Event.String {
+		case "QOS_NOTIF":
+			for _, qnc := range notif.QncReports {
+				report := models.UserPlaneEventReport{
+					Event:            models.UserPlaneEventQosNotGuaranteed,
+					FlowIds:          flowIds(qnc.Flows),
+					AppliedQosRef:    data.AppliedQosRef,
+					AltQosNotSuppInd: qnc.GetAltSerReqNotSuppInd(),
+				}
+				if qnc.NotifType.String != nil && *qnc.NotifType.String == "GUARANTEED" {
+					report.Event = models.UserPlaneEventQosGuaranteed
+				}
+				addReport(report)
+			}
+		case "FAILED_RESOURCES_ALLOCATION":
+			report := models.UserPlaneEventReport{Event: models.UserPlaneEventFailedResourcesAllocation}
+			for _, info := range notif.FailedResourcAllocReports {
+				report.FlowIds = append(report.FlowIds, flowIds(info.Flows)...)
+			}
+			addReport(report)
+		case "SUCCESSFUL_RESOURCES_ALLOCATION":
+			report := models.UserPlaneEventReport{
+				Event:         models.UserPlaneEventSuccessfulResourcesAllocation,
+				AppliedQosRef: data.AppliedQosRef,
+			}
+			for _, info := range notif.SuccResourcAllocReports {
+				report.FlowIds = append(report.FlowIds, flowIds(info.Flows)...)
+			}
+			addReport(report)
+		case "USAGE_REPORT":
+			report := models.UserPlaneEventReport{Event: models.UserPlaneEventUsageReport}
+			if notif.UsgRep != nil {
+				report.AccumulatedUsage = models.AccumulatedUsage{
+					Duration:       notif.UsgRep.GetDuration(),
+					TotalVolume:    notif.UsgRep.GetTotalVolume(),
+					DownlinkVolume: notif.UsgRep.GetDownlinkVolume(),
+					UplinkVolume:   notif.UsgRep.GetUplinkVolume(),
+				}
+			}
+			addReport(report)
+		case "QOS_MONITORING":
+			report := models.UserPlaneEventReport{Event: models.UserPlaneEventQosMonitoring}
+			for _, qosMon := range notif.QosMonReports {
+				report.QosMonReports = append(report.QosMonReports, qosMonitoringReport2Nef(qosMon))
+			}
+			addReport(report)
+		case "PLMN_CHG":
+			report := models.UserPlaneEventReport{Event: models.UserPlaneEventPlmnChg}
+			if notif.PlmnId != nil {
+				report.PlmnId = models.PlmnIdNid{
+					Mcc: notif.PlmnId.Mcc,
+					Mnc: notif.PlmnId.Mnc,
+					Nid: notif.PlmnId.GetNid(),
+				}
+			}
+			addReport(report)
+		case "ACCESS_TYPE_CHANGE":
+			addReport(models.UserPlaneEventReport{Event: models.UserPlaneEventAccessTypeChange})
+		default:
+			log.Printf("PCF event %s is not relayed to the AF", *evNotif.Event.String)
+		}
+	}
+	return upNotif
+}
+
+// ------------------------------------------------------------------------------
+// appliedQosReference tells which QoS reference is in force after a PCF
+// notification: the alternative service requirement the PCF fell back to, or
+// the requested qosReference once it is guaranteed again.
+func appliedQosReference(data *models.AsSessionWithQoSSubscription, notif *pcfclient.EventsNotification) (string, bool) {
+	applied, found := "", false
+	for _, info := range notif.SuccResourcAllocReports {
+		applied, found = info.GetAltSerReq(), true
+		if len(applied) == 0 {
+			applied = data.QosReference
+		}
+	}
+	for _, qnc := range notif.QncReports {
+		found = true
+		if qnc.NotifType.String != nil && *qnc.NotifType.String == "GUARANTEED" {
+			applied = data.QosReference
+		} else {
+			/*no alternative QoS means none of the requested QoS is in force*/
+			applied = qnc.GetAltSerReq()
+		}
+	}
+	return applied, found
+}
+
+// ------------------------------------------------------------------------------
+func flowIds(flows []pcfclient.Flows) []int32 {
+	ids := []int32{}
+	for _, flow := range flows {
+		ids = append(ids, flow.FNums...)
+	}
+	if len(ids) == 0 {
+		/*no flow number means the event applies to all the flows*/
+		return nil
+	}
+	return ids
+}
+
+// ------------------------------------------------------------------------------
+func qosMonitoringReport2Nef(qosMon pcfclient.QosMonitoringReport) models.QosMonitoringReport {
+	report := models.QosMonitoringReport{
+		UlDelays:   qosMon.UlDelays,
+		DlDelays:   qosMon.DlDelays,
+		RtDelays:   qosMon.RtDelays,
+		Pdmf:       qosMon.GetPdmf(),
+		UlDataRate: qosMon.GetUlDataRate(),
+		DlDataRate: qosMon.GetDlDataRate(),
+	}
+	if len(qosMon.UlConInfo) > 0 {
+		report.UlConInfo = qosMon.UlConInfo[0]
+	}
+	if len(qosMon.DlConInfo) > 0 {
+		report.DlConInfo = qosMon.DlConInfo[0]
+	}
+	return report
+}
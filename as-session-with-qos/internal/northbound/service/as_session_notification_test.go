@@ -0,0 +1,208 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"strings"
+	"testing"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+)
+
+func TestSessionWithQoS2EventsSubsc(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		Events: []models.UserPlaneEvent{
+			models.UserPlaneEventQosGuaranteed,
+			models.UserPlaneEventQosNotGuaranteed,
+			models.UserPlaneEventUsageReport,
+			models.UserPlaneEventSessionTermination,
+		},
+		UsageThreshold: models.UsageThreshold{TotalVolume: 1000},
+	}
+
+	evSubsc := sessionWithQoS2EventsSubsc("http://nef/notif", data)
+	if evSubsc == nil {
+		t.Fatalf("expected an events subscription, got nil")
+	}
+	if len(evSubsc.Events) != 2 {
+		t.Errorf("expected 2 PCF events, got %d", len(evSubsc.Events))
+	}
+	if evSubsc.GetNotifUri() != "http://nef/notif/notify" {
+		t.Errorf("expected notifUri http://nef/notif/notify, got %s", evSubsc.GetNotifUri())
+	}
+	if evSubsc.UsgThres == nil || evSubsc.UsgThres.GetTotalVolume() != 1000 {
+		t.Errorf("expected usage threshold to be forwarded")
+	}
+
+	noEvents := &models.AsSessionWithQoSSubscription{
+		Events: []models.UserPlaneEvent{models.UserPlaneEventSessionTermination},
+	}
+	if evSubsc := sessionWithQoS2EventsSubsc("http://nef/notif", noEvents); evSubsc != nil {
+		t.Errorf("expected no events subscription, got %v", evSubsc)
+	}
+}
+
+func TestEventsNotification2UserPlane(t *testing.T) {
+	notif := &pcfclient.EventsNotification{
+		EvNotifs: []pcfclient.AfEventNotification{
+			{Event: pcfclient.AfEvent{String: pcfclient.PtrString("QOS_NOTIF")}},
+			{Event: pcfclient.AfEvent{String: pcfclient.PtrString("USAGE_REPORT")}},
+		},
+		QncReports: []pcfclient.QosNotificationControlInfo{{
+			NotifType: pcfclient.QosNotifType{String: pcfclient.PtrString("NOT_GUARANTEED")},
+			Flows:     []pcfclient.Flows{{MedCompN: 1, FNums: []int32{1, 2}}},
+		}},
+		UsgRep: &pcfclient.AccumulatedUsage{TotalVolume: pcfclient.PtrInt64(42)},
+	}
+
+	data := &models.AsSessionWithQoSSubscription{
+		Events: []models.UserPlaneEvent{models.UserPlaneEventQosNotGuaranteed, models.UserPlaneEventUsageReport},
+	}
+	upNotif := eventsNotification2UserPlane("/sub", data, notif)
+	if upNotif.Transaction != "/sub" {
+		t.Errorf("expected transaction /sub, got %s", upNotif.Transaction)
+	}
+	if len(upNotif.EventReports) != 2 {
+		t.Fatalf("expected 2 event reports, got %d", len(upNotif.EventReports))
+	}
+	if upNotif.EventReports[0].Event != models.UserPlaneEventQosNotGuaranteed || len(upNotif.EventReports[0].FlowIds) != 2 {
+		t.Errorf("unexpected QoS report %v", upNotif.EventReports[0])
+	}
+	if upNotif.EventReports[1].AccumulatedUsage.TotalVolume != 42 {
+		t.Errorf("expected total volume 42, got %d", upNotif.EventReports[1].AccumulatedUsage.TotalVolume)
+	}
+
+	/*events not subscribed by the AF are filtered out*/
+	data.Events = []models.UserPlaneEvent{models.UserPlaneEventQosGuaranteed}
+	upNotif = eventsNotification2UserPlane("/sub", data, notif)
+	if len(upNotif.EventReports) != 0 {
+		t.Errorf("expected no event reports, got %d", len(upNotif.EventReports))
+	}
+}
+
+func TestAppliedQosReference(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		QosReference:     "qos4",
+		AltQoSReferences: []string{"qos3", "qos2"},
+	}
+
+	degraded := &pcfclient.EventsNotification{
+		QncReports: []pcfclient.QosNotificationControlInfo{{
+			NotifType: pcfclient.QosNotifType{String: pcfclient.PtrString("NOT_GUARANTEED")},
+			AltSerReq: pcfclient.PtrString("qos3"),
+		}},
+	}
+	applied, ok := appliedQosReference(data, degraded)
+	if !ok || applied != "qos3" {
+		t.Errorf("expected applied QoS qos3, got %q", applied)
+	}
+
+	restored := &pcfclient.EventsNotification{
+		QncReports: []pcfclient.QosNotificationControlInfo{{
+			NotifType: pcfclient.QosNotifType{String: pcfclient.PtrString("GUARANTEED")},
+		}},
+	}
+	applied, ok = appliedQosReference(data, restored)
+	if !ok || applied != "qos4" {
+		t.Errorf("expected applied QoS qos4, got %q", applied)
+	}
+
+	usage := &pcfclient.EventsNotification{UsgRep: &pcfclient.AccumulatedUsage{}}
+	if _, ok := appliedQosReference(data, usage); ok {
+		t.Errorf("expected no applied QoS change for a usage report")
+	}
+}
+
+func TestSessionWithQoS2EventsSubscAltQos(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		AltQoSReferences: []string{"qos3"},
+	}
+
+	evSubsc := sessionWithQoS2EventsSubsc("http://nef/notif", data)
+	if evSubsc == nil {
+		t.Fatalf("expected an events subscription, got nil")
+	}
+	if len(evSubsc.Events) != 2 {
+		t.Errorf("expected QOS_NOTIF and SUCCESSFUL_RESOURCES_ALLOCATION, got %d events", len(evSubsc.Events))
+	}
+}
+
+func TestPcfNotifUri(t *testing.T) {
+	s := newTestService()
+	s.Cfg().Nbi.Fqdn = "nef"
+	s.Cfg().Sbi.Port = 8081
+	af := contexts.NewAf("af1")
+	_, sub := af.NewAfSubscription(&models.AsSessionWithQoSSubscription{})
+	_, other := af.NewAfSubscription(&models.AsSessionWithQoSSubscription{})
+
+	uri := s.pcfNotifUri("af1", sub)
+	if !strings.HasPrefix(uri, "http://nef:8081"+PcfNotificationsPath+"/af1/subscriptions/"+sub.Data.Self+"/") {
+		t.Fatalf("unexpected notification uri %s", uri)
+	}
+	token := uri[strings.LastIndex(uri, "/")+1:]
+	if !validNotifToken(sub, token) {
+		t.Errorf("token of the notification uri rejected")
+	}
+	if validNotifToken(other, token) || validNotifToken(sub, "") {
+		t.Errorf("token of another subscription accepted")
+	}
+	sub.NotifToken = ""
+	if validNotifToken(sub, "") {
+		t.Errorf("empty token accepted")
+	}
+}
+
+func TestHandlePcfEventsNotificationAppliedQos(t *testing.T) {
+	app := &testApp{cfg: &config.AppConfig{}}
+	app.ctx = contexts.NewAsSessionAppCtx(app, contexts.NewMemoryStore())
+	s := NewAsSessionWithQoSService(app)
+	af := app.ctx.AddAf("af1")
+	_, sub := af.NewAfSubscription(&models.AsSessionWithQoSSubscription{
+		QosReference:     "qos4",
+		AltQoSReferences: []string{"qos3"},
+		AppliedQosRef:    "qos4",
+	})
+	uri := s.pcfNotifUri("af1", sub)
+	token := uri[strings.LastIndex(uri, "/")+1:]
+
+	fetched, _, err := s.FetchSessionWithQoSSubscription("af1", sub.Data.Self)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	degraded := &pcfclient.EventsNotification{
+		QncReports: []pcfclient.QosNotificationControlInfo{{
+			NotifType: pcfclient.QosNotifType{String: pcfclient.PtrString("NOT_GUARANTEED")},
+			AltSerReq: pcfclient.PtrString("qos3"),
+		}},
+	}
+	if status, err := s.HandlePcfEventsNotification("af1", sub.Data.Self, token, degraded); err != nil {
+		t.Fatalf("got status %d, error %s", status, err.Error())
+	}
+	/*a subscription already fetched is not modified by the notification*/
+	if fetched.AppliedQosRef != "qos4" {
+		t.Errorf("got fetched appliedQosRef %s, wanted qos4", fetched.AppliedQosRef)
+	}
+	if sub.Data.AppliedQosRef != "qos3" {
+		t.Errorf("got stored appliedQosRef %s, wanted qos3", sub.Data.AppliedQosRef)
+	}
+}
@@ -20,14 +20,21 @@
 package service
 
 import (
+	"cmp"
 	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/connector"
 	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
 	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 )
 
@@ -35,20 +42,24 @@ type app interface {
 	Cfg() *config.AppConfig
 	Connector() *connector.Connector
 	Ctx() *contexts.AsSessionAppCtx
+	Policies() *afpolicy.Registry
 }
 
 type Service struct {
 	app
+	lifecycle *lifecycleManager
 }
 
 func NewAsSessionWithQoSService(app app) *Service {
-	svc := &Service{app: app}
+	svc := &Service{app: app, lifecycle: newLifecycleManager()}
 	return svc
 }
 
 // ------------------------------------------------------------------------------
-func (s *Service) PostSessionWithQoSSubscription(afId string, data *models.AsSessionWithQoSSubscription) (string, int, error) {
-	var loc string
+// PostSessionWithQoSSubscription creates the subscription of the AF. When an
+// idempotency key is given, a retry of a previous creation returns the
+// original subscription instead of creating a new one.
+func (s *Service) PostSessionWithQoSSubscription(afId string, idempotencyKey string, data *models.AsSessionWithQoSSubscription) (string, int, error) {
 
 	af := s.Ctx().GetAf(afId)
 	if af == nil {
@@ -58,28 +69,244 @@ func (s *Service) PostSessionWithQoSSubscription(afId string, data *models.AsSes
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
 
-	if len(data.Gpsi) > 0 || len(data.UeIpv4Addr) > 0 || len(data.UeIpv6Addr) > 0 {
+	reqHash := requestHash(data)
+	if sub, err := idempotentReplay(af, idempotencyKey, reqHash); err != nil {
+		return "", http.StatusConflict, err
+	} else if sub != nil {
+		*data = *sub.Data
+		return sub.Location(), http.StatusCreated, nil
+	}
+
+	if err := policyProblem(s.Policies().Policy(afId).CheckSubscriptions(len(af.GetAfSubscriptions()))); err != nil {
+		return "", http.StatusForbidden, err
+	}
+	if err := s.quotaProblem(afId, len(af.GetAfSubscriptions())); err != nil {
+		return "", http.StatusTooManyRequests, err
+	}
+	if err := s.authorizeSessionWithQoS(afId, data); err != nil {
+		return "", http.StatusForbidden, err
+	}
+
+	return s.postSessionWithQoSSubscription(afId, af, data, idempotencyKey, reqHash)
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) postSessionWithQoSSubscription(afId string, af *contexts.AppFunctionCtx, data *models.AsSessionWithQoSSubscription, idempotencyKey string, reqHash string) (string, int, error) {
+	var loc string
+	data.FailedMembers = nil
+
+	if len(data.Gpsi) > 0 || hasUeAddress(data) {
 		// Single UE, sent to PCF
 		pa_ctx, err := s.sessionWithQoS2PolicyAuthz(afId, data)
 		if err == nil {
+			/*the PCF notifies the NEF, which relays the events to the AF*/
+			tiLoc, subCtx := af.NewAfSubscription(data)
+			subCtx.IdempotencyKey = idempotencyKey
+			subCtx.RequestHash = reqHash
+			notifUri := s.pcfNotifUri(afId, subCtx)
+			pa_ctx.AscReqData.Get().NotifUri = notifUri
+
 			loc, err = s.Connector().CreatePolicyAuthzSubscription(*pa_ctx)
 			if err != nil {
+				_ = af.DeleteAfscription(data.Self)
 				return "", http.StatusInternalServerError, fmt.Errorf("could not create PCF Policy Authorization context")
 			}
-			tiLoc, subCtx := af.NewAfSubscription(data)
 			subCtx.AppSessId = loc
+			data.AppliedQosRef = data.QosReference
+
+			if evSubsc := sessionWithQoS2EventsSubsc(notifUri, data); evSubsc != nil {
+				err = s.Connector().SubscribePolicyAuthzEvents(loc, *evSubsc)
+				if err != nil {
+					_ = s.Connector().RemovePolicyAuthzSubscription(loc)
+					_ = af.DeleteAfscription(data.Self)
+					return "", http.StatusInternalServerError, fmt.Errorf("could not subscribe to PCF events")
+				}
+			}
+			subCtx.ExpiryTime = qosExpiryTime(data)
+			s.scheduleExpiry(afId, subCtx)
+			if err := af.SaveAfSubscription(subCtx); err != nil {
+				log.Printf("could not store subscription %s: %s", data.Self, err)
+			}
 			return tiLoc, http.StatusCreated, nil
 		} else {
-			return "", http.StatusBadRequest, err
+			return "", errorStatus(err, http.StatusBadRequest), err
 		}
 	} else if len(data.ExtGroupId) > 0 {
-		return "", http.StatusNotImplemented, fmt.Errorf("udr policies not supported yet")
+		// Group of UEs, one PCF app session per member
+		return s.postGroupSessionWithQoSSubscription(afId, af, data, idempotencyKey, reqHash)
 	} else {
 		return "", http.StatusNotImplemented, fmt.Errorf("not in the single or multiple UE cases")
 	}
 
 }
 
+// ------------------------------------------------------------------------------
+// postGroupSessionWithQoSSubscription resolves the members of the external
+// group and creates one PCF app session per member. The subscription is kept
+// with the members that could be provisioned, the failing ones being reported
+// as InvalidParams in its failedMembers, and is only rejected when no member
+// could be provisioned. Called with af.Mu held, which is released while the
+// member app sessions are created.
+func (s *Service) postGroupSessionWithQoSSubscription(afId string, af *contexts.AppFunctionCtx, data *models.AsSessionWithQoSSubscription, idempotencyKey string, reqHash string) (string, int, error) {
+
+	if !s.Connector().HasGroupStore() {
+		return "", http.StatusNotImplemented, fmt.Errorf("group store not configured")
+	}
+	if err := validateSubscriptionData(data); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	if _, err := s.qosProfile("/qosReference", data.QosReference); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	members, err := s.Connector().GetGroupMembers(data.ExtGroupId)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("could not resolve extGroupId")
+	}
+	if len(members) == 0 {
+		return "", http.StatusNotFound, fmt.Errorf("could not find extGroupId")
+	}
+
+	/*the subscription is reserved, so that it counts in the quotas and a retry
+	 * finds its idempotency key, before the AF lock is released*/
+	tiLoc, subCtx := af.NewAfSubscription(data)
+	subCtx.IdempotencyKey = idempotencyKey
+	subCtx.RequestHash = reqHash
+	notifUri := s.pcfNotifUri(afId, subCtx)
+
+	af.Mu.Unlock()
+	results := s.createMemberAppSessions(afId, notifUri, members, data)
+	af.Mu.Lock()
+
+	status := http.StatusBadRequest
+	appSessIds := make(map[string]string)
+	failedMembers := []models.InvalidParam{}
+	for _, result := range results {
+		if result.err != nil {
+			log.Printf("group %s member %s: %s", data.ExtGroupId, result.supi, result.err)
+			/*the SUPI is never exposed to the AF*/
+			reason := result.err.Error()
+			if len(result.gpsi) > 0 {
+				reason = result.gpsi + ": " + reason
+			}
+			failedMembers = append(failedMembers, models.InvalidParam{Param: "/extGroupId", Reason: reason})
+			status = max(status, result.status)
+			continue
+		}
+		appSessIds[result.supi] = result.appSessId
+	}
+
+	if len(appSessIds) == 0 || af.GetAfSubscription(data.Self) != subCtx {
+		for _, appSessId := range appSessIds {
+			if err := s.Connector().RemovePolicyAuthzSubscription(appSessId); err != nil {
+				log.Printf("%s", err)
+			}
+		}
+		_ = af.DeleteAfscription(data.Self)
+		return "", status, &models.ProblemError{Problem: models.ProblemDetails{
+			Title:         "Group provisioning failed",
+			Detail:        fmt.Sprintf("could not provision any of the %d group members", len(members)),
+			InvalidParams: failedMembers,
+		}}
+	}
+	subCtx.MemberAppSessIds = appSessIds
+	if len(failedMembers) > 0 {
+		data.FailedMembers = failedMembers
+	}
+	data.AppliedQosRef = data.QosReference
+	subCtx.ExpiryTime = qosExpiryTime(data)
+	s.scheduleExpiry(afId, subCtx)
+	if err := af.SaveAfSubscription(subCtx); err != nil {
+		log.Printf("could not store subscription %s: %s", data.Self, err)
+	}
+	return tiLoc, http.StatusCreated, nil
+}
+
+// groupWorkers bounds the PCF app sessions of a group created concurrently
+const groupWorkers = 8
+
+// memberAppSession is the outcome of the PCF app session creation of a member
+type memberAppSession struct {
+	supi      string
+	gpsi      string
+	appSessId string
+	status    int
+	err       error
+}
+
+// ------------------------------------------------------------------------------
+// createMemberAppSessions creates the PCF app sessions of the group members,
+// up to groupWorkers at once
+func (s *Service) createMemberAppSessions(afId string, notifUri string, members []string, data *models.AsSessionWithQoSSubscription) []memberAppSession {
+	results := make([]memberAppSession, len(members))
+	workers := make(chan struct{}, groupWorkers)
+	var wg sync.WaitGroup
+	for i, supi := range members {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-workers }()
+			results[i] = s.createMemberAppSession(afId, notifUri, supi, data)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// ------------------------------------------------------------------------------
+// createMemberAppSession creates the PCF app session of a group member on its
+// PDU session matching the subscription dnn/slice.
+func (s *Service) createMemberAppSession(afId string, notifUri string, supi string, data *models.AsSessionWithQoSSubscription) memberAppSession {
+	result := memberAppSession{supi: supi, status: http.StatusCreated}
+
+	ueProfile, err := s.Connector().GetUeProfile(supi)
+	if err != nil {
+		result.status, result.err = http.StatusNotFound, fmt.Errorf("unknown UE")
+		return result
+	}
+	result.gpsi = ueProfile.Gpsi
+	ueIpv4 := ueProfile.GetSessionIpv4(data.Dnn, data.Snssai)
+	if len(ueIpv4) == 0 {
+		result.status, result.err = http.StatusNotFound, fmt.Errorf("no active PDU session")
+		return result
+	}
+	/*each member is bound to the UE address ranges of the AF*/
+	if err := s.Policies().Policy(afId).CheckUeAddress("/extGroupId", ueIpv4); err != nil {
+		result.status, result.err = http.StatusForbidden, err
+		return result
+	}
+
+	memberData := *data
+	memberData.UeIpv4Addr = ueIpv4
+	pa_ctx, err := s.sessionWithQoS2PolicyAuthz(afId, &memberData)
+	if err != nil {
+		result.status, result.err = http.StatusBadRequest, err
+		return result
+	}
+	req := pa_ctx.AscReqData.Get()
+	req.NotifUri = notifUri
+	req.SetSupi(supi)
+	if len(ueProfile.Gpsi) > 0 {
+		req.SetGpsi(ueProfile.Gpsi)
+	}
+
+	appSessId, err := s.Connector().CreatePolicyAuthzSubscription(*pa_ctx)
+	if err != nil {
+		result.status, result.err = http.StatusInternalServerError, fmt.Errorf("could not create PCF Policy Authorization context")
+		return result
+	}
+	if evSubsc := sessionWithQoS2EventsSubsc(notifUri, data); evSubsc != nil {
+		if err := s.Connector().SubscribePolicyAuthzEvents(appSessId, *evSubsc); err != nil {
+			_ = s.Connector().RemovePolicyAuthzSubscription(appSessId)
+			result.status, result.err = http.StatusInternalServerError, fmt.Errorf("could not subscribe to PCF events")
+			return result
+		}
+	}
+	result.appSessId = appSessId
+	return result
+}
+
 // ------------------------------------------------------------------------------
 func (s *Service) DeleteSessionWithQoSSubscription(afId string, subId string) (int, error) {
 
@@ -91,17 +318,21 @@ func (s *Service) DeleteSessionWithQoSSubscription(afId string, subId string) (i
 
 		sub := af.GetAfSubscription(subId)
 		if sub != nil {
-			if len(sub.AppSessId) > 0 {
-				err := s.Connector().RemovePolicyAuthzSubscription(sub.AppSessId)
-				if err != nil {
-					return http.StatusInternalServerError, err
-				} else {
-					err = af.DeleteAfscription(subId)
+			appSessIds := sub.AppSessIds()
+			if len(appSessIds) > 0 {
+				for _, appSessId := range appSessIds {
+					err := s.Connector().RemovePolicyAuthzSubscription(appSessId)
 					if err != nil {
 						return http.StatusInternalServerError, err
 					}
-					return http.StatusNoContent, nil
+					sub.RemoveAppSession(appSessId)
+				}
+				s.lifecycle.cancel(sub.Location())
+				err := af.DeleteAfscription(subId)
+				if err != nil {
+					return http.StatusInternalServerError, err
 				}
+				return http.StatusNoContent, nil
 			}
 		}
 	}
@@ -126,6 +357,121 @@ func (s *Service) FetchSessionWithQoSSubscription(afId string, subId string) (*m
 	return nil, http.StatusNotFound, fmt.Errorf("could not find af/subId")
 }
 
+// ------------------------------------------------------------------------------
+func (s *Service) FetchAllSessionWithQoSSubscriptions(afId string, ipAddrs []string, ipDomain string, macAddrs []string) ([]*models.AsSessionWithQoSSubscription, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+
+	if af != nil {
+		af.Mu.Lock()
+		defer af.Mu.Unlock()
+
+		result := []*models.AsSessionWithQoSSubscription{}
+		for _, sub := range af.GetAfSubscriptions() {
+			if matchSubscriptionFilters(sub.Data, ipAddrs, ipDomain, macAddrs) {
+				result = append(result, sub.Data)
+			}
+		}
+		return result, http.StatusOK, nil
+	}
+	/*an AF without subscriptions yet has none to list*/
+	return []*models.AsSessionWithQoSSubscription{}, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) UpdateSessionWithQoSSubscription(afId string, subId string, data *models.AsSessionWithQoSSubscription) (*models.AsSessionWithQoSSubscription, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+
+	if af != nil {
+		af.Mu.Lock()
+		defer af.Mu.Unlock()
+
+		sub := af.GetAfSubscription(subId)
+		if sub != nil && len(sub.AppSessIds()) > 0 {
+			if err := s.authorizeSessionWithQoS(afId, data); err != nil {
+				return nil, http.StatusForbidden, err
+			}
+			return s.applySubscriptionUpdate(afId, af, sub, data)
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) ModifySessionWithQoSSubscription(afId string, subId string, patch *models.AsSessionWithQoSSubscriptionPatch) (*models.AsSessionWithQoSSubscription, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+
+	if af != nil {
+		af.Mu.Lock()
+		defer af.Mu.Unlock()
+
+		sub := af.GetAfSubscription(subId)
+		if sub != nil && len(sub.AppSessIds()) > 0 {
+			data := mergeSubscriptionPatch(sub.Data, patch)
+			if err := s.authorizeSessionWithQoS(afId, data); err != nil {
+				return nil, http.StatusForbidden, err
+			}
+			return s.applySubscriptionUpdate(afId, af, sub, data)
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+}
+
+// ------------------------------------------------------------------------------
+// applySubscriptionUpdate pushes the new subscription data to the PCF app
+// sessions and only stores it once the PCF accepted the modification of all
+// of them, so that the stored data always reflects what the PCF holds.
+func (s *Service) applySubscriptionUpdate(afId string, af *contexts.AppFunctionCtx, sub *contexts.AfSubscriptionCtx, data *models.AsSessionWithQoSSubscription) (*models.AsSessionWithQoSSubscription, int, error) {
+
+	/*UE identity, dnn and slice identify the PCF app session and cannot be changed*/
+	if !sameSessionTarget(sub.Data, data) {
+		return nil, http.StatusBadRequest, fmt.Errorf("UE address, group, dnn and snssai cannot be modified")
+	}
+
+	patch, err := s.sessionWithQoS2PolicyAuthzUpdate(afId, sub.Data, data)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	/*the reverse patch restores the stored data, nil if it cannot be built*/
+	revert, err := s.sessionWithQoS2PolicyAuthzUpdate(afId, data, sub.Data)
+	if err != nil {
+		log.Printf("could not build the revert of %s: %s", sub.Data.Self, err)
+		revert = nil
+	}
+
+	notifUri := s.pcfNotifUri(afId, sub)
+	appSessIds := sub.AppSessIds()
+	for i, appSessId := range appSessIds {
+		if err := s.updateAppSession(notifUri, appSessId, patch, sub.Data, data); err != nil {
+			/*the group members already modified, and the failing one which may
+			 * be half modified, are reverted to keep matching the stored data*/
+			for _, modified := range appSessIds[:i+1] {
+				if revert == nil || s.updateAppSession(notifUri, modified, revert, data, sub.Data) != nil {
+					log.Printf("could not revert PCF app session %s of %s", modified, sub.Data.Self)
+				}
+			}
+			return nil, http.StatusInternalServerError, err
+		}
+	}
+
+	data.Self = sub.Data.Self
+	data.FailedMembers = sub.Data.FailedMembers
+	data.AppliedQosRef = data.QosReference
+	if data.QosDuration != sub.Data.QosDuration {
+		/*the new qosDuration applies from the modification*/
+		sub.ExpiryTime = qosExpiryTime(data)
+		s.scheduleExpiry(afId, sub)
+	}
+	sub.Data = data
+	if err := af.SaveAfSubscription(sub); err != nil {
+		log.Printf("could not store subscription %s: %s", data.Self, err)
+	}
+	return sub.Data, http.StatusOK, nil
+}
+
 // ------------------------------------------------------------------------------
 func (s *Service) sessionWithQoS2PolicyAuthz(afId string, data *models.AsSessionWithQoSSubscription) (*pcfclient.AppSessionContext, error) {
 	/*Convert NEF model to PCF models*/
@@ -136,10 +482,7 @@ func (s *Service) sessionWithQoS2PolicyAuthz(afId string, data *models.AsSession
 	}
 
 	req := pcfclient.AppSessionContextReqData{
-		AfAppId: &afId,
-		UeIpv4:  pcfclient.PtrString(data.UeIpv4Addr),
-		// TODO add support for ipv6
-		NotifUri: data.NotificationDestination,
+		AfAppId:  &afId,
 		SuppFeat: s.Cfg().SupportedFeat,
 		Dnn:      pcfclient.PtrString(data.Dnn),
 		SliceInfo: &pcfclient.Snssai{
@@ -149,22 +492,57 @@ func (s *Service) sessionWithQoS2PolicyAuthz(afId string, data *models.AsSession
 		QosDuration: pcfclient.PtrInt32(data.QosDuration),
 	}
 
+	/*UE targeting: IPv4, IPv6, MAC or GPSI resolved to the live PDU session*/
+	if len(data.UeIpv4Addr) > 0 {
+		req.SetUeIpv4(data.UeIpv4Addr)
+	}
+	if len(data.UeIpv6Addr) > 0 {
+		req.SetUeIpv6(*pcfclient.NewIpv6Addr(data.UeIpv6Addr))
+	}
+	if len(data.MacAddr) > 0 {
+		req.SetUeMac(data.MacAddr)
+	}
+	if len(data.Gpsi) > 0 {
+		req.SetGpsi(data.Gpsi)
+		if !hasUeAddress(data) {
+			supi, ueIpv4, err := s.resolveGpsi(afId, data)
+			if err != nil {
+				return nil, err
+			}
+			if err := policyProblem(s.Policies().Policy(afId).CheckUeAddress("/gpsi", ueIpv4)); err != nil {
+				return nil, err
+			}
+			req.SetSupi(supi)
+			req.SetUeIpv4(ueIpv4)
+		}
+	}
+
 	medComponents := make(map[string]pcfclient.MediaComponent)
 	req.MedComponents = &medComponents
 
 	medComponent := pcfclient.MediaComponent{}
 	medComponent.SetMedCompN(1)
-	medComponent.SetFStatus("ENABLED")
 	medComponent.SetAfAppId(afId)
-	/*Based on the QoS reference retrieve the BwInfo*/
-	qosInfo, ok := s.Cfg().QosConf[data.QosReference]
-	if !ok {
+	/*Based on the QoS reference retrieve the QoS profile*/
+	qosInfo, err := s.qosProfile("/qosReference", data.QosReference)
+	if err != nil {
 		log.Default().Printf("requested QoS reference do not exists")
-		return nil, fmt.Errorf("invalid QoS reference")
+		return nil, err
+	}
+	qosProfile2MediaComponent(qosInfo, &medComponent)
+	qosProfile2Sponsor(qosInfo, &req)
+
+	/*alternative QoS the PCF can fall back to instead of failing*/
+	altSerReqs, altSerReqsData, err := s.alternativeQos2PolicyAuthz(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(altSerReqs) > 0 {
+		medComponent.SetAltSerReqs(altSerReqs)
+	}
+	if len(altSerReqsData) > 0 {
+		medComponent.SetAltSerReqsData(altSerReqsData)
 	}
-	medComponent.SetMarBwDl(qosInfo.MarBwDl)
-	medComponent.SetMarBwUl(qosInfo.MarBwUl)
-	medComponent.SetMedType(qosInfo.MediaType)
 
 	/*subComponent containing target flows information*/
 	medSubComponents := make(map[string]pcfclient.MediaSubComponent)
@@ -173,14 +551,24 @@ func (s *Service) sessionWithQoS2PolicyAuthz(afId string, data *models.AsSession
 		medSubComponent := pcfclient.MediaSubComponent{}
 		medSubComponent.FNum = flow.FlowId
 		medSubComponent.FlowUsage = pcfclient.PtrString("NO_INFO")
+		medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString(qosInfo.FlowStatus())})
 
 		medSubComponent.FDescs = append(medSubComponent.FDescs, flow.FlowDescriptions...)
 
-		medSubComponents[string(flow.FlowId)] = medSubComponent
+		medSubComponents[strconv.Itoa(int(flow.FlowId))] = medSubComponent
+	}
+	for i, ethFlow := range data.EthFlowInfo {
+		fNum := ethFlowNumber(data, i)
+		medSubComponent := pcfclient.MediaSubComponent{}
+		medSubComponent.FNum = fNum
+		medSubComponent.FlowUsage = pcfclient.PtrString("NO_INFO")
+		medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString(qosInfo.FlowStatus())})
+		medSubComponent.EthfDescs = []pcfclient.EthFlowDescription{ethFlowDescription2Pcf(ethFlow)}
+
+		medSubComponents[strconv.Itoa(int(fNum))] = medSubComponent
 	}
 	medComponents["1"] = medComponent
 
-	log.Printf("%+v", *req.MedComponents)
 	ctx := &pcfclient.AppSessionContext{}
 	ctx.SetAscReqData(req)
 	return ctx, nil
@@ -188,12 +576,291 @@ func (s *Service) sessionWithQoS2PolicyAuthz(afId string, data *models.AsSession
 }
 
 // ------------------------------------------------------------------------------
-func validateSubscriptionData(data *models.AsSessionWithQoSSubscription) error {
+// updateAppSession modifies a PCF app session from the prev to the data
+// subscription, along with its events subscription
+func (s *Service) updateAppSession(notifUri string, appSessId string, patch *pcfclient.AppSessionContextUpdateDataPatch, prev *models.AsSessionWithQoSSubscription, data *models.AsSessionWithQoSSubscription) error {
 
+	if err := s.Connector().ModifyPolicyAuthzSubscription(appSessId, *patch); err != nil {
+		return fmt.Errorf("could not modify PCF Policy Authorization context")
+	}
+	if slices.Equal(prev.Events, data.Events) {
+		return nil
+	}
 	var err error
-	err = AssertStringNotEmpty(data.UeIpv4Addr)
+	if evSubsc := sessionWithQoS2EventsSubsc(notifUri, data); evSubsc != nil {
+		err = s.Connector().SubscribePolicyAuthzEvents(appSessId, *evSubsc)
+	} else if sessionWithQoS2EventsSubsc(notifUri, prev) != nil {
+		err = s.Connector().UnsubscribePolicyAuthzEvents(appSessId)
+	}
+	if err != nil {
+		return fmt.Errorf("could not update PCF events subscription")
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) sessionWithQoS2PolicyAuthzUpdate(afId string, prev *models.AsSessionWithQoSSubscription, data *models.AsSessionWithQoSSubscription) (*pcfclient.AppSessionContextUpdateDataPatch, error) {
+	/*Convert NEF model to PCF update models*/
+
+	err := validateSubscriptionData(data)
+	if err != nil {
+		return nil, err
+	}
+
+	req := pcfclient.AppSessionContextUpdateData{}
+	req.SetAfAppId(afId)
+	if data.QosDuration > 0 {
+		req.SetQosDuration(data.QosDuration)
+	} else {
+		req.SetQosDurationNil()
+	}
+
+	medComponent := pcfclient.MediaComponentRm{}
+	medComponent.SetMedCompN(1)
+	medComponent.SetAfAppId(afId)
+	/*Based on the QoS reference retrieve the QoS profile*/
+	qosInfo, err := s.qosProfile("/qosReference", data.QosReference)
+	if err != nil {
+		log.Default().Printf("requested QoS reference do not exists")
+		return nil, err
+	}
+	qosProfile2MediaComponentRm(qosInfo, &medComponent)
+	qosProfile2SponsorUpdate(s.Cfg().QosConf[prev.QosReference], qosInfo, &req)
+
+	altSerReqs, altSerReqsData, err := s.alternativeQos2PolicyAuthz(data)
 	if err != nil {
-		return fmt.Errorf("field UeIpv4Addr not provided")
+		return nil, err
+	}
+	if len(altSerReqs) > 0 || len(prev.AltQoSReferences) > 0 {
+		medComponent.SetAltSerReqs(altSerReqs)
+	}
+	if len(altSerReqsData) > 0 || len(prev.AltQosReqs) > 0 {
+		medComponent.SetAltSerReqsData(altSerReqsData)
+	}
+
+	/*subComponent containing target flows information*/
+	medSubComponents := make(map[string]pcfclient.MediaSubComponentRm)
+	for _, flow := range data.FlowInfo {
+		medSubComponent := pcfclient.MediaSubComponentRm{}
+		medSubComponent.SetFNum(flow.FlowId)
+		medSubComponent.SetFlowUsage(pcfclient.FlowUsage{String: pcfclient.PtrString("NO_INFO")})
+		medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString(qosInfo.FlowStatus())})
+		medSubComponent.SetFDescs(flow.FlowDescriptions)
+
+		medSubComponents[strconv.Itoa(int(flow.FlowId))] = medSubComponent
+	}
+	for i, ethFlow := range data.EthFlowInfo {
+		fNum := ethFlowNumber(data, i)
+		medSubComponent := pcfclient.MediaSubComponentRm{}
+		medSubComponent.SetFNum(fNum)
+		medSubComponent.SetFlowUsage(pcfclient.FlowUsage{String: pcfclient.PtrString("NO_INFO")})
+		medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString(qosInfo.FlowStatus())})
+		medSubComponent.SetEthfDescs([]pcfclient.EthFlowDescription{ethFlowDescription2Pcf(ethFlow)})
+
+		medSubComponents[strconv.Itoa(int(fNum))] = medSubComponent
+	}
+	/*flows no longer requested are explicitly removed from the app session*/
+	prevFNums := []int32{}
+	for _, flow := range prev.FlowInfo {
+		prevFNums = append(prevFNums, flow.FlowId)
+	}
+	for i := range prev.EthFlowInfo {
+		prevFNums = append(prevFNums, ethFlowNumber(prev, i))
+	}
+	for _, fNum := range prevFNums {
+		key := strconv.Itoa(int(fNum))
+		if _, ok := medSubComponents[key]; !ok {
+			medSubComponent := pcfclient.MediaSubComponentRm{}
+			medSubComponent.SetFNum(fNum)
+			medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString("REMOVED")})
+			medSubComponents[key] = medSubComponent
+		}
+	}
+	medComponent.SetMedSubComps(medSubComponents)
+	req.SetMedComponents(map[string]pcfclient.MediaComponentRm{"1": medComponent})
+
+	patch := &pcfclient.AppSessionContextUpdateDataPatch{}
+	patch.SetAscReqData(req)
+	return patch, nil
+}
+
+// ------------------------------------------------------------------------------
+// mergeSubscriptionPatch returns a copy of the stored subscription with the
+// patch merged on top of it (RFC 7396): the attributes present in the patch
+// replace the stored ones, those set to null being reset.
+func mergeSubscriptionPatch(data *models.AsSessionWithQoSSubscription, patch *models.AsSessionWithQoSSubscriptionPatch) *models.AsSessionWithQoSSubscription {
+	merged := *data
+
+	if patch.Provided("exterAppId", len(patch.ExterAppId) > 0) {
+		merged.ExterAppId = patch.ExterAppId
+	}
+	if patch.Provided("flowInfo", len(patch.FlowInfo) > 0) {
+		merged.FlowInfo = patch.FlowInfo
+	}
+	if patch.Provided("ethFlowInfo", len(patch.EthFlowInfo) > 0) {
+		merged.EthFlowInfo = patch.EthFlowInfo
+	}
+	if patch.Provided("enEthFlowInfo", len(patch.EnEthFlowInfo) > 0) {
+		merged.EnEthFlowInfo = patch.EnEthFlowInfo
+	}
+	if patch.Provided("listUeAddrs", len(patch.ListUeAddrs) > 0) {
+		merged.ListUeAddrs = patch.ListUeAddrs
+	}
+	if patch.Provided("qosReference", len(patch.QosReference) > 0) {
+		merged.QosReference = patch.QosReference
+	}
+	if patch.Provided("altQoSReferences", len(patch.AltQoSReferences) > 0) {
+		merged.AltQoSReferences = patch.AltQoSReferences
+	}
+	if patch.Provided("altQosReqs", len(patch.AltQosReqs) > 0) {
+		merged.AltQosReqs = patch.AltQosReqs
+	}
+	if patch.Provided("disUeNotif", patch.DisUeNotif) {
+		merged.DisUeNotif = patch.DisUeNotif
+	}
+	if patch.Provided("usageThreshold", patch.UsageThreshold != nil) {
+		if threshold := patch.UsageThreshold; threshold == nil {
+			merged.UsageThreshold = models.UsageThreshold{}
+		} else {
+			if patch.ProvidedIn("usageThreshold", "duration", threshold.Duration != nil) {
+				merged.UsageThreshold.Duration = valueOrZero(threshold.Duration)
+			}
+			if patch.ProvidedIn("usageThreshold", "totalVolume", threshold.TotalVolume != nil) {
+				merged.UsageThreshold.TotalVolume = valueOrZero(threshold.TotalVolume)
+			}
+			if patch.ProvidedIn("usageThreshold", "downlinkVolume", threshold.DownlinkVolume != nil) {
+				merged.UsageThreshold.DownlinkVolume = valueOrZero(threshold.DownlinkVolume)
+			}
+			if patch.ProvidedIn("usageThreshold", "uplinkVolume", threshold.UplinkVolume != nil) {
+				merged.UsageThreshold.UplinkVolume = valueOrZero(threshold.UplinkVolume)
+			}
+		}
+	}
+	if patch.Provided("directNotifInd", patch.DirectNotifInd) {
+		merged.DirectNotifInd = patch.DirectNotifInd
+	}
+	if patch.Provided("notificationDestination", len(patch.NotificationDestination) > 0) {
+		merged.NotificationDestination = patch.NotificationDestination
+	}
+	if patch.Provided("events", len(patch.Events) > 0) {
+		merged.Events = patch.Events
+	}
+	if patch.Provided("pduSetQosDl", patch.PduSetQosDl != nil) {
+		merged.PduSetQosDl = valueOrZero(patch.PduSetQosDl)
+	}
+	if patch.Provided("pduSetQosUl", patch.PduSetQosUl != nil) {
+		merged.PduSetQosUl = valueOrZero(patch.PduSetQosUl)
+	}
+	if patch.Provided("rTLatencyInd", patch.RTLatencyInd) {
+		merged.RTLatencyInd = patch.RTLatencyInd
+	}
+	if patch.Provided("periodUl", patch.PeriodUl > 0) {
+		merged.PeriodUl = patch.PeriodUl
+	}
+	if patch.Provided("periodDl", patch.PeriodDl > 0) {
+		merged.PeriodDl = patch.PeriodDl
+	}
+	if patch.Provided("qosDuration", patch.QosDuration != nil) {
+		merged.QosDuration = valueOrZero(patch.QosDuration)
+	}
+	if patch.Provided("qosInactInt", patch.QosInactInt != nil) {
+		merged.QosInactInt = valueOrZero(patch.QosInactInt)
+	}
+	if patch.Provided("avrgWndw", patch.AvrgWndw != nil) {
+		merged.AvrgWndw = valueOrZero(patch.AvrgWndw)
+	}
+	if patch.Provided("listUeConsDtRt", len(patch.ListUeConsDtRt) > 0) {
+		merged.ListUeConsDtRt = patch.ListUeConsDtRt
+	}
+	return &merged
+}
+
+// valueOrZero returns the patched value, the zero value for null
+func valueOrZero[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+// ------------------------------------------------------------------------------
+// matchSubscriptionFilters checks the subscription against the ip-addrs,
+// ip-domain and mac-addrs query parameters. Each provided filter must match.
+func matchSubscriptionFilters(data *models.AsSessionWithQoSSubscription, ipAddrs []string, ipDomain string, macAddrs []string) bool {
+
+	if len(ipAddrs) > 0 {
+		found := false
+		for _, ip := range ipAddrs {
+			addr, err := netip.ParseAddr(strings.TrimSpace(ip))
+			if err != nil {
+				continue
+			}
+			if sameIpAddr(data.UeIpv4Addr, addr) || sameIpAddr(data.UeIpv6Addr, addr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(ipDomain) > 0 && data.IpDomain != ipDomain {
+		return false
+	}
+
+	if len(macAddrs) > 0 {
+		found := false
+		for _, mac := range macAddrs {
+			if strings.EqualFold(strings.TrimSpace(mac), data.MacAddr) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// ------------------------------------------------------------------------------
+func sameIpAddr(s string, addr netip.Addr) bool {
+	if len(s) == 0 {
+		return false
+	}
+	ueAddr, err := netip.ParseAddr(s)
+	return err == nil && ueAddr == addr
+}
+
+// ------------------------------------------------------------------------------
+func sameSessionTarget(a *models.AsSessionWithQoSSubscription, b *models.AsSessionWithQoSSubscription) bool {
+	return a.UeIpv4Addr == b.UeIpv4Addr &&
+		a.UeIpv6Addr == b.UeIpv6Addr &&
+		a.MacAddr == b.MacAddr &&
+		a.Gpsi == b.Gpsi &&
+		a.ExtGroupId == b.ExtGroupId &&
+		a.Dnn == b.Dnn &&
+		a.Snssai == b.Snssai
+}
+
+// ------------------------------------------------------------------------------
+func validateSubscriptionData(data *models.AsSessionWithQoSSubscription) error {
+
+	var err error
+	if !hasUeAddress(data) && len(data.Gpsi) == 0 && len(data.ExtGroupId) == 0 {
+		return fmt.Errorf("field UeIpv4Addr, UeIpv6Addr, MacAddr, Gpsi or ExtGroupId not provided")
+	}
+	if len(data.UeIpv4Addr) > 0 {
+		if addr, err := netip.ParseAddr(data.UeIpv4Addr); err != nil || !addr.Is4() {
+			return fmt.Errorf("invalid UeIpv4Addr")
+		}
+	}
+	if len(data.UeIpv6Addr) > 0 {
+		if addr, err := netip.ParseAddr(data.UeIpv6Addr); err != nil || !addr.Is6() {
+			return fmt.Errorf("invalid UeIpv6Addr")
+		}
 	}
 	err = AssertStringNotEmpty(data.NotificationDestination)
 	if err != nil {
@@ -208,7 +875,18 @@ func validateSubscriptionData(data *models.AsSessionWithQoSSubscription) error {
 		return fmt.Errorf("field QosReference not provided")
 	}
 
-	if len(data.FlowInfo) == 0 {
+	if len(data.MacAddr) > 0 {
+		/*Ethernet PDU session, traffic is described by ethernet flows*/
+		if len(data.EthFlowInfo) == 0 {
+			return fmt.Errorf("field EthFlowInfo not provided")
+		}
+		for _, ethFlow := range data.EthFlowInfo {
+			err = AssertStringNotEmpty(ethFlow.EthType)
+			if err != nil {
+				return fmt.Errorf("invalid ethernet flow descriptor")
+			}
+		}
+	} else if len(data.FlowInfo) == 0 {
 		return fmt.Errorf("field FlowInfo not provided")
 	}
 
@@ -226,6 +904,131 @@ func validateSubscriptionData(data *models.AsSessionWithQoSSubscription) error {
 	return nil
 }
 
+// ------------------------------------------------------------------------------
+// resolveGpsi retrieves the SUPI of a GPSI only request through the identity
+// service and the IPv4 address of its PDU session on the requested dnn/slice.
+func (s *Service) resolveGpsi(afId string, data *models.AsSessionWithQoSSubscription) (string, string, error) {
+
+	supi, err := s.Connector().LookupGpsi(afId, data.Gpsi)
+	if err != nil {
+		log.Printf("%s", err)
+		return "", "", fmt.Errorf("could not resolve Gpsi")
+	}
+
+	ueProfile, err := s.Connector().GetUeProfile(supi)
+	if err != nil {
+		log.Printf("%s", err)
+		return "", "", fmt.Errorf("could not find an active PDU session for Gpsi")
+	}
+
+	ueIpv4 := ueProfile.GetSessionIpv4(data.Dnn, data.Snssai)
+	if len(ueIpv4) == 0 {
+		return "", "", fmt.Errorf("could not find an active PDU session for Gpsi")
+	}
+	return supi, ueIpv4, nil
+}
+
+// ------------------------------------------------------------------------------
+// alternativeQos2PolicyAuthz maps the alternative QoS of the subscription, in
+// priority order, to the PCF alternative service requirements. The references
+// must be configured QoS profiles, whose bandwidth completes the requirements
+// the AF did not detail.
+func (s *Service) alternativeQos2PolicyAuthz(data *models.AsSessionWithQoSSubscription) ([]string, []pcfclient.AlternativeServiceRequirementsData, error) {
+
+	if len(data.AltQoSReferences) > 0 && len(data.AltQosReqs) > 0 {
+		return nil, nil, fmt.Errorf("fields AltQoSReferences and AltQosReqs are mutually exclusive")
+	}
+
+	altSerReqs := []string{}
+	for i, altQosRef := range data.AltQoSReferences {
+		if _, err := s.qosProfile(fmt.Sprintf("/altQoSReferences/%d", i), altQosRef); err != nil {
+			return nil, nil, err
+		}
+		altSerReqs = append(altSerReqs, altQosRef)
+	}
+
+	altSerReqsData := []pcfclient.AlternativeServiceRequirementsData{}
+	for _, altQosReq := range data.AltQosReqs {
+		if len(altQosReq.AltQosParamSetRef) == 0 {
+			return nil, nil, fmt.Errorf("field AltQosParamSetRef not provided")
+		}
+		altSerReqData := pcfclient.AlternativeServiceRequirementsData{
+			AltQosParamSetRef: altQosReq.AltQosParamSetRef,
+		}
+		gbrUl, gbrDl, pdb := altQosReq.GbrUl, altQosReq.GbrDl, altQosReq.Pdb
+		if qosInfo, ok := s.Cfg().QosConf[altQosReq.AltQosParamSetRef]; ok {
+			if len(gbrUl) == 0 {
+				gbrUl = cmp.Or(qosInfo.GbrUl, qosInfo.MarBwUl)
+			}
+			if len(gbrDl) == 0 {
+				gbrDl = cmp.Or(qosInfo.GbrDl, qosInfo.MarBwDl)
+			}
+			if pdb == 0 {
+				pdb = qosInfo.PacketDelayBudget
+			}
+		}
+		if len(gbrUl) > 0 {
+			altSerReqData.SetGbrUl(gbrUl)
+		}
+		if len(gbrDl) > 0 {
+			altSerReqData.SetGbrDl(gbrDl)
+		}
+		if pdb > 0 {
+			altSerReqData.SetPdb(pdb)
+		}
+		if len(altQosReq.Per) > 0 {
+			altSerReqData.SetPer(altQosReq.Per)
+		}
+		altSerReqsData = append(altSerReqsData, altSerReqData)
+	}
+	return altSerReqs, altSerReqsData, nil
+}
+
+// ------------------------------------------------------------------------------
+func hasUeAddress(data *models.AsSessionWithQoSSubscription) bool {
+	return len(data.UeIpv4Addr) > 0 || len(data.UeIpv6Addr) > 0 || len(data.MacAddr) > 0
+}
+
+// ------------------------------------------------------------------------------
+// ethFlowNumber returns the flow number of the i-th ethernet flow, numbered
+// after the IP flows so that both kinds can coexist in the same media component.
+func ethFlowNumber(data *models.AsSessionWithQoSSubscription, i int) int32 {
+	var maxFlowId int32
+	for _, flow := range data.FlowInfo {
+		if flow.FlowId > maxFlowId {
+			maxFlowId = flow.FlowId
+		}
+	}
+	return maxFlowId + int32(i) + 1
+}
+
+// ------------------------------------------------------------------------------
+func ethFlowDescription2Pcf(ethFlow models.EthFlowDescription) pcfclient.EthFlowDescription {
+	desc := pcfclient.EthFlowDescription{
+		EthType:  ethFlow.EthType,
+		VlanTags: ethFlow.VlanTags,
+	}
+	if len(ethFlow.DestMacAddr) > 0 {
+		desc.SetDestMacAddr(ethFlow.DestMacAddr)
+	}
+	if len(ethFlow.SourceMacAddr) > 0 {
+		desc.SetSourceMacAddr(ethFlow.SourceMacAddr)
+	}
+	if len(ethFlow.SrcMacAddrEnd) > 0 {
+		desc.SetSrcMacAddrEnd(ethFlow.SrcMacAddrEnd)
+	}
+	if len(ethFlow.DestMacAddrEnd) > 0 {
+		desc.SetDestMacAddrEnd(ethFlow.DestMacAddrEnd)
+	}
+	if len(ethFlow.FDesc) > 0 {
+		desc.SetFDesc(ethFlow.FDesc)
+	}
+	if len(ethFlow.FDir) > 0 {
+		desc.SetFDir(pcfclient.FlowDirection{String: pcfclient.PtrString(string(ethFlow.FDir))})
+	}
+	return desc
+}
+
 // ------------------------------------------------------------------------------
 func AssertStringNotEmpty(s string) error {
 	if len(s) == 0 {
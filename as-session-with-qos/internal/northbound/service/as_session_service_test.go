@@ -20,12 +20,71 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
 )
 
+type testApp struct {
+	cfg       *config.AppConfig
+	policies  *afpolicy.Registry
+	connector *connector.Connector
+	ctx       *contexts.AsSessionAppCtx
+}
+
+func (a *testApp) Cfg() *config.AppConfig          { return a.cfg }
+func (a *testApp) Connector() *connector.Connector { return a.connector }
+func (a *testApp) Ctx() *contexts.AsSessionAppCtx  { return a.ctx }
+func (a *testApp) Policies() *afpolicy.Registry    { return a.policies }
+
+func newTestService() *Service {
+	return NewAsSessionWithQoSService(&testApp{cfg: &config.AppConfig{
+		QosConf: map[string]config.QosConfig{
+			"qos1": {MarBwDl: "1 Mbps", MarBwUl: "1 Mbps", MediaType: "VIDEO"},
+			"qos2": {MarBwDl: "2 Mbps", MarBwUl: "2 Mbps", MediaType: "VIDEO"},
+		},
+	}})
+}
+
+func TestSessionWithQoS2PolicyAuthzUeIpv6(t *testing.T) {
+	s := newTestService()
+	data := &models.AsSessionWithQoSSubscription{
+		UeIpv6Addr:              "2001:db8::1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "qos1",
+		FlowInfo: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from any to 2001:db8::1"},
+		}},
+	}
+
+	pa_ctx, err := s.sessionWithQoS2PolicyAuthz("af1", data)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	body, _ := json.Marshal(pa_ctx)
+	var sent struct {
+		AscReqData map[string]any `json:"ascReqData"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil || sent.AscReqData["ueIpv6"] != "2001:db8::1" {
+		t.Errorf("expected the ueIpv6 address in %s", body)
+	}
+}
+
 func TestAsSessionWithQoSValidation(t *testing.T) {
 	data := &models.AsSessionWithQoSSubscription{
 		UeIpv4Addr:              "12.1.1.1",
@@ -54,7 +113,7 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		},
 	}
 	err = validateSubscriptionData(dataNoIp)
-	expectedErr := fmt.Errorf("field UeIpv4Addr not provided")
+	expectedErr := fmt.Errorf("field UeIpv4Addr, UeIpv6Addr, MacAddr, Gpsi or ExtGroupId not provided")
 	if err == nil {
 		t.Errorf("expected %s, no error", expectedErr.Error())
 	} else if err.Error() != expectedErr.Error() {
@@ -166,4 +225,388 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
 	}
 
+	dataIpv6 := &models.AsSessionWithQoSSubscription{
+		UeIpv6Addr:              "2001:db8::1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+		FlowInfo: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from any to 2001:db8::1"},
+		},
+		},
+	}
+	err = validateSubscriptionData(dataIpv6)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	dataGpsi := &models.AsSessionWithQoSSubscription{
+		Gpsi:                    "msisdn-33611111111",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+		FlowInfo: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+		},
+		},
+	}
+	err = validateSubscriptionData(dataGpsi)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	dataGroup := &models.AsSessionWithQoSSubscription{
+		ExtGroupId:              "fleet-1@nef.org",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+		FlowInfo: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+		},
+		},
+	}
+	err = validateSubscriptionData(dataGroup)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	dataMac := &models.AsSessionWithQoSSubscription{
+		MacAddr:                 "00-11-22-33-44-55",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+		EthFlowInfo: []models.EthFlowDescription{{
+			EthType: "0800",
+			FDir:    models.FlowDirectionDownlink,
+		},
+		},
+	}
+	err = validateSubscriptionData(dataMac)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	dataMacNoEthFlow := &models.AsSessionWithQoSSubscription{
+		MacAddr:                 "00-11-22-33-44-55",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+	}
+	err = validateSubscriptionData(dataMacNoEthFlow)
+	expectedErr = fmt.Errorf("field EthFlowInfo not provided")
+	if err == nil {
+		t.Errorf("expected %s, no error", expectedErr.Error())
+	} else if err.Error() != expectedErr.Error() {
+		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
+	}
+
+}
+
+func TestEthFlowNumber(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		FlowInfo:    []models.FlowInfo{{FlowId: 1}, {FlowId: 4}},
+		EthFlowInfo: []models.EthFlowDescription{{EthType: "0800"}, {EthType: "86DD"}},
+	}
+	if fNum := ethFlowNumber(data, 0); fNum != 5 {
+		t.Errorf("expected flow number 5, got %d", fNum)
+	}
+	if fNum := ethFlowNumber(data, 1); fNum != 6 {
+		t.Errorf("expected flow number 6, got %d", fNum)
+	}
+}
+
+func TestMergeSubscriptionPatch(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		Self:                    "sub1",
+		UeIpv4Addr:              "12.1.1.1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "QoS1",
+		QosDuration:             60,
+		FlowInfo: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+		},
+		},
+	}
+
+	duration := int32(120)
+	patch := &models.AsSessionWithQoSSubscriptionPatch{
+		QosReference: "QoS2",
+		QosDuration:  &duration,
+	}
+
+	merged := mergeSubscriptionPatch(data, patch)
+	if merged == data {
+		t.Errorf("expected a copy of the stored subscription, got the same reference")
+	}
+	if merged.QosReference != "QoS2" {
+		t.Errorf("got qosReference %s, wanted %s", merged.QosReference, "QoS2")
+	}
+	if merged.QosDuration != duration {
+		t.Errorf("got qosDuration %d, wanted %d", merged.QosDuration, duration)
+	}
+	if data.QosReference != "QoS1" || data.QosDuration != 60 {
+		t.Errorf("stored subscription must not be modified by the merge")
+	}
+	if merged.UeIpv4Addr != data.UeIpv4Addr || merged.Self != data.Self {
+		t.Errorf("attributes not present in the patch must be kept")
+	}
+	if len(merged.FlowInfo) != 1 || merged.FlowInfo[0].FlowId != 1 {
+		t.Errorf("got flowInfo %v, wanted %v", merged.FlowInfo, data.FlowInfo)
+	}
+	if !sameSessionTarget(data, merged) {
+		t.Errorf("expected same session target after a QoS only patch")
+	}
+}
+
+func TestMergeSubscriptionPatchResets(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		Self:             "sub1",
+		UeIpv4Addr:       "12.1.1.1",
+		QosReference:     "QoS1",
+		AltQoSReferences: []string{"QoS2", "QoS3"},
+		QosDuration:      60,
+		DisUeNotif:       true,
+		DirectNotifInd:   true,
+		RTLatencyInd:     true,
+		UsageThreshold:   models.UsageThreshold{Duration: 30, TotalVolume: 1000},
+	}
+
+	patch := &models.AsSessionWithQoSSubscriptionPatch{}
+	body := `{"disUeNotif": false, "directNotifInd": false, "rTLatencyInd": false,
+		"altQoSReferences": null, "qosDuration": null, "usageThreshold": {"duration": null}}`
+	if err := json.Unmarshal([]byte(body), patch); err != nil {
+		t.Fatalf("could not decode patch: %s", err)
+	}
+
+	merged := mergeSubscriptionPatch(data, patch)
+	if merged.DisUeNotif || merged.DirectNotifInd || merged.RTLatencyInd {
+		t.Errorf("explicit false must reset disUeNotif, directNotifInd and rTLatencyInd, got %v %v %v",
+			merged.DisUeNotif, merged.DirectNotifInd, merged.RTLatencyInd)
+	}
+	if merged.AltQoSReferences != nil {
+		t.Errorf("null must remove altQoSReferences, got %v", merged.AltQoSReferences)
+	}
+	if merged.QosDuration != 0 {
+		t.Errorf("null must remove qosDuration, got %d", merged.QosDuration)
+	}
+	if merged.UsageThreshold.Duration != 0 || merged.UsageThreshold.TotalVolume != 1000 {
+		t.Errorf("got usageThreshold %v, wanted only the duration removed", merged.UsageThreshold)
+	}
+	if merged.QosReference != "QoS1" {
+		t.Errorf("attributes absent from the patch must be kept, got qosReference %s", merged.QosReference)
+	}
+	if !data.DisUeNotif || data.QosDuration != 60 || len(data.AltQoSReferences) != 2 {
+		t.Errorf("stored subscription must not be modified by the merge")
+	}
+
+	unknown := &models.AsSessionWithQoSSubscriptionPatch{}
+	if err := json.Unmarshal([]byte(`{"unknownAttr": true}`), unknown); err == nil {
+		t.Errorf("expected unknown attributes to be refused")
+	}
+}
+
+func TestMatchSubscriptionFilters(t *testing.T) {
+	data := &models.AsSessionWithQoSSubscription{
+		UeIpv4Addr: "12.1.1.1",
+		IpDomain:   "domain1",
+		MacAddr:    "0A-0B-0C-0D-0E-0F",
+	}
+
+	if !matchSubscriptionFilters(data, nil, "", nil) {
+		t.Errorf("expected subscription to match when no filter is provided")
+	}
+	if !matchSubscriptionFilters(data, []string{"10.0.0.1", "12.1.1.1"}, "", nil) {
+		t.Errorf("expected subscription to match ip-addrs filter")
+	}
+	if matchSubscriptionFilters(data, []string{"10.0.0.1"}, "", nil) {
+		t.Errorf("expected subscription not to match ip-addrs filter")
+	}
+	if matchSubscriptionFilters(data, []string{"12.1.1.1"}, "domain2", nil) {
+		t.Errorf("expected subscription not to match ip-domain filter")
+	}
+	if !matchSubscriptionFilters(data, nil, "domain1", []string{"0a-0b-0c-0d-0e-0f"}) {
+		t.Errorf("expected subscription to match mac-addrs filter")
+	}
+	if matchSubscriptionFilters(data, nil, "", []string{"00-00-00-00-00-00"}) {
+		t.Errorf("expected subscription not to match mac-addrs filter")
+	}
+
+	dataV6 := &models.AsSessionWithQoSSubscription{
+		UeIpv6Addr: "2001:db8::1",
+	}
+	if !matchSubscriptionFilters(dataV6, []string{"2001:0db8:0:0::1"}, "", nil) {
+		t.Errorf("expected ipv6 subscription to match ip-addrs filter")
+	}
+}
+
+func TestFetchAllSessionWithQoSSubscriptionsUnknownAf(t *testing.T) {
+	s := NewAsSessionWithQoSService(&testApp{cfg: &config.AppConfig{}, ctx: contexts.NewAsSessionAppCtx(nil, contexts.NewMemoryStore())})
+
+	subs, status, err := s.FetchAllSessionWithQoSSubscriptions("af1", nil, "", nil)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("expected 200, got %d %v", status, err)
+	}
+	if body, _ := json.Marshal(subs); string(body) != "[]" {
+		t.Errorf("expected an empty list, got %s", body)
+	}
+}
+
+func TestAlternativeQos2PolicyAuthz(t *testing.T) {
+	s := newTestService()
+
+	data := &models.AsSessionWithQoSSubscription{
+		QosReference:     "qos2",
+		AltQoSReferences: []string{"qos1"},
+	}
+	altSerReqs, altSerReqsData, err := s.alternativeQos2PolicyAuthz(data)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+	if len(altSerReqs) != 1 || altSerReqs[0] != "qos1" || len(altSerReqsData) != 0 {
+		t.Errorf("unexpected alternative service requirements %v %v", altSerReqs, altSerReqsData)
+	}
+
+	data = &models.AsSessionWithQoSSubscription{
+		QosReference: "qos2",
+		AltQosReqs:   []models.AlternativeServiceRequirementsData{{AltQosParamSetRef: "qos1", Pdb: 50}},
+	}
+	_, altSerReqsData, err = s.alternativeQos2PolicyAuthz(data)
+	if err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+	if len(altSerReqsData) != 1 || altSerReqsData[0].GetGbrDl() != "1 Mbps" || altSerReqsData[0].GetPdb() != 50 {
+		t.Errorf("unexpected alternative service requirements data %v", altSerReqsData)
+	}
+
+	data = &models.AsSessionWithQoSSubscription{
+		QosReference:     "qos2",
+		AltQoSReferences: []string{"qos9"},
+	}
+	_, _, err = s.alternativeQos2PolicyAuthz(data)
+	expectedErr := fmt.Errorf("unknown QoS reference qos9")
+	if err == nil {
+		t.Errorf("expected %s, no error", expectedErr.Error())
+	} else if err.Error() != expectedErr.Error() {
+		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
+	}
+}
+
+func TestApplySubscriptionUpdateRevert(t *testing.T) {
+	var mu sync.Mutex
+	patches := map[string][]string{}
+	pcf := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		appSessId := strings.TrimPrefix(r.URL.Path, "/npcf-policyauthorization/v1/app-sessions/")
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		patches[appSessId] = append(patches[appSessId], string(body))
+		mu.Unlock()
+		if appSessId == "app2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer pcf.Close()
+
+	app := &testApp{cfg: &config.AppConfig{
+		QosConf: map[string]config.QosConfig{
+			"qos1": {MarBwDl: "1 Mbps", MarBwUl: "1 Mbps", MediaType: "VIDEO"},
+			"qos2": {MarBwDl: "2 Mbps", MarBwUl: "2 Mbps", MediaType: "VIDEO"},
+		},
+		Sbi: config.SbiConfig{PcfSvc: pcf.URL},
+	}}
+	app.connector = connector.NewConnector(app)
+	s := NewAsSessionWithQoSService(app)
+
+	af := contexts.NewAsSessionAppCtx(app, contexts.NewMemoryStore()).AddAf("af1")
+	prev := &models.AsSessionWithQoSSubscription{
+		ExtGroupId:              "fleet-1@nef.org",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "qos1",
+		FlowInfo:                []models.FlowInfo{{FlowId: 1, FlowDescriptions: []string{"permit out ip from any to 10.0.0.1"}}},
+	}
+	_, sub := af.NewAfSubscription(prev)
+	sub.MemberAppSessIds = map[string]string{"imsi-1": "app1", "imsi-2": "app2", "imsi-3": "app3"}
+
+	data := *prev
+	data.QosReference = "qos2"
+	if _, status, err := s.applySubscriptionUpdate("af1", af, sub, &data); err == nil || status != http.StatusInternalServerError {
+		t.Fatalf("got status %d, wanted a failure", status)
+	}
+	if sub.Data.QosReference != "qos1" {
+		t.Errorf("got stored qosReference %s, wanted qos1", sub.Data.QosReference)
+	}
+	/*each app session modified to qos2 was reverted to qos1*/
+	for appSessId, bodies := range patches {
+		if strings.Contains(bodies[0], "2 Mbps") && !strings.Contains(bodies[len(bodies)-1], "1 Mbps") {
+			t.Errorf("app session %s was not reverted: %v", appSessId, bodies)
+		}
+	}
+	if len(patches["app2"]) != 2 || !strings.Contains(patches["app2"][0], "2 Mbps") || !strings.Contains(patches["app2"][1], "1 Mbps") {
+		t.Errorf("got patches %v of the failing app session, wanted the modification and its revert", patches["app2"])
+	}
+}
+
+func TestCreateMemberAppSessions(t *testing.T) {
+	profiles := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ue-profile/v1/profiles/imsi-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Imsi": "1", "Gpsi": "msisdn-100", "PduSessions": {"1": {"Id": 1, "Ipv4": "12.1.1.2", "Dnn": "internet", "Snssai": {"sst": 1}}}}`))
+	}))
+	defer profiles.Close()
+	pcf := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "http://pcf/npcf-policyauthorization/v1/app-sessions/app1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer pcf.Close()
+
+	app := &testApp{cfg: &config.AppConfig{
+		QosConf: map[string]config.QosConfig{"qos1": {MarBwDl: "1 Mbps", MarBwUl: "1 Mbps", MediaType: "VIDEO"}},
+		Sbi:     config.SbiConfig{PcfSvc: pcf.URL, ProfileSvc: profiles.URL},
+	}}
+	app.connector = connector.NewConnector(app)
+	s := NewAsSessionWithQoSService(app)
+
+	data := &models.AsSessionWithQoSSubscription{
+		ExtGroupId:              "fleet-1@nef.org",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		QosReference:            "qos1",
+		FlowInfo:                []models.FlowInfo{{FlowId: 1, FlowDescriptions: []string{"permit out ip from any to 10.0.0.1"}}},
+	}
+	results := s.createMemberAppSessions("af1", "http://nef/notify", []string{"imsi-1", "imsi-2"}, data)
+	if len(results) != 2 {
+		t.Fatalf("got %d results, wanted one per member", len(results))
+	}
+	if results[0].err != nil || results[0].appSessId != "app1" || results[0].gpsi != "msisdn-100" {
+		t.Errorf("got %+v, wanted the app session app1 of msisdn-100", results[0])
+	}
+	if results[1].err == nil || results[1].status != http.StatusNotFound || len(results[1].gpsi) > 0 {
+		t.Errorf("got %+v, wanted an unknown UE", results[1])
+	}
+
+	/*a member outside of the UE address ranges of the AF is not provisioned*/
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	if err := os.WriteFile(path, []byte("afs:\n  af1:\n    ueIpRanges: [10.0.0.0/8]\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := afpolicy.NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	app.policies = registry
+	results = s.createMemberAppSessions("af1", "http://nef/notify", []string{"imsi-1"}, data)
+	if results[0].err == nil || results[0].status != http.StatusForbidden || len(results[0].appSessId) > 0 {
+		t.Errorf("got %+v, wanted a forbidden member", results[0])
+	}
 }
@@ -0,0 +1,61 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+)
+
+func TestIdempotentReplay(t *testing.T) {
+	af := contexts.NewAf("af-test")
+
+	req := &models.AsSessionWithQoSSubscription{NotificationDestination: "http://af/notify", QosReference: "qos-1"}
+	reqHash := requestHash(req)
+	_, subCtx := af.NewAfSubscription(req)
+	subCtx.IdempotencyKey = "key-1"
+	subCtx.RequestHash = reqHash
+
+	if sub, err := idempotentReplay(af, "", reqHash); sub != nil || err != nil {
+		t.Errorf("got %v, %v without key, wanted no replay", sub, err)
+	}
+	if sub, err := idempotentReplay(af, "key-2", reqHash); sub != nil || err != nil {
+		t.Errorf("got %v, %v for a new key, wanted no replay", sub, err)
+	}
+
+	retry := &models.AsSessionWithQoSSubscription{NotificationDestination: "http://af/notify", QosReference: "qos-1"}
+	sub, err := idempotentReplay(af, "key-1", requestHash(retry))
+	if err != nil || sub != subCtx {
+		t.Errorf("got %v, %v for a retry, wanted the original subscription", sub, err)
+	}
+
+	conflict := &models.AsSessionWithQoSSubscription{NotificationDestination: "http://af/notify", QosReference: "qos-2"}
+	_, err = idempotentReplay(af, "key-1", requestHash(conflict))
+	var problem *models.ProblemError
+	if !errors.As(err, &problem) {
+		t.Fatalf("got %v for a conflicting payload, wanted a ProblemError", err)
+	}
+	if len(problem.Problem.InvalidParams) != 1 || problem.Problem.InvalidParams[0].Param != "Idempotency-Key" {
+		t.Errorf("got invalid params %v, wanted Idempotency-Key", problem.Problem.InvalidParams)
+	}
+}
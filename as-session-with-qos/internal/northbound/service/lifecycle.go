@@ -0,0 +1,165 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+)
+
+// lifecycleManager schedules the expiry of the subscriptions, keyed by their location
+type lifecycleManager struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newLifecycleManager() *lifecycleManager {
+	return &lifecycleManager{timers: make(map[string]*time.Timer)}
+}
+
+// schedule calls expire at the given time, replacing any previous expiry of the key
+func (l *lifecycleManager) schedule(key string, at time.Time, expire func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		l.mu.Lock()
+		if l.timers[key] != timer {
+			/*rescheduled or cancelled meanwhile*/
+			l.mu.Unlock()
+			return
+		}
+		delete(l.timers, key)
+		l.mu.Unlock()
+
+		log.Printf("subscription %s expired", key)
+		expire()
+	})
+	l.timers[key] = timer
+}
+
+// cancel drops the scheduled expiry of the key, if any
+func (l *lifecycleManager) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+		delete(l.timers, key)
+	}
+}
+
+// scheduled tells whether an expiry is pending for the key
+func (l *lifecycleManager) scheduled(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.timers[key]
+	return ok
+}
+
+// ------------------------------------------------------------------------------
+// qosExpiryTime returns when the requested qosDuration ends, zero when unbounded
+func qosExpiryTime(data *models.AsSessionWithQoSSubscription) time.Time {
+	if data.QosDuration > 0 {
+		return time.Now().Add(time.Duration(data.QosDuration) * time.Second)
+	}
+	return time.Time{}
+}
+
+// ------------------------------------------------------------------------------
+// scheduleExpiry arms the teardown of the subscription at its expiry time
+func (s *Service) scheduleExpiry(afId string, sub *contexts.AfSubscriptionCtx) {
+	if sub.ExpiryTime.IsZero() {
+		s.lifecycle.cancel(sub.Location())
+		return
+	}
+	subId := sub.Data.Self
+	s.lifecycle.schedule(sub.Location(), sub.ExpiryTime, func() {
+		s.expireSessionWithQoSSubscription(afId, subId)
+	})
+}
+
+// ------------------------------------------------------------------------------
+// expireSessionWithQoSSubscription releases the PCF app sessions of a
+// subscription whose qosDuration elapsed and reports the SESSION_TERMINATION
+// to the AF.
+func (s *Service) expireSessionWithQoSSubscription(afId string, subId string) {
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil {
+		return
+	}
+	for _, appSessId := range sub.AppSessIds() {
+		if err := s.Connector().RemovePolicyAuthzSubscription(appSessId); err != nil {
+			log.Printf("%s", err)
+		}
+		sub.RemoveAppSession(appSessId)
+	}
+	_ = af.DeleteAfscription(subId)
+
+	notificationUri := sub.Data.NotificationDestination
+	upNotif := sessionTerminationNotification(sub)
+	go func() {
+		if err := s.Connector().SendAfNotification(notificationUri, upNotif); err != nil {
+			log.Printf("%s", err)
+		}
+	}()
+}
+
+// ------------------------------------------------------------------------------
+// RestoreSessionWithQoSSubscriptions reloads the stored subscriptions and arms
+// again their expiry, the ones that expired meanwhile are torn down right away.
+func (s *Service) RestoreSessionWithQoSSubscriptions() error {
+	if err := s.Ctx().Restore(); err != nil {
+		return err
+	}
+	for afId, af := range s.Ctx().AppFunc {
+		for _, sub := range af.GetAfSubscriptions() {
+			s.scheduleExpiry(afId, sub)
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+func sessionTerminationNotification(sub *contexts.AfSubscriptionCtx) *models.UserPlaneNotificationData {
+	return &models.UserPlaneNotificationData{
+		Transaction: sub.Location(),
+		EventReports: []models.UserPlaneEventReport{{
+			Event: models.UserPlaneEventSessionTermination,
+		}},
+	}
+}
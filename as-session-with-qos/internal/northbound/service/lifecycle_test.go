@@ -0,0 +1,78 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+)
+
+func TestLifecycleManager(t *testing.T) {
+	l := newLifecycleManager()
+
+	expired := make(chan string, 2)
+	l.schedule("sub1", time.Now().Add(10*time.Millisecond), func() { expired <- "sub1" })
+	l.schedule("sub2", time.Now().Add(10*time.Millisecond), func() { expired <- "sub2" })
+	l.cancel("sub2")
+	if l.scheduled("sub2") {
+		t.Errorf("sub2 expiry should be cancelled")
+	}
+
+	select {
+	case key := <-expired:
+		if key != "sub1" {
+			t.Errorf("got expired %s, wanted sub1", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sub1 did not expire")
+	}
+	if l.scheduled("sub1") {
+		t.Errorf("sub1 expiry should not be pending anymore")
+	}
+
+	/* rescheduling replaces the previous expiry */
+	l.schedule("sub3", time.Now().Add(10*time.Millisecond), func() { expired <- "sub3-old" })
+	l.schedule("sub3", time.Now().Add(20*time.Millisecond), func() { expired <- "sub3" })
+	select {
+	case key := <-expired:
+		if key != "sub3" {
+			t.Errorf("got expired %s, wanted sub3", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("sub3 did not expire")
+	}
+	select {
+	case key := <-expired:
+		t.Errorf("unexpected expiry %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestQosExpiryTime(t *testing.T) {
+	if !qosExpiryTime(&models.AsSessionWithQoSSubscription{}).IsZero() {
+		t.Errorf("expected no expiry without qosDuration")
+	}
+	expiry := qosExpiryTime(&models.AsSessionWithQoSSubscription{QosDuration: 60})
+	if d := time.Until(expiry); d <= 59*time.Second || d > 60*time.Second {
+		t.Errorf("got expiry in %s, wanted 60s", d)
+	}
+}
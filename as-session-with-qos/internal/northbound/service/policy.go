@@ -0,0 +1,111 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+)
+
+// ------------------------------------------------------------------------------
+// policyProblem gathers the violations of the AF policy into a 403
+// ProblemDetails, nil when there is none.
+func policyProblem(errs ...error) error {
+	invalidParams := []models.InvalidParam{}
+	for _, err := range errs {
+		var violation *afpolicy.Violation
+		if errors.As(err, &violation) {
+			invalidParams = append(invalidParams, models.InvalidParam{Param: violation.Param, Reason: violation.Reason})
+		}
+	}
+	if len(invalidParams) == 0 {
+		return nil
+	}
+	return &models.ProblemError{Problem: models.ProblemDetails{
+		Title:         "Forbidden",
+		Status:        http.StatusForbidden,
+		Detail:        "request not allowed by the AF policy",
+		InvalidParams: invalidParams,
+	}}
+}
+
+// ------------------------------------------------------------------------------
+// errorStatus returns the status carried by a ProblemError, or the given one
+func errorStatus(err error, status int) int {
+	var problemErr *models.ProblemError
+	if errors.As(err, &problemErr) && problemErr.Problem.Status > 0 {
+		return int(problemErr.Problem.Status)
+	}
+	return status
+}
+
+// ------------------------------------------------------------------------------
+// quotaProblem rejects a creation once the AF holds the configured maximum of
+// active subscriptions, nil while below it.
+func (s *Service) quotaProblem(afId string, subscriptions int) error {
+	maxSubs := s.Cfg().Limits.Of(afId).MaxSubscriptions
+	if maxSubs == 0 || subscriptions < maxSubs {
+		return nil
+	}
+	return &models.ProblemError{Problem: models.ProblemDetails{
+		Title:  "Too Many Requests",
+		Status: http.StatusTooManyRequests,
+		Detail: fmt.Sprintf("maximum of %d active subscriptions reached", maxSubs),
+		Cause:  "QUOTA_EXCEEDED",
+	}}
+}
+
+// ------------------------------------------------------------------------------
+// authorizeSessionWithQoS checks the UE, dnn, slice and QoS references of the
+// subscription against the policy of the AF.
+func (s *Service) authorizeSessionWithQoS(afId string, data *models.AsSessionWithQoSSubscription) error {
+	policy := s.Policies().Policy(afId)
+	if policy == nil {
+		return nil
+	}
+
+	errs := []error{}
+	if len(data.Dnn) > 0 {
+		errs = append(errs, policy.CheckDnn("/dnn", data.Dnn))
+	}
+	if data.Snssai.Sst > 0 {
+		errs = append(errs, policy.CheckSnssai("/snssai", data.Snssai.Sst, data.Snssai.Sd))
+	}
+	if len(data.UeIpv4Addr) > 0 {
+		errs = append(errs, policy.CheckUeAddress("/ueIpv4Addr", data.UeIpv4Addr))
+	}
+	if len(data.UeIpv6Addr) > 0 {
+		errs = append(errs, policy.CheckUeAddress("/ueIpv6Addr", data.UeIpv6Addr))
+	}
+	if len(data.QosReference) > 0 {
+		errs = append(errs, policy.CheckQosReference("/qosReference", data.QosReference))
+	}
+	for i, altQosRef := range data.AltQoSReferences {
+		errs = append(errs, policy.CheckQosReference(fmt.Sprintf("/altQoSReferences/%d", i), altQosRef))
+	}
+	for i, altQosReq := range data.AltQosReqs {
+		errs = append(errs, policy.CheckQosReference(fmt.Sprintf("/altQosReqs/%d/altQosParamSetRef", i), altQosReq.AltQosParamSetRef))
+	}
+	return policyProblem(errs...)
+}
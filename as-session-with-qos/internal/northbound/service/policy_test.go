@@ -0,0 +1,93 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+)
+
+func TestAuthorizeSessionWithQoS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	policies := `
+afs:
+  af1:
+    dnns: [internet]
+    qosReferences: [qos1]
+    ueIpRanges: [12.1.1.0/24]
+`
+	if err := os.WriteFile(path, []byte(policies), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := afpolicy.NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	s := NewAsSessionWithQoSService(&testApp{cfg: &config.AppConfig{}, policies: registry})
+
+	data := &models.AsSessionWithQoSSubscription{UeIpv4Addr: "12.1.1.2", Dnn: "internet", QosReference: "qos1"}
+	if err := s.authorizeSessionWithQoS("af1", data); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	data = &models.AsSessionWithQoSSubscription{UeIpv4Addr: "12.1.1.2", Dnn: "internet", QosReference: "qos1", AltQoSReferences: []string{"qos2"}}
+	err = s.authorizeSessionWithQoS("af1", data)
+	var problem *models.ProblemError
+	if !errors.As(err, &problem) {
+		t.Fatalf("got %v, wanted a ProblemError", err)
+	}
+	if errorStatus(err, 0) != 403 {
+		t.Errorf("got status %d, wanted 403", errorStatus(err, 0))
+	}
+	if len(problem.Problem.InvalidParams) != 1 || problem.Problem.InvalidParams[0].Param != "/altQoSReferences/0" {
+		t.Errorf("got invalid params %v, wanted /altQoSReferences/0", problem.Problem.InvalidParams)
+	}
+
+	data = &models.AsSessionWithQoSSubscription{UeIpv4Addr: "12.1.1.2", Dnn: "internet", QosReference: "qos1",
+		AltQosReqs: []models.AlternativeServiceRequirementsData{{AltQosParamSetRef: "qos1"}, {AltQosParamSetRef: "qos2"}}}
+	err = s.authorizeSessionWithQoS("af1", data)
+	if !errors.As(err, &problem) || len(problem.Problem.InvalidParams) != 1 || problem.Problem.InvalidParams[0].Param != "/altQosReqs/1/altQosParamSetRef" {
+		t.Errorf("got %v, wanted /altQosReqs/1/altQosParamSetRef to be forbidden", err)
+	}
+}
+
+func TestQuotaProblem(t *testing.T) {
+	s := NewAsSessionWithQoSService(&testApp{cfg: &config.AppConfig{Limits: config.LimitsConfig{
+		MaxSubscriptions: 2,
+		Afs:              map[string]config.AfLimitsConfig{"af2": {MaxSubscriptions: 5}},
+	}}})
+
+	if err := s.quotaProblem("af1", 1); err != nil {
+		t.Errorf("expected no errors below the quota, got %s", err.Error())
+	}
+	err := s.quotaProblem("af1", 2)
+	if errorStatus(err, 0) != 429 {
+		t.Errorf("got %v, wanted a 429 ProblemError", err)
+	}
+	if err := s.quotaProblem("af2", 2); err != nil {
+		t.Errorf("expected the af2 override to apply, got %s", err.Error())
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+)
+
+// ------------------------------------------------------------------------------
+// qosProfile returns the configured QoS profile of the reference. Unknown
+// references are reported with the list of the valid profile names.
+func (s *Service) qosProfile(param string, qosRef string) (config.QosConfig, error) {
+	qosInfo, ok := s.Cfg().QosConf[qosRef]
+	if !ok {
+		return config.QosConfig{}, &models.ProblemError{Problem: models.ProblemDetails{
+			Title:  "Invalid QoS reference",
+			Detail: fmt.Sprintf("unknown QoS reference %s", qosRef),
+			InvalidParams: []models.InvalidParam{{
+				Param:  param,
+				Reason: "valid QoS references are: " + strings.Join(s.qosProfileNames(), ", "),
+			}},
+		}}
+	}
+	return qosInfo, nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) qosProfileNames() []string {
+	names := make([]string, 0, len(s.Cfg().QosConf))
+	for name := range s.Cfg().QosConf {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ------------------------------------------------------------------------------
+// arpPriority2ResPrio maps the ARP priority level, 1 being the highest, to the
+// reservation priority, PRIO_16 being the highest.
+func arpPriority2ResPrio(arpPriority int32) pcfclient.ReservPriority {
+	return pcfclient.ReservPriority{String: pcfclient.PtrString("PRIO_" + strconv.Itoa(int(17-arpPriority)))}
+}
+
+// ------------------------------------------------------------------------------
+// qosProfile2MediaComponent sets the media component QoS from the profile
+func qosProfile2MediaComponent(qosInfo config.QosConfig, medComponent *pcfclient.MediaComponent) {
+	medComponent.SetMarBwDl(qosInfo.MarBwDl)
+	medComponent.SetMarBwUl(qosInfo.MarBwUl)
+	medComponent.SetMedType(qosInfo.MediaType)
+	medComponent.SetFStatus(qosInfo.FlowStatus())
+
+	if qosInfo.FiveQi > 0 {
+		medComponent.SetQosReference(strconv.Itoa(int(qosInfo.FiveQi)))
+	}
+	if len(qosInfo.GbrDl) > 0 {
+		medComponent.SetMirBwDl(qosInfo.GbrDl)
+	}
+	if len(qosInfo.GbrUl) > 0 {
+		medComponent.SetMirBwUl(qosInfo.GbrUl)
+	}
+	if qosInfo.ArpPriority > 0 {
+		medComponent.SetResPrio(arpPriority2ResPrio(qosInfo.ArpPriority))
+	}
+	if len(qosInfo.PreemptCap) > 0 {
+		medComponent.SetPreemptCap(pcfclient.PreemptionCapability{String: pcfclient.PtrString(qosInfo.PreemptCap)})
+	}
+	if len(qosInfo.PreemptVuln) > 0 {
+		medComponent.SetPreemptVuln(pcfclient.PreemptionVulnerability{String: pcfclient.PtrString(qosInfo.PreemptVuln)})
+	}
+	if qosInfo.MaxPacketLossRateDl != nil {
+		medComponent.SetMaxPacketLossRateDl(*qosInfo.MaxPacketLossRateDl)
+	}
+	if qosInfo.MaxPacketLossRateUl != nil {
+		medComponent.SetMaxPacketLossRateUl(*qosInfo.MaxPacketLossRateUl)
+	}
+	if qosInfo.PacketDelayBudget > 0 {
+		medComponent.SetDesMaxLatency(float32(qosInfo.PacketDelayBudget))
+	}
+}
+
+// ------------------------------------------------------------------------------
+// qosProfile2MediaComponentRm sets the media component QoS from the profile,
+// the attributes the profile does not define are removed from the app session.
+func qosProfile2MediaComponentRm(qosInfo config.QosConfig, medComponent *pcfclient.MediaComponentRm) {
+	medComponent.SetMarBwDl(qosInfo.MarBwDl)
+	medComponent.SetMarBwUl(qosInfo.MarBwUl)
+	medComponent.SetMedType(pcfclient.MediaType{String: pcfclient.PtrString(qosInfo.MediaType)})
+	medComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString(qosInfo.FlowStatus())})
+
+	if qosInfo.FiveQi > 0 {
+		medComponent.SetQosReference(strconv.Itoa(int(qosInfo.FiveQi)))
+	} else {
+		medComponent.SetQosReferenceNil()
+	}
+	if len(qosInfo.GbrDl) > 0 {
+		medComponent.SetMirBwDl(qosInfo.GbrDl)
+	} else {
+		medComponent.SetMirBwDlNil()
+	}
+	if len(qosInfo.GbrUl) > 0 {
+		medComponent.SetMirBwUl(qosInfo.GbrUl)
+	} else {
+		medComponent.SetMirBwUlNil()
+	}
+	if qosInfo.ArpPriority > 0 {
+		medComponent.SetResPrio(arpPriority2ResPrio(qosInfo.ArpPriority))
+	} else {
+		medComponent.SetResPrioNil()
+	}
+	if len(qosInfo.PreemptCap) > 0 {
+		medComponent.SetPreemptCap(pcfclient.PreemptionCapability{String: pcfclient.PtrString(qosInfo.PreemptCap)})
+	} else {
+		medComponent.SetPreemptCapNil()
+	}
+	if len(qosInfo.PreemptVuln) > 0 {
+		medComponent.SetPreemptVuln(pcfclient.PreemptionVulnerability{String: pcfclient.PtrString(qosInfo.PreemptVuln)})
+	} else {
+		medComponent.SetPreemptVulnNil()
+	}
+	if qosInfo.MaxPacketLossRateDl != nil {
+		medComponent.SetMaxPacketLossRateDl(*qosInfo.MaxPacketLossRateDl)
+	} else {
+		medComponent.SetMaxPacketLossRateDlNil()
+	}
+	if qosInfo.MaxPacketLossRateUl != nil {
+		medComponent.SetMaxPacketLossRateUl(*qosInfo.MaxPacketLossRateUl)
+	} else {
+		medComponent.SetMaxPacketLossRateUlNil()
+	}
+	if qosInfo.PacketDelayBudget > 0 {
+		medComponent.SetDesMaxLatency(float32(qosInfo.PacketDelayBudget))
+	} else {
+		medComponent.SetDesMaxLatencyNil()
+	}
+}
+
+// ------------------------------------------------------------------------------
+// qosProfile2Sponsor sets the sponsor and AF charging information of the profile
+func qosProfile2Sponsor(qosInfo config.QosConfig, req *pcfclient.AppSessionContextReqData) {
+	if len(qosInfo.SponsorId) > 0 {
+		req.SetSponId(qosInfo.SponsorId)
+		req.SetSponStatus(pcfclient.SponsoringStatus{String: pcfclient.PtrString("SPONSOR_ENABLED")})
+	}
+	if len(qosInfo.AspId) > 0 {
+		req.SetAspId(qosInfo.AspId)
+	}
+	if len(qosInfo.AfChargId) > 0 {
+		req.SetAfChargId(qosInfo.AfChargId)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// qosProfile2SponsorUpdate sets the sponsor and AF charging information of the
+// profile. The sponsoring is disabled, and the aspId and afChargId removed,
+// when the previous profile defined them and the new one does not.
+func qosProfile2SponsorUpdate(prev config.QosConfig, qosInfo config.QosConfig, req *pcfclient.AppSessionContextUpdateData) {
+	if len(qosInfo.SponsorId) > 0 {
+		req.SetSponId(qosInfo.SponsorId)
+		req.SetSponStatus(pcfclient.SponsoringStatus{String: pcfclient.PtrString("SPONSOR_ENABLED")})
+	} else if len(prev.SponsorId) > 0 {
+		req.SetSponStatus(pcfclient.SponsoringStatus{String: pcfclient.PtrString("SPONSOR_DISABLED")})
+	}
+	if len(qosInfo.AspId) > 0 {
+		req.SetAspId(qosInfo.AspId)
+	} else if len(prev.AspId) > 0 {
+		req.SetAspIdNil()
+	}
+	if len(qosInfo.AfChargId) > 0 {
+		req.SetAfChargId(qosInfo.AfChargId)
+	} else if len(prev.AfChargId) > 0 {
+		req.SetAfChargIdNil()
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+)
+
+func TestQosProfileUnknownReference(t *testing.T) {
+	s := newTestService()
+
+	_, err := s.qosProfile("/qosReference", "qos9")
+	var problemErr *models.ProblemError
+	if !errors.As(err, &problemErr) {
+		t.Fatalf("expected a ProblemError, got %v", err)
+	}
+	if len(problemErr.Problem.InvalidParams) != 1 {
+		t.Fatalf("expected 1 invalid param, got %v", problemErr.Problem.InvalidParams)
+	}
+	invalidParam := problemErr.Problem.InvalidParams[0]
+	if invalidParam.Param != "/qosReference" || invalidParam.Reason != "valid QoS references are: qos1, qos2" {
+		t.Errorf("unexpected invalid param %+v", invalidParam)
+	}
+
+	if _, err := s.qosProfile("/qosReference", "qos1"); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+}
+
+func TestQosProfile2MediaComponent(t *testing.T) {
+	lossRate := int32(10)
+	qosInfo := config.QosConfig{
+		MarBwDl:             "10 Mbps",
+		MarBwUl:             "5 Mbps",
+		MediaType:           "VIDEO",
+		FiveQi:              2,
+		GbrDl:               "4 Mbps",
+		GbrUl:               "2 Mbps",
+		ArpPriority:         1,
+		PreemptCap:          "MAY_PREEMPT",
+		PreemptVuln:         "NOT_PREEMPTABLE",
+		MaxPacketLossRateDl: &lossRate,
+		PacketDelayBudget:   150,
+		FlowStatusUl:        "DISABLED",
+	}
+	medComponent := pcfclient.MediaComponent{}
+	qosProfile2MediaComponent(qosInfo, &medComponent)
+
+	if medComponent.GetQosReference() != "2" {
+		t.Errorf("expected qosReference 2, got %s", medComponent.GetQosReference())
+	}
+	if medComponent.GetMarBwDl() != "10 Mbps" || medComponent.GetMirBwDl() != "4 Mbps" || medComponent.GetMirBwUl() != "2 Mbps" {
+		t.Errorf("unexpected bandwidth %s %s %s", medComponent.GetMarBwDl(), medComponent.GetMirBwDl(), medComponent.GetMirBwUl())
+	}
+	if resPrio := medComponent.GetResPrio(); resPrio.String == nil || *resPrio.String != "PRIO_16" {
+		t.Errorf("expected PRIO_16, got %v", resPrio)
+	}
+	if preemptCap := medComponent.GetPreemptCap(); preemptCap.String == nil || *preemptCap.String != "MAY_PREEMPT" {
+		t.Errorf("expected MAY_PREEMPT, got %v", preemptCap)
+	}
+	if medComponent.GetMaxPacketLossRateDl() != 10 || medComponent.MaxPacketLossRateUl.IsSet() {
+		t.Errorf("unexpected max packet loss rate %v %v", medComponent.MaxPacketLossRateDl, medComponent.MaxPacketLossRateUl)
+	}
+	if medComponent.GetDesMaxLatency() != 150 {
+		t.Errorf("expected desMaxLatency 150, got %f", medComponent.GetDesMaxLatency())
+	}
+	if medComponent.GetFStatus() != "ENABLED-DOWNLINK" {
+		t.Errorf("expected ENABLED-DOWNLINK, got %s", medComponent.GetFStatus())
+	}
+}
+
+func TestQosProfile2MediaComponentRm(t *testing.T) {
+	medComponent := pcfclient.MediaComponentRm{}
+	qosProfile2MediaComponentRm(config.QosConfig{MarBwDl: "1 Mbps", ArpPriority: 15}, &medComponent)
+	if resPrio := medComponent.GetResPrio(); resPrio.String == nil || *resPrio.String != "PRIO_2" {
+		t.Errorf("expected PRIO_2, got %v", resPrio)
+	}
+
+	/*a profile without ARP priority removes the one of the previous profile*/
+	medComponent = pcfclient.MediaComponentRm{}
+	qosProfile2MediaComponentRm(config.QosConfig{MarBwDl: "1 Mbps"}, &medComponent)
+	if resPrio, set := medComponent.GetResPrioOk(); !set || resPrio != nil {
+		t.Errorf("expected the resPrio to be removed, got %v", resPrio)
+	}
+	body, _ := json.Marshal(medComponent)
+	if !strings.Contains(string(body), `"resPrio":null`) {
+		t.Errorf("expected a null resPrio in %s", body)
+	}
+}
+
+func TestQosProfile2SponsorUpdate(t *testing.T) {
+	sponsored := config.QosConfig{SponsorId: "sponsor1", AspId: "asp1", AfChargId: "charg1"}
+
+	req := pcfclient.AppSessionContextUpdateData{}
+	qosProfile2SponsorUpdate(config.QosConfig{}, sponsored, &req)
+	if req.GetSponId() != "sponsor1" || req.GetAspId() != "asp1" || req.GetAfChargId() != "charg1" {
+		t.Errorf("unexpected sponsoring %v", req)
+	}
+
+	req = pcfclient.AppSessionContextUpdateData{}
+	qosProfile2SponsorUpdate(sponsored, config.QosConfig{}, &req)
+	if status := req.GetSponStatus(); status.String == nil || *status.String != "SPONSOR_DISABLED" {
+		t.Errorf("expected SPONSOR_DISABLED, got %v", status)
+	}
+	body, _ := json.Marshal(req)
+	for _, attr := range []string{"aspId", "afChargId"} {
+		if !strings.Contains(string(body), `"`+attr+`":null`) {
+			t.Errorf("expected %s to be removed in %s", attr, body)
+		}
+	}
+}
+
+func TestQosConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		qosInfo config.QosConfig
+		valid   bool
+	}{
+		{"empty profile", config.QosConfig{}, true},
+		{"complete profile", config.QosConfig{FiveQi: 9, ArpPriority: 15, PreemptCap: "NOT_PREEMPT", FlowStatusDl: "DISABLED"}, true},
+		{"arp priority out of range", config.QosConfig{ArpPriority: 16}, false},
+		{"negative arp priority", config.QosConfig{ArpPriority: -1}, false},
+		{"invalid preemption capability", config.QosConfig{PreemptCap: "ALWAYS"}, false},
+		{"invalid flow status", config.QosConfig{FlowStatusUl: "REMOVED"}, false},
+	}
+	for _, tt := range tests {
+		err := tt.qosInfo.Validate()
+		if tt.valid && err != nil {
+			t.Errorf("%s: expected no errors, got %s", tt.name, err.Error())
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
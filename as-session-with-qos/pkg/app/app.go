@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -33,6 +34,7 @@ import (
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/northbound"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/internal/northbound/service"
 	"gitlab.eurecom.fr/open-exposure/nef/as-session-with-qos/pkg/config"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
 )
 
 type AppCtx struct {
@@ -49,6 +51,7 @@ type AppCtx struct {
 
 	//contexts
 	AsSessionAppCtx *contexts.AsSessionAppCtx
+	policies        *afpolicy.Registry
 }
 
 func InitApplication(name string, configPath string) (*AppCtx, error) {
@@ -59,15 +62,38 @@ func InitApplication(name string, configPath string) (*AppCtx, error) {
 		appId:   uuid.New().String(),
 	}
 
-	appInstance.server, _ = northbound.NewNorthbound(appInstance)
+	policies, err := afpolicy.NewRegistry(appInstance.config.AfPolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load AF policies: %w", err)
+	}
+	appInstance.policies = policies
+
+	server, err := northbound.NewNorthbound(appInstance)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the northbound: %w", err)
+	}
+	appInstance.server = server
 	appInstance.service = service.NewAsSessionWithQoSService(appInstance)
 	appInstance.connector = connector.NewConnector(appInstance)
 
-	appInstance.AsSessionAppCtx = contexts.NewAsSessionAppCtx(appInstance)
+	appInstance.AsSessionAppCtx = contexts.NewAsSessionAppCtx(appInstance, newSubscriptionStore(appInstance.config))
+	if err := appInstance.service.RestoreSessionWithQoSSubscriptions(); err != nil {
+		return nil, fmt.Errorf("could not restore subscriptions: %w", err)
+	}
 
 	return appInstance, nil
 }
 
+// newSubscriptionStore selects where the subscriptions are persisted
+func newSubscriptionStore(cfg *config.AppConfig) contexts.SubscriptionStore {
+	switch cfg.SubsStore {
+	case "redis":
+		return contexts.NewRedisStore(cfg.Sbi.RedisSvc, "as-session-with-qos")
+	default:
+		return contexts.NewMemoryStore()
+	}
+}
+
 func (app *AppCtx) Cfg() *config.AppConfig {
 	return app.config
 }
@@ -88,6 +114,10 @@ func (app *AppCtx) Ctx() *contexts.AsSessionAppCtx {
 	return app.AsSessionAppCtx
 }
 
+func (app *AppCtx) Policies() *afpolicy.Registry {
+	return app.policies
+}
+
 func (app *AppCtx) Nbi() *northbound.NbiServer {
 	return app.server
 }
@@ -99,6 +129,7 @@ func (app *AppCtx) Run() {
 
 	app.wg.Add(1)
 	go app.listenShutdownEvent()
+	go app.policies.Watch(app.ctx, afpolicy.ReloadInterval)
 
 	log.Printf("starting: %s (%s)", app.appName, app.appId)
 	log.Printf("running config: \n%s", app.config.Dumps())
@@ -20,23 +20,59 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
 
 	"gopkg.in/yaml.v3"
 )
 
 type AppConfig struct {
 	/* Basic configuration parameters */
-	Sbi           SbiConfig `yaml:"sbi"`
-	Nbi           NbiConfig `yaml:"nbi"`
-	CapifSvc      string    `yaml:"capifSvc"`
-	SupportedFeat string    `yaml:"supportedFeatures"`
+	Sbi           SbiConfig    `yaml:"sbi"`
+	Nbi           NbiConfig    `yaml:"nbi"`
+	CapifSvc      string       `yaml:"capifSvc"`
+	SupportedFeat string       `yaml:"supportedFeatures"`
+	SubsStore     string       `yaml:"subscriptionStore"` /*memory (default) or redis, stored in sbi redisSvc*/
+	AfPolicyFile  string       `yaml:"afPolicyFile"`      /*optional per-AF authorization policies, reloaded on change*/
+	Limits        LimitsConfig `yaml:"limits"`            /*per-AF request rate and subscription quota*/
 
 	/* Custom configuration parameters */
 	QosConf map[string]QosConfig `yaml:"qosConfig"`
 }
 
+// LimitsConfig bounds the request rate and the active subscriptions of the AFs
+type LimitsConfig struct {
+	Rate             float64                   `yaml:"rate"`             /*requests per second of an AF, 0 for unlimited*/
+	Burst            int                       `yaml:"burst"`            /*requests accepted at once, defaults to one second worth*/
+	MaxSubscriptions int                       `yaml:"maxSubscriptions"` /*active subscriptions of an AF, 0 for unlimited*/
+	Afs              map[string]AfLimitsConfig `yaml:"afs"`              /*per AF overrides, unset values inheriting the above*/
+}
+
+type AfLimitsConfig struct {
+	Rate             float64 `yaml:"rate"`
+	Burst            int     `yaml:"burst"`
+	MaxSubscriptions int     `yaml:"maxSubscriptions"`
+}
+
+// Of returns the limits of the AF
+func (l LimitsConfig) Of(afId string) AfLimitsConfig {
+	limits := AfLimitsConfig{Rate: l.Rate, Burst: l.Burst, MaxSubscriptions: l.MaxSubscriptions}
+	if af, ok := l.Afs[afId]; ok {
+		if af.Rate > 0 {
+			limits.Rate = af.Rate
+		}
+		if af.Burst > 0 {
+			limits.Burst = af.Burst
+		}
+		if af.MaxSubscriptions > 0 {
+			limits.MaxSubscriptions = af.MaxSubscriptions
+		}
+	}
+	return limits
+}
+
 type NbiConfig struct {
 	HttpVersion uint16 `yaml:"httpVersion"`
 	UseTLS      bool   `yaml:"useTLS"`
@@ -48,13 +84,35 @@ type SbiConfig struct {
 	NrfSvc      string `yaml:"nrfSvc"`
 	UseNrf      bool   `yaml:"useNrf"`
 	PcfSvc      string `yaml:"pcfSvc"`
+	BsfSvc      string `yaml:"bsfSvc"`      /*optional, PCF bindings are looked up by UE address before NRF discovery*/
+	NrfCacheTtl int    `yaml:"nrfCacheTtl"` /*seconds the discovered PCF profiles are kept, defaults to 60*/
+	IdentitySvc string `yaml:"identitySvc"`
+	ProfileSvc  string `yaml:"profileSvc"`
+	RedisSvc    string `yaml:"redisSvc"`    /*group store, external groups are resolved from group:<extGroupId> sets*/
+	Port        uint16 `yaml:"port"`        /*listen port of the PCF callbacks, apart from the nbi port, defaults to 8081*/
+	CallbackUri string `yaml:"callbackUri"` /*NEF address reachable by the PCF, defaults to nbi fqdn and sbi port*/
 	Httpversion int    `yaml:"httpVersion"`
 }
 
 type QosConfig struct {
-	MarBwDl   string `yaml:"marBwDl"`
-	MarBwUl   string `yaml:"marBwUl"`
+	MarBwDl   string `yaml:"marBwDl"`   /*MBR downlink*/
+	MarBwUl   string `yaml:"marBwUl"`   /*MBR uplink*/
 	MediaType string `yaml:"mediaType"` /*allowed values CONTROL, AUDIO, VIDEO*/
+
+	FiveQi              int32  `yaml:"5qi"`                 /*sent as the pre-defined qosReference of the media component*/
+	GbrDl               string `yaml:"gbrDl"`               /*GBR downlink, sent as the minimum requested bandwidth*/
+	GbrUl               string `yaml:"gbrUl"`               /*GBR uplink, sent as the minimum requested bandwidth*/
+	ArpPriority         int32  `yaml:"arpPriority"`         /*1 (highest) to 15 (lowest), unset when 0*/
+	PreemptCap          string `yaml:"preemptCap"`          /*allowed values MAY_PREEMPT, NOT_PREEMPT*/
+	PreemptVuln         string `yaml:"preemptVuln"`         /*allowed values PREEMPTABLE, NOT_PREEMPTABLE*/
+	MaxPacketLossRateDl *int32 `yaml:"maxPacketLossRateDl"` /*in tenth of percent, 0 to 1000*/
+	MaxPacketLossRateUl *int32 `yaml:"maxPacketLossRateUl"` /*in tenth of percent, 0 to 1000*/
+	PacketDelayBudget   int32  `yaml:"packetDelayBudget"`   /*in milliseconds*/
+	FlowStatusDl        string `yaml:"flowStatusDl"`        /*allowed values ENABLED (default), DISABLED*/
+	FlowStatusUl        string `yaml:"flowStatusUl"`        /*allowed values ENABLED (default), DISABLED*/
+	SponsorId           string `yaml:"sponsorId"`
+	AspId               string `yaml:"aspId"`
+	AfChargId           string `yaml:"afChargId"`
 }
 
 func InitConfig(configPath string) *AppConfig {
@@ -69,9 +127,67 @@ func InitConfig(configPath string) *AppConfig {
 	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
+	if cfg.Sbi.Port == 0 {
+		cfg.Sbi.Port = 8081
+	}
+	if cfg.Sbi.Port == cfg.Nbi.Port {
+		log.Fatalf("sbi port must differ from the nbi port, PCF callbacks are not exposed to the AFs")
+	}
+	for name, qosConf := range cfg.QosConf {
+		if err := qosConf.Validate(); err != nil {
+			log.Fatalf("invalid qosConfig %s: %v", name, err)
+		}
+	}
 	return &cfg
 }
 
+// Validate checks the values of the QoS profile that are not free text
+func (q *QosConfig) Validate() error {
+	/*0 leaves the 5qi and arpPriority unset*/
+	if q.FiveQi < 0 || q.FiveQi > 255 {
+		return fmt.Errorf("5qi must be between 1 and 255")
+	}
+	if q.ArpPriority < 0 || q.ArpPriority > 15 {
+		return fmt.Errorf("arpPriority must be between 1 and 15")
+	}
+	if !slices.Contains([]string{"", "MAY_PREEMPT", "NOT_PREEMPT"}, q.PreemptCap) {
+		return fmt.Errorf("invalid preemptCap %s", q.PreemptCap)
+	}
+	if !slices.Contains([]string{"", "PREEMPTABLE", "NOT_PREEMPTABLE"}, q.PreemptVuln) {
+		return fmt.Errorf("invalid preemptVuln %s", q.PreemptVuln)
+	}
+	for _, rate := range []*int32{q.MaxPacketLossRateDl, q.MaxPacketLossRateUl} {
+		if rate != nil && (*rate < 0 || *rate > 1000) {
+			return fmt.Errorf("maxPacketLossRate must be between 0 and 1000")
+		}
+	}
+	if q.PacketDelayBudget < 0 {
+		return fmt.Errorf("packetDelayBudget must be positive")
+	}
+	for _, status := range []string{q.FlowStatusDl, q.FlowStatusUl} {
+		if !slices.Contains([]string{"", "ENABLED", "DISABLED"}, status) {
+			return fmt.Errorf("invalid flow status %s", status)
+		}
+	}
+	return nil
+}
+
+// FlowStatus combines the per direction flow status into the PCF flow status
+func (q *QosConfig) FlowStatus() string {
+	dl := q.FlowStatusDl != "DISABLED"
+	ul := q.FlowStatusUl != "DISABLED"
+	switch {
+	case dl && ul:
+		return "ENABLED"
+	case dl:
+		return "ENABLED-DOWNLINK"
+	case ul:
+		return "ENABLED-UPLINK"
+	default:
+		return "DISABLED"
+	}
+}
+
 func (cfg *AppConfig) Dumps() string {
 	d, err := yaml.Marshal(&cfg)
 	if err != nil {
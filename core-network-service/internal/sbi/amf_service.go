@@ -163,7 +163,7 @@ func getUpdateRegistration(report amf_client.AmfEventReport) ([]byte, error) {
 	//       	}
 	//	}
 
-	timeStamp := time.Now().Unix()
+	timeStamp := reportTime(report)
 	// TODO: fix (get rid of) the "RmStateAnyOf" field
 	push := rmInfo{
 		RmInfo:    rmInfoList[len(rmInfoList)-1],
@@ -181,7 +181,7 @@ func getUpdateLocation(report amf_client.AmfEventReport) ([]byte, error) {
 		return nil, errors.New("failed to get Location")
 	}
 	//log.Printf("%+v\n", *locationObj)
-	timeStamp := time.Now().Unix()
+	timeStamp := reportTime(report)
 	push := location{UserLocation: *locationObj, TimeStamp: timeStamp}
 	update, err := json.Marshal(push)
 	return update, err
@@ -195,7 +195,7 @@ func getUpdateLossOfConnectivity(report amf_client.AmfEventReport) ([]byte, erro
 		return nil, errors.New("failed to get lossOfConnectReason")
 	}
 	//log.Printf("%+v\n", *lossOfConnectReasonObj.LossOfConnectivityReasonAnyOf)
-	timeStamp := time.Now().Unix()
+	timeStamp := reportTime(report)
 	push := lossOfConnectReason{
 		LossOfConnectReason: *lossOfConnectReasonObj.LossOfConnectivityReasonAnyOf,
 		TimeStamp:           timeStamp,
@@ -212,7 +212,7 @@ func getUpdateConnectivityStateReport(report amf_client.AmfEventReport) ([]byte,
 		return nil, errors.New("failed to get cmInfoList")
 	}
 
-	timeStamp := time.Now().Unix()
+	timeStamp := reportTime(report)
 	// TODO: fix (get rid of) the "RmStateAnyOf" field
 	push := cmInfo{
 		CmInfo:    cmInfoList[len(cmInfoList)-1],
@@ -222,3 +222,13 @@ func getUpdateConnectivityStateReport(report amf_client.AmfEventReport) ([]byte,
 	update, err := json.Marshal(push)
 	return update, err
 }
+
+// ------------------------------------------------------------------------------
+// reportTime - Return the time of the report, the reception time when the AMF
+// did not give one
+func reportTime(report amf_client.AmfEventReport) int64 {
+	if timeStamp := report.GetTimeStamp().Unix(); timeStamp > 0 {
+		return timeStamp
+	}
+	return time.Now().Unix()
+}
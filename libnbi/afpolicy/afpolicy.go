@@ -0,0 +1,294 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package afpolicy
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ReloadInterval is how often the policy file is checked for changes
+const ReloadInterval = 5 * time.Second
+
+// Snssai is an allowed slice, an empty sd matching any sd of the sst
+type Snssai struct {
+	Sst int32  `yaml:"sst"`
+	Sd  string `yaml:"sd"`
+}
+
+// Policy restricts what an AF may request, an empty list leaving the
+// attribute unrestricted
+type Policy struct {
+	InvokerIds       []string `yaml:"invokerIds"` /*CAPIF invokers allowed to act as the AF*/
+	Dnns             []string `yaml:"dnns"`
+	Snssais          []Snssai `yaml:"snssais"`
+	Dnais            []string `yaml:"dnais"`
+	QosReferences    []string `yaml:"qosReferences"`
+	MaxSubscriptions int      `yaml:"maxSubscriptions"` /*0 for unlimited*/
+	UeIpRanges       []string `yaml:"ueIpRanges"`       /*IPv4 or IPv6 prefixes*/
+
+	prefixes []netip.Prefix
+}
+
+type policyFile struct {
+	Afs map[string]*Policy `yaml:"afs"`
+}
+
+// Violation is a request outside of the policy of the AF
+type Violation struct {
+	Param  string
+	Reason string
+}
+
+func (v *Violation) Error() string {
+	return v.Param + ": " + v.Reason
+}
+
+// Registry holds the policies of the AFs, a nil registry leaving every AF
+// unrestricted
+type Registry struct {
+	path    string
+	mu      sync.RWMutex
+	modTime time.Time
+	afs     map[string]*Policy
+}
+
+// NewRegistry loads the AF policies from the file, nil when no file is given
+func NewRegistry(path string) (*Registry, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+	r := &Registry{path: path, afs: make(map[string]*Policy)}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Authorize checks that the AF has a policy and, when the policy lists CAPIF
+// invokers, that the request comes from one of them. The invokerId must be the
+// one of the validated CAPIF token, without it an AF bound to invokers is
+// always rejected.
+func (r *Registry) Authorize(afId string, invokerId string) error {
+	if r == nil {
+		return nil
+	}
+	policy := r.Policy(afId)
+	if policy == nil {
+		return &Violation{Param: "afId", Reason: "no policy for the AF"}
+	}
+	if len(policy.InvokerIds) == 0 {
+		return nil
+	}
+	if len(invokerId) == 0 {
+		return &Violation{Param: "afId", Reason: "no authenticated API invoker"}
+	}
+	if !slices.Contains(policy.InvokerIds, invokerId) {
+		return &Violation{Param: "afId", Reason: "not bound to the API invoker"}
+	}
+	return nil
+}
+
+// Binds tells whether the policy of the AF binds it to the authenticated
+// invoker, so that the afId of the request can be trusted
+func (r *Registry) Binds(afId string, invokerId string) bool {
+	policy := r.Policy(afId)
+	return policy != nil && len(invokerId) > 0 && slices.Contains(policy.InvokerIds, invokerId)
+}
+
+// BindsInvokers tells whether any AF is restricted to CAPIF invokers
+func (r *Registry) BindsInvokers() bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, policy := range r.afs {
+		if len(policy.InvokerIds) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Policy returns the policy of the AF, nil if none
+func (r *Registry) Policy(afId string) *Policy {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.afs[afId]
+}
+
+// Watch reloads the policies whenever the file changes, until ctx is done. A
+// file that cannot be loaded leaves the previous policies in place.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration) {
+	if r == nil {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reload(); err != nil {
+				log.Printf("could not reload AF policies: %s", err)
+			}
+		}
+	}
+}
+
+func (r *Registry) reload() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	r.mu.RLock()
+	unchanged := info.ModTime().Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	return r.load()
+}
+
+func (r *Registry) load() error {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(r.path)
+	if err != nil {
+		return err
+	}
+	file := policyFile{}
+	if err := yaml.Unmarshal(content, &file); err != nil {
+		return err
+	}
+	afs := make(map[string]*Policy)
+	for afId, policy := range file.Afs {
+		if policy == nil {
+			policy = &Policy{}
+		}
+		for _, ipRange := range policy.UeIpRanges {
+			prefix, err := netip.ParsePrefix(ipRange)
+			if err != nil {
+				return fmt.Errorf("af %s: invalid ueIpRange %s", afId, ipRange)
+			}
+			policy.prefixes = append(policy.prefixes, prefix.Masked())
+		}
+		afs[afId] = policy
+	}
+
+	r.mu.Lock()
+	r.afs = afs
+	r.modTime = info.ModTime()
+	r.mu.Unlock()
+	log.Printf("loaded the policies of %d AFs from %s", len(afs), r.path)
+	return nil
+}
+
+// CheckDnn checks that the AF may request the dnn
+func (p *Policy) CheckDnn(param string, dnn string) error {
+	if p == nil || len(p.Dnns) == 0 || slices.Contains(p.Dnns, dnn) {
+		return nil
+	}
+	return &Violation{Param: param, Reason: "dnn " + dnn + " not allowed"}
+}
+
+// CheckSnssai checks that the AF may request the slice
+func (p *Policy) CheckSnssai(param string, sst int32, sd string) error {
+	if p == nil || len(p.Snssais) == 0 {
+		return nil
+	}
+	for _, snssai := range p.Snssais {
+		if snssai.Sst == sst && (len(snssai.Sd) == 0 || snssai.Sd == sd) {
+			return nil
+		}
+	}
+	return &Violation{Param: param, Reason: fmt.Sprintf("snssai %d/%s not allowed", sst, sd)}
+}
+
+// CheckDnai checks that the AF may steer the traffic to the dnai
+func (p *Policy) CheckDnai(param string, dnai string) error {
+	if p == nil || len(p.Dnais) == 0 || slices.Contains(p.Dnais, dnai) {
+		return nil
+	}
+	return &Violation{Param: param, Reason: "dnai " + dnai + " not allowed"}
+}
+
+// CheckQosReference checks that the AF may request the QoS profile
+func (p *Policy) CheckQosReference(param string, qosReference string) error {
+	if p == nil || len(p.QosReferences) == 0 || slices.Contains(p.QosReferences, qosReference) {
+		return nil
+	}
+	return &Violation{Param: param, Reason: "qos reference " + qosReference + " not allowed"}
+}
+
+// CheckUeAddress checks that the UE address, or the whole IPv6 prefix, is
+// within the ranges of the AF
+func (p *Policy) CheckUeAddress(param string, ueAddr string) error {
+	if p == nil || len(p.prefixes) == 0 {
+		return nil
+	}
+	ue, err := netip.ParsePrefix(ueAddr)
+	if err != nil {
+		addr, err := netip.ParseAddr(ueAddr)
+		if err != nil {
+			return &Violation{Param: param, Reason: "invalid UE address"}
+		}
+		ue = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	for _, prefix := range p.prefixes {
+		if ue.Bits() >= prefix.Bits() && prefix.Contains(ue.Addr()) {
+			return nil
+		}
+	}
+	return &Violation{Param: param, Reason: "UE address " + ueAddr + " not allowed"}
+}
+
+// CheckUeRange checks that the AF may target UEs whose address is not given,
+// as all the UEs of a dnn or the members of a group, which an AF limited to
+// ranges of UE addresses may not
+func (p *Policy) CheckUeRange(param string) error {
+	if p == nil || len(p.prefixes) == 0 {
+		return nil
+	}
+	return &Violation{Param: param, Reason: "UEs outside of the allowed UE address ranges"}
+}
+
+// CheckSubscriptions checks that the AF may hold one more subscription
+func (p *Policy) CheckSubscriptions(count int) error {
+	if p == nil || p.MaxSubscriptions == 0 || count < p.MaxSubscriptions {
+		return nil
+	}
+	return &Violation{Param: "afId", Reason: fmt.Sprintf("maximum of %d subscriptions reached", p.MaxSubscriptions)}
+}
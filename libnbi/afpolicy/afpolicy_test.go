@@ -0,0 +1,182 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package afpolicy
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePolicies(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNoRegistry(t *testing.T) {
+	r, err := NewRegistry("")
+	if err != nil || r != nil {
+		t.Fatalf("got %v, %v without file, wanted no registry", r, err)
+	}
+	if err := r.Authorize("af1", ""); err != nil {
+		t.Errorf("expected every AF to be authorized, got %s", err)
+	}
+	if err := r.Policy("af1").CheckDnn("/dnn", "internet"); err != nil {
+		t.Errorf("expected every dnn to be allowed, got %s", err)
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	writePolicies(t, path, `
+afs:
+  af1:
+    invokerIds: [invoker1]
+  af2:
+`, time.Now())
+
+	r, err := NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err)
+	}
+	if err := r.Authorize("af1", "invoker1"); err != nil {
+		t.Errorf("expected af1 to be authorized for invoker1, got %s", err)
+	}
+	if err := r.Authorize("af1", "invoker2"); err == nil {
+		t.Errorf("expected af1 not to be authorized for invoker2")
+	}
+	if err := r.Authorize("af1", ""); err == nil {
+		t.Errorf("expected af1 not to be authorized without an authenticated invoker")
+	}
+	if !r.Binds("af1", "invoker1") || r.Binds("af1", "") || r.Binds("af2", "invoker1") {
+		t.Errorf("expected af1 only to be bound to invoker1")
+	}
+	if !r.BindsInvokers() {
+		t.Errorf("expected the registry to bind invokers")
+	}
+	if err := r.Authorize("af2", "invoker2"); err != nil {
+		t.Errorf("expected af2 to be authorized for any invoker, got %s", err)
+	}
+	var violation *Violation
+	if err := r.Authorize("af3", "invoker1"); !errors.As(err, &violation) {
+		t.Errorf("got %v for an AF without policy, wanted a Violation", err)
+	}
+}
+
+func TestPolicyChecks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	writePolicies(t, path, `
+afs:
+  af1:
+    dnns: [internet]
+    snssais: [{sst: 1, sd: "000001"}, {sst: 2}]
+    dnais: [MEC1]
+    qosReferences: [qos1]
+    maxSubscriptions: 2
+    ueIpRanges: [12.1.1.0/24, "2001:db8::/32"]
+`, time.Now())
+
+	r, err := NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err)
+	}
+	p := r.Policy("af1")
+
+	allowed := []error{
+		p.CheckDnn("/dnn", "internet"),
+		p.CheckSnssai("/snssai", 1, "000001"),
+		p.CheckSnssai("/snssai", 2, "000005"),
+		p.CheckDnai("/dnai", "MEC1"),
+		p.CheckQosReference("/qosReference", "qos1"),
+		p.CheckUeAddress("/ueIpv4Addr", "12.1.1.2"),
+		p.CheckUeAddress("/ueIpv6Addr", "2001:db8::1"),
+		p.CheckUeAddress("/ueIpv6Prefix", "2001:db8:1::/64"),
+		p.CheckSubscriptions(1),
+	}
+	for i, err := range allowed {
+		if err != nil {
+			t.Errorf("check %d: expected no errors, got %s", i, err)
+		}
+	}
+
+	denied := []error{
+		p.CheckDnn("/dnn", "ims"),
+		p.CheckSnssai("/snssai", 1, "000002"),
+		p.CheckDnai("/dnai", "MEC2"),
+		p.CheckQosReference("/qosReference", "qos2"),
+		p.CheckUeAddress("/ueIpv4Addr", "12.1.2.2"),
+		p.CheckUeAddress("/ueIpv4Addr", "not-an-address"),
+		p.CheckUeAddress("/ueIpv6Prefix", "2001:db8::/16"),
+		p.CheckUeAddress("/ueIpv6Prefix", "2001::/16"),
+		p.CheckSubscriptions(2),
+		p.CheckUeRange("/anyUeInd"),
+	}
+	for i, err := range denied {
+		var violation *Violation
+		if !errors.As(err, &violation) {
+			t.Errorf("check %d: got %v, wanted a Violation", i, err)
+		}
+	}
+
+	if err := r.Policy("af2").CheckUeRange("/anyUeInd"); err != nil {
+		t.Errorf("expected an AF without ueIpRanges to target any UE, got %s", err)
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	start := time.Now().Add(-time.Minute)
+	writePolicies(t, path, "afs:\n  af1:\n", start)
+
+	r, err := NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err)
+	}
+
+	/*an invalid file keeps the previous policies*/
+	writePolicies(t, path, "afs:\n  af2:\n    ueIpRanges: [invalid]\n", start.Add(time.Second))
+	if err := r.reload(); err == nil {
+		t.Errorf("expected an error for an invalid ueIpRange")
+	}
+	if r.Policy("af1") == nil {
+		t.Errorf("expected af1 policy to be kept")
+	}
+
+	writePolicies(t, path, "afs:\n  af2:\n", start.Add(2*time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Watch(ctx, 10*time.Millisecond)
+
+	deadline := time.Now().Add(time.Second)
+	for r.Policy("af2") == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r.Policy("af2") == nil || r.Policy("af1") != nil {
+		t.Errorf("expected the policies to be reloaded with af2 only")
+	}
+}
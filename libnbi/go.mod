@@ -0,0 +1,17 @@
+module gitlab.eurecom.fr/open-exposure/nef/libnbi
+
+go 1.22.0
+
+require (
+	github.com/redis/go-redis/v9 v9.11.0
+	gitlab.eurecom.fr/open-exposure/nef/nrfclient v1.0.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	golang.org/x/net v0.30.0 // indirect
+	golang.org/x/oauth2 v0.23.0 // indirect
+	golang.org/x/text v0.19.0 // indirect
+)
@@ -0,0 +1,139 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+// Package identity resolves the GPSIs of the AFs into UE SUPIs with the UE
+// identity service, and retrieves the live UE profiles from the UE profile
+// service.
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client holds the api roots of the identity and profile services
+type Client struct {
+	IdentitySvc string
+	ProfileSvc  string
+	client      *http.Client
+}
+
+func NewClient(identitySvc string, profileSvc string) *Client {
+	return &Client{
+		IdentitySvc: identitySvc,
+		ProfileSvc:  profileSvc,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ------------------------------------------------------------------------------
+// LookupGpsi resolves a GPSI (msisdn- or extid- form) of the AF into the UE
+// SUPI. The external identifiers are only resolved for the AF they were
+// issued to, the unprefixed values are rejected.
+func (c *Client) LookupGpsi(afId string, gpsi string) (string, error) {
+	query := url.Values{"afId": {afId}}
+	switch {
+	case strings.HasPrefix(gpsi, "msisdn-"):
+		query.Set("msisdn", strings.TrimPrefix(gpsi, "msisdn-"))
+	case strings.HasPrefix(gpsi, "extid-"):
+		/*the identity service issues the external identifiers as afId:encoded*/
+		externalId := strings.TrimPrefix(gpsi, "extid-")
+		if issuer, _, found := strings.Cut(externalId, ":"); !found || len(afId) == 0 || issuer != afId {
+			return "", fmt.Errorf("the gpsi %s was not issued to af %s", gpsi, afId)
+		}
+		query.Set("externalId", externalId)
+	default:
+		return "", fmt.Errorf("the gpsi %s is neither an msisdn- nor an extid- one", gpsi)
+	}
+
+	body, err := c.get(c.IdentitySvc + "/resolve?" + query.Encode())
+	if err != nil {
+		return "", fmt.Errorf("could not resolve gpsi %s: %s", gpsi, err)
+	}
+
+	var val map[string]interface{}
+	if err := json.Unmarshal(body, &val); err != nil {
+		return "", fmt.Errorf("error parsing response body")
+	}
+
+	supi, ok := val["Supi"].(string)
+	if !ok || len(supi) == 0 {
+		return "", fmt.Errorf("the gpsi %s was not found", gpsi)
+	}
+	return supi, nil
+}
+
+// ------------------------------------------------------------------------------
+// GetUeProfile retrieves the live UE profile (PDU sessions, location) of a
+// SUPI into the profile model of the service
+func (c *Client) GetUeProfile(supi string, ueProfile interface{}) error {
+
+	body, err := c.get(c.ProfileSvc + fmt.Sprintf("/ue-profile/v1/profiles/%s", supi))
+	if err != nil {
+		return fmt.Errorf("could not retrieve ue %s profile: %s", supi, err)
+	}
+
+	if err := json.Unmarshal(body, ueProfile); err != nil {
+		return fmt.Errorf("error parsing response body")
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+func (c *Client) get(url string) ([]byte, error) {
+
+	// Create a new GET request
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Send the GET request
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			log.Printf("could not close response body correctly")
+		}
+	}(resp.Body)
+
+	// Check the HTTP status code
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("not found")
+		}
+		return nil, fmt.Errorf("received status code %d", resp.StatusCode)
+	}
+
+	// Read the response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body")
+	}
+	return body, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package identity
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLookupGpsi(t *testing.T) {
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("afId") != "af1" || (query.Get("msisdn") != "0123456789" && query.Get("externalId") != "af1:c0ffee") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Supi": "imsi-001010000000001"}`))
+	}))
+	defer identity.Close()
+
+	c := NewClient(identity.URL, "")
+
+	for _, gpsi := range []string{"msisdn-0123456789", "extid-af1:c0ffee"} {
+		supi, err := c.LookupGpsi("af1", gpsi)
+		if err != nil {
+			t.Fatalf("expected no errors for %s, got %s", gpsi, err.Error())
+		}
+		if supi != "imsi-001010000000001" {
+			t.Errorf("got supi %s for %s, wanted imsi-001010000000001", supi, gpsi)
+		}
+	}
+
+	/*unknown, unprefixed, raw SUPI and other AF identifiers*/
+	for _, gpsi := range []string{"msisdn-9876543210", "0123456789", "imsi-001010000000001", "extid-001010000000001", "extid-af2:c0ffee"} {
+		if _, err := c.LookupGpsi("af1", gpsi); err == nil {
+			t.Errorf("expected an error for gpsi %s", gpsi)
+		}
+	}
+}
+
+func TestGetUeProfile(t *testing.T) {
+	profile := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ue-profile/v1/profiles/imsi-001010000000001" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Imsi": "001010000000001", "Tac": "0001"}`))
+	}))
+	defer profile.Close()
+
+	c := NewClient("", profile.URL)
+
+	ueProfile := struct {
+		Imsi string
+		Tac  string
+	}{}
+	if err := c.GetUeProfile("imsi-001010000000001", &ueProfile); err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if ueProfile.Imsi != "001010000000001" || ueProfile.Tac != "0001" {
+		t.Errorf("got profile %v, wanted the one of imsi-001010000000001", ueProfile)
+	}
+
+	if err := c.GetUeProfile("imsi-001010000000002", &ueProfile); err == nil {
+		t.Errorf("expected an error for an unknown supi")
+	}
+}
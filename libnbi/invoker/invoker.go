@@ -0,0 +1,58 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+// Package invoker carries the CAPIF API invoker of a request, as validated from
+// its token by the CAPIF middleware of libcapif.
+package invoker
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header is where the CAPIF middleware sets the invoker of the validated token
+const Header = "InvokerId"
+
+type contextKey struct{}
+
+// Strip drops the invoker header sent by the client, to be installed before
+// the CAPIF middleware so that only the header it sets is trusted
+func Strip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(Header)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Bind moves the invoker set by the CAPIF middleware into the request context,
+// to be installed after it
+func Bind(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if invokerId := r.Header.Get(Header); len(invokerId) > 0 {
+			r = r.WithContext(context.WithValue(r.Context(), contextKey{}, invokerId))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Of returns the authenticated invoker of the request, empty if none
+func Of(r *http.Request) string {
+	invokerId, _ := r.Context().Value(contextKey{}).(string)
+	return invokerId
+}
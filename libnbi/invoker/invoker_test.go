@@ -0,0 +1,61 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package invoker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInvoker(t *testing.T) {
+	var got string
+	/*stands in for the CAPIF middleware, setting the invoker of a validated token*/
+	capif := func(invokerId string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if len(invokerId) > 0 {
+					r.Header.Set(Header, invokerId)
+				}
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	record := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = Of(r)
+	})
+
+	for _, tc := range []struct {
+		name      string
+		validated string
+		want      string
+	}{
+		{name: "validated token", validated: "invoker1", want: "invoker1"},
+		{name: "no CAPIF", validated: "", want: ""},
+	} {
+		got = ""
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set(Header, "spoofed")
+		Strip(capif(tc.validated)(Bind(record))).ServeHTTP(httptest.NewRecorder(), r)
+		if got != tc.want {
+			t.Errorf("%s: got invoker %q, wanted %q", tc.name, got, tc.want)
+		}
+	}
+}
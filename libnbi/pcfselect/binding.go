@@ -0,0 +1,198 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package pcfselect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// BindingStore remembers the api root of the PCF owning each app session,
+// so that updates and deletions reach the PCF that created it
+type BindingStore interface {
+	Get(appSessId string) (string, error)
+	Set(appSessId, apiRoot string) error
+	Delete(appSessId string) error
+}
+
+// MemoryBindingStore keeps the bindings for the lifetime of the process
+type MemoryBindingStore struct {
+	mu       sync.Mutex
+	bindings map[string]string
+}
+
+func NewMemoryBindingStore() *MemoryBindingStore {
+	return &MemoryBindingStore{bindings: make(map[string]string)}
+}
+
+func (m *MemoryBindingStore) Get(appSessId string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bindings[appSessId], nil
+}
+
+func (m *MemoryBindingStore) Set(appSessId, apiRoot string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.bindings[appSessId] = apiRoot
+	return nil
+}
+
+func (m *MemoryBindingStore) Delete(appSessId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.bindings, appSessId)
+	return nil
+}
+
+// RedisBindingStore keeps the bindings in the <prefix>:pcf-binding hash,
+// beside the persisted subscriptions
+type RedisBindingStore struct {
+	redisClient *redis.Client
+	ctx         context.Context
+	key         string
+}
+
+func NewRedisBindingStore(addr, prefix string) *RedisBindingStore {
+	return &RedisBindingStore{
+		ctx: context.Background(),
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+		key: fmt.Sprintf("%s:pcf-binding", prefix),
+	}
+}
+
+func (r *RedisBindingStore) Get(appSessId string) (string, error) {
+	apiRoot, err := r.redisClient.HGet(r.ctx, r.key, appSessId).Result()
+	if err == redis.Nil {
+		return "", nil
+	}
+	return apiRoot, err
+}
+
+func (r *RedisBindingStore) Set(appSessId, apiRoot string) error {
+	return r.redisClient.HSet(r.ctx, r.key, appSessId, apiRoot).Err()
+}
+
+func (r *RedisBindingStore) Delete(appSessId string) error {
+	return r.redisClient.HDel(r.ctx, r.key, appSessId).Err()
+}
+
+// ------------------------------------------------------------------------------
+// EndpointOf returns the api root of the PCF owning the app session, a new
+// discovery is done for sessions created before the binding was recorded
+func (s *Selector) EndpointOf(appSessId string) (string, error) {
+	apiRoot, err := s.bindings.Get(appSessId)
+	if err != nil {
+		log.Printf("could not read the PCF binding of %s: %v", appSessId, err)
+	}
+	if apiRoot != "" {
+		return apiRoot, nil
+	}
+	return s.Discover()
+}
+
+// ------------------------------------------------------------------------------
+// Bind records the PCF owning the app session
+func (s *Selector) Bind(appSessId, apiRoot string) {
+	if err := s.bindings.Set(appSessId, apiRoot); err != nil {
+		log.Printf("could not store the PCF binding of %s: %v", appSessId, err)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// Unbind forgets the PCF of the removed app session
+func (s *Selector) Unbind(appSessId string) {
+	if err := s.bindings.Delete(appSessId); err != nil {
+		log.Printf("could not remove the PCF binding of %s: %v", appSessId, err)
+	}
+}
+
+// pcfBinding is the subset of the Nbsf_Management PcfBinding used to reach the PCF
+type pcfBinding struct {
+	PcfFqdn        string `json:"pcfFqdn,omitempty"`
+	PcfIpEndPoints []struct {
+		Ipv4Address string `json:"ipv4Address,omitempty"`
+		Port        int32  `json:"port,omitempty"`
+	} `json:"pcfIpEndPoints,omitempty"`
+}
+
+// ------------------------------------------------------------------------------
+// bsfDiscoverPCFEndpoint looks up the PCF bound to the UE address in the BSF,
+// an empty api root is returned when no binding exists
+func (s *Selector) bsfDiscoverPCFEndpoint(sel Selection) (string, error) {
+	query := url.Values{}
+	if sel.UeIpv4 != "" {
+		query.Set("ipv4Addr", sel.UeIpv4)
+	} else {
+		query.Set("ipv6Prefix", sel.UeIpv6Prefix)
+	}
+	if sel.Dnn != "" {
+		query.Set("dnn", sel.Dnn)
+	}
+	if sel.Snssai != nil {
+		snssai, _ := json.Marshal(sel.Snssai)
+		query.Set("snssai", string(snssai))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(s.cfg.BsfSvc + "/nbsf-management/v1/pcfBindings?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNoContent, http.StatusNotFound:
+		return "", nil
+	default:
+		return "", fmt.Errorf("BSF returned status %d", resp.StatusCode)
+	}
+
+	var binding pcfBinding
+	if err := json.NewDecoder(resp.Body).Decode(&binding); err != nil {
+		return "", fmt.Errorf("invalid PCF binding: %w", err)
+	}
+	return binding.apiRoot(), nil
+}
+
+func (b pcfBinding) apiRoot() string {
+	host := b.PcfFqdn
+	if len(b.PcfIpEndPoints) > 0 && b.PcfIpEndPoints[0].Ipv4Address != "" {
+		host = b.PcfIpEndPoints[0].Ipv4Address
+		if b.PcfIpEndPoints[0].Port != 0 {
+			host = host + ":" + strconv.Itoa(int(b.PcfIpEndPoints[0].Port))
+		}
+	}
+	if host == "" {
+		return ""
+	}
+	return "http://" + host + "/npcf-policyauthorization/v1"
+}
@@ -0,0 +1,315 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+// Package pcfselect selects the PCF serving an application session, from the
+// BSF binding of the UE or the PCF profiles discovered in the NRF, and remembers
+// the PCF owning each app session.
+package pcfselect
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	nrf_client "gitlab.eurecom.fr/open-exposure/nef/nrfclient"
+)
+
+const defaultNrfCacheTtl = 60 * time.Second
+
+// Config holds where the PCFs are found: the PcfSvc api root when the NRF is
+// not used, the BSF bindings and NRF profiles otherwise.
+type Config struct {
+	UseNrf      bool
+	NrfSvc      string
+	PcfSvc      string
+	BsfSvc      string
+	Httpversion int
+	CacheTtl    time.Duration /*defaults to 60 seconds*/
+}
+
+// Selection holds the session parameters used to choose the PCF serving a
+// new application session, empty values are not taken into account.
+type Selection struct {
+	Dnn          string
+	Snssai       *nrf_client.Snssai
+	UeIpv4       string
+	UeIpv6Prefix string
+	Supi         string
+}
+
+// key identifies the NRF discovery of the selection, which only depends on the
+// DNN and S-NSSAI so that the cache does not grow with the UEs
+func (s Selection) key() string {
+	snssai := ""
+	if s.Snssai != nil {
+		snssai = strconv.Itoa(int(s.Snssai.Sst)) + "-" + s.Snssai.GetSd()
+	}
+	return s.Dnn + "|" + snssai
+}
+
+// maxPcfCacheEntries bounds the cached discoveries, the entries closest to
+// expiry being evicted first
+const maxPcfCacheEntries = 256
+
+// ------------------------------------------------------------------------------
+// pcfProfileCache keeps the PCF profiles returned by the NRF per DNN and
+// S-NSSAI, entries are refreshed once expired and served stale for up to
+// another ttl if the NRF is unreachable, then evicted.
+type pcfProfileCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]pcfCacheEntry
+}
+
+type pcfCacheEntry struct {
+	profiles []nrf_client.NFProfile
+	expiry   time.Time
+}
+
+func newPcfProfileCache(ttl time.Duration) *pcfProfileCache {
+	if ttl <= 0 {
+		ttl = defaultNrfCacheTtl
+	}
+	return &pcfProfileCache{ttl: ttl, entries: make(map[string]pcfCacheEntry)}
+}
+
+func (p *pcfProfileCache) get(key string, discover func() ([]nrf_client.NFProfile, time.Duration, error)) ([]nrf_client.NFProfile, error) {
+	p.mu.Lock()
+	entry, found := p.entries[key]
+	p.mu.Unlock()
+	now := time.Now()
+	if found && now.Before(entry.expiry) {
+		return entry.profiles, nil
+	}
+
+	profiles, validity, err := discover()
+	if err != nil {
+		if found && len(entry.profiles) > 0 && now.Before(entry.expiry.Add(p.ttl)) {
+			log.Printf("NRF discovery failed, using expired PCF profiles: %v", err)
+			return entry.profiles, nil
+		}
+		return nil, err
+	}
+
+	ttl := p.ttl
+	if validity > 0 && validity < ttl {
+		ttl = validity
+	}
+	p.mu.Lock()
+	p.evict(now, key)
+	p.entries[key] = pcfCacheEntry{profiles: profiles, expiry: now.Add(ttl)}
+	p.mu.Unlock()
+	return profiles, nil
+}
+
+// evict drops the entries no longer served stale and, above maxPcfCacheEntries,
+// the entries closest to expiry to make room for key. Called with p.mu held.
+func (p *pcfProfileCache) evict(now time.Time, key string) {
+	for k, entry := range p.entries {
+		if !now.Before(entry.expiry.Add(p.ttl)) {
+			delete(p.entries, k)
+		}
+	}
+	delete(p.entries, key)
+	for len(p.entries) >= maxPcfCacheEntries {
+		oldest := ""
+		for k, entry := range p.entries {
+			if oldest == "" || entry.expiry.Before(p.entries[oldest].expiry) {
+				oldest = k
+			}
+		}
+		delete(p.entries, oldest)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// Selector chooses the PCF of the new app sessions and keeps the PCF owning
+// each of them
+type Selector struct {
+	cfg      Config
+	cache    *pcfProfileCache
+	bindings BindingStore
+}
+
+func NewSelector(cfg Config, bindings BindingStore) *Selector {
+	return &Selector{cfg: cfg, cache: newPcfProfileCache(cfg.CacheTtl), bindings: bindings}
+}
+
+// ------------------------------------------------------------------------------
+func (s *Selector) Discover() (string, error) {
+	return s.Select(Selection{})
+}
+
+// ------------------------------------------------------------------------------
+// Select returns the npcf-policyauthorization api root of the PCF serving the
+// given session: the BSF binding if any, the NRF profiles otherwise.
+func (s *Selector) Select(sel Selection) (string, error) {
+	if !s.cfg.UseNrf {
+		if s.cfg.PcfSvc == "" {
+			return "", fmt.Errorf("NRF is disabled and PCF svc is not set")
+		}
+		return s.cfg.PcfSvc + "/npcf-policyauthorization/v1", nil
+	}
+
+	if s.cfg.BsfSvc != "" && (sel.UeIpv4 != "" || sel.UeIpv6Prefix != "") {
+		server_addr, err := s.bsfDiscoverPCFEndpoint(sel)
+		if err != nil {
+			log.Printf("BSF binding lookup failed, falling back to NRF: %v", err)
+		} else if server_addr != "" {
+			return server_addr, nil
+		}
+	}
+
+	pcfs, err := s.cache.get(sel.key(), func() ([]nrf_client.NFProfile, time.Duration, error) {
+		return s.nrfDiscoverPCFInstances(sel)
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, pcf := range selectPCFProfiles(pcfs, sel) {
+		if server_addr := pcfProfileGetPolicyAuthorizationInfo(pcf); server_addr != "" {
+			return server_addr, nil
+		}
+	}
+	return "", fmt.Errorf("no PCF supporting %s found", nrf_client.NPCF_POLICYAUTHORIZATION)
+}
+
+// ------------------------------------------------------------------------------
+// selectPCFProfiles keeps the profiles matching the DNN and S-NSSAI of the
+// session, ordered by priority (lowest value first)
+func selectPCFProfiles(pcfs []nrf_client.NFProfile, sel Selection) []nrf_client.NFProfile {
+	selected := []nrf_client.NFProfile{}
+	for _, pcf := range pcfs {
+		if status := pcf.NfStatus.NFStatusAnyOf; status != nil && *status != nrf_client.REGISTERED {
+			continue
+		}
+		if sel.Dnn != "" && pcf.PcfInfo != nil && len(pcf.PcfInfo.DnnList) > 0 &&
+			!slices.Contains(pcf.PcfInfo.DnnList, sel.Dnn) {
+			continue
+		}
+		if sel.Snssai != nil && len(pcf.SNssais) > 0 &&
+			!slices.ContainsFunc(pcf.SNssais, func(s nrf_client.Snssai) bool {
+				return s.Sst == sel.Snssai.Sst && s.GetSd() == sel.Snssai.GetSd()
+			}) {
+			continue
+		}
+		selected = append(selected, pcf)
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].GetPriority() < selected[j].GetPriority()
+	})
+	return selected
+}
+
+// ------------------------------------------------------------------------------
+func pcfProfileGetPolicyAuthorizationInfo(pcf nrf_client.NFProfile) string {
+
+	for _, service := range pcf.GetNfServices() {
+
+		if service.GetServiceName().ServiceNameAnyOf.Ptr() != nil &&
+			*service.GetServiceName().ServiceNameAnyOf.Ptr() == nrf_client.NPCF_POLICYAUTHORIZATION {
+
+			if len(service.GetVersions()) == 0 {
+				continue
+			}
+			host := service.GetFqdn()
+			if host == "" {
+				host = pcf.GetFqdn()
+			}
+			port := ""
+			if len(service.GetIpEndPoints()) > 0 {
+				if ip := service.GetIpEndPoints()[0].GetIpv4Address(); ip != "" {
+					host = ip
+				}
+				if service.GetIpEndPoints()[0].HasPort() {
+					port = strconv.FormatInt(int64(service.GetIpEndPoints()[0].GetPort()), 10)
+				}
+			}
+			if host == "" && len(pcf.GetIpv4Addresses()) > 0 {
+				host = pcf.GetIpv4Addresses()[0]
+			}
+			if host == "" {
+				continue
+			}
+			if port != "" {
+				host = host + ":" + port
+			}
+			ver := service.GetVersions()[0].GetApiVersionInUri()
+
+			url := url.URL{
+				Scheme: "http",
+				Host:   host,
+				Path:   string(nrf_client.NPCF_POLICYAUTHORIZATION) + "/" + ver,
+			}
+
+			log.Printf("Found PCF(%s) supporting %s\n host: %s\n apiVersion: %s\n", pcf.GetNfInstanceId(), string(nrf_client.NPCF_POLICYAUTHORIZATION), host, ver)
+			return url.String()
+		}
+	}
+	return ""
+}
+
+// ------------------------------------------------------------------------------
+// nrfDiscoverPCFInstances searches the PCFs serving the DNN and S-NSSAI of the
+// selection. The query is built by hand as the generated client cannot
+// serialize the snssais, a JSON array in TS 29.510.
+func (s *Selector) nrfDiscoverPCFInstances(sel Selection) ([]nrf_client.NFProfile, time.Duration, error) {
+	query := url.Values{
+		"target-nf-type":    {string(nrf_client.NFType_PCF)},
+		"requester-nf-type": {string(nrf_client.NFType_NEF)},
+	}
+	if sel.Dnn != "" {
+		query.Set("dnn", sel.Dnn)
+	}
+	if sel.Snssai != nil {
+		snssais, _ := json.Marshal([]nrf_client.Snssai{*sel.Snssai})
+		query.Set("snssais", string(snssais))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if configuration := nrf_client.NewConfiguration(s.cfg.NrfSvc, s.cfg.Httpversion); configuration.HTTPClient != nil {
+		/*keeps the HTTP/2 transport of the configured version*/
+		client.Transport = configuration.HTTPClient.Transport
+	}
+	resp, err := client.Get(s.cfg.NrfSvc + "/nnrf-disc/v1/nf-instances?" + query.Encode())
+	if err != nil {
+		log.Printf("Error when searching the PCF instances in the NRF: %v\n", err)
+		return nil, 0, fmt.Errorf("PCF discovery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("PCF discovery failed: NRF returned status %d", resp.StatusCode)
+	}
+
+	var result nrf_client.SearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, fmt.Errorf("PCF discovery failed: invalid search result: %w", err)
+	}
+	if len(result.NfInstances) == 0 {
+		return nil, 0, fmt.Errorf("no PCF instance registered in the NRF")
+	}
+	return result.NfInstances, time.Duration(result.GetValidityPeriod()) * time.Second, nil
+}
@@ -0,0 +1,189 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package pcfselect
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	nrf_client "gitlab.eurecom.fr/open-exposure/nef/nrfclient"
+)
+
+func TestPcfProfileCache(t *testing.T) {
+	cache := newPcfProfileCache(time.Minute)
+	calls := 0
+	discover := func() ([]nrf_client.NFProfile, time.Duration, error) {
+		calls++
+		return []nrf_client.NFProfile{{NfInstanceId: "pcf1"}}, 0, nil
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.get("k", discover); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected one NRF discovery, got %d", calls)
+	}
+
+	// expired entries are refreshed, and served stale if the NRF fails
+	cache.entries["k"] = pcfCacheEntry{profiles: cache.entries["k"].profiles, expiry: time.Now().Add(-time.Second)}
+	profiles, err := cache.get("k", func() ([]nrf_client.NFProfile, time.Duration, error) {
+		calls++
+		return nil, 0, errors.New("nrf down")
+	})
+	if err != nil || len(profiles) != 1 || calls != 2 {
+		t.Errorf("expected the stale profile after a refresh attempt, got %v %v (%d calls)", profiles, err, calls)
+	}
+
+	if _, err := cache.get("other", func() ([]nrf_client.NFProfile, time.Duration, error) {
+		return nil, 0, errors.New("nrf down")
+	}); err == nil {
+		t.Errorf("expected an error without cached profiles")
+	}
+}
+
+func TestPcfProfileCacheEviction(t *testing.T) {
+	cache := newPcfProfileCache(time.Minute)
+	discover := func() ([]nrf_client.NFProfile, time.Duration, error) {
+		return []nrf_client.NFProfile{{NfInstanceId: "pcf1"}}, 0, nil
+	}
+	nrfDown := func() ([]nrf_client.NFProfile, time.Duration, error) {
+		return nil, 0, errors.New("nrf down")
+	}
+
+	// entries expired for longer than the ttl are neither served nor kept
+	_, _ = cache.get("old", discover)
+	cache.entries["old"] = pcfCacheEntry{profiles: cache.entries["old"].profiles, expiry: time.Now().Add(-2 * time.Minute)}
+	if _, err := cache.get("old", nrfDown); err == nil {
+		t.Errorf("expected an error instead of profiles expired for too long")
+	}
+	_, _ = cache.get("new", discover)
+	if _, found := cache.entries["old"]; found {
+		t.Errorf("expected the expired entry to be evicted")
+	}
+
+	for i := 0; i < maxPcfCacheEntries+10; i++ {
+		_, _ = cache.get(strconv.Itoa(i), discover)
+	}
+	if len(cache.entries) > maxPcfCacheEntries {
+		t.Errorf("got %d entries, wanted at most %d", len(cache.entries), maxPcfCacheEntries)
+	}
+}
+
+func TestNrfDiscoverPCFInstances(t *testing.T) {
+	calls := 0
+	nrf := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		query := r.URL.Query()
+		if r.URL.Path != "/nnrf-disc/v1/nf-instances" || query.Get("target-nf-type") != "PCF" ||
+			query.Get("dnn") != "internet" || query.Get("snssais") != `[{"sd":"000001","sst":1}]` {
+			t.Errorf("unexpected NRF query %s", r.URL.String())
+		}
+		if query.Has("ue-ipv4-address") || query.Has("supi") {
+			t.Errorf("unexpected UE parameters in the NRF query %s", r.URL.String())
+		}
+		w.Write([]byte(`{"validityPeriod": 30, "nfInstances": [{"nfInstanceId": "pcf1", "nfType": "PCF", "nfStatus": "REGISTERED",
+			"nfServices": [{"serviceInstanceId": "1", "serviceName": "npcf-policyauthorization", "scheme": "http",
+			"nfServiceStatus": "REGISTERED", "versions": [{"apiVersionInUri": "v1", "apiFullVersion": "1.0.0"}],
+			"ipEndPoints": [{"ipv4Address": "192.168.70.3", "port": 8080}]}]}]}`))
+	}))
+	defer nrf.Close()
+
+	s := NewSelector(Config{UseNrf: true, NrfSvc: nrf.URL}, NewMemoryBindingStore())
+	sd := "000001"
+	for _, ueIpv4 := range []string{"10.0.0.1", "10.0.0.2"} {
+		url, err := s.Select(Selection{Dnn: "internet", Snssai: &nrf_client.Snssai{Sst: 1, Sd: &sd}, UeIpv4: ueIpv4})
+		if err != nil || url != "http://192.168.70.3:8080/npcf-policyauthorization/v1" {
+			t.Errorf("unexpected NRF selection %s %v", url, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected one NRF discovery for the UEs of a DNN and slice, got %d", calls)
+	}
+}
+
+func TestSelectPCFProfiles(t *testing.T) {
+	sd := "000001"
+	low, high := int32(1), int32(10)
+	pcfs := []nrf_client.NFProfile{
+		{NfInstanceId: "internet", Priority: &high, PcfInfo: &nrf_client.PcfInfo{DnnList: []string{"internet"}}},
+		{NfInstanceId: "ims", PcfInfo: &nrf_client.PcfInfo{DnnList: []string{"ims"}}},
+		{NfInstanceId: "slice", Priority: &low, SNssais: []nrf_client.Snssai{{Sst: 1, Sd: &sd}}},
+	}
+
+	selected := selectPCFProfiles(pcfs, Selection{Dnn: "internet", Snssai: &nrf_client.Snssai{Sst: 1, Sd: &sd}})
+	if len(selected) != 2 || selected[0].NfInstanceId != "slice" || selected[1].NfInstanceId != "internet" {
+		t.Errorf("unexpected selection %v", selected)
+	}
+
+	selected = selectPCFProfiles(pcfs, Selection{Dnn: "ims", Snssai: &nrf_client.Snssai{Sst: 2}})
+	if len(selected) != 1 || selected[0].NfInstanceId != "ims" {
+		t.Errorf("unexpected selection %v", selected)
+	}
+}
+
+func TestSelectWithoutNrf(t *testing.T) {
+	s := NewSelector(Config{}, NewMemoryBindingStore())
+	if _, err := s.Select(Selection{}); err == nil {
+		t.Errorf("expected an error when no PCF is configured")
+	}
+}
+
+func TestBsfBindingLookup(t *testing.T) {
+	bsf := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ipv4Addr") != "10.0.0.1" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"dnn":"internet","pcfIpEndPoints":[{"ipv4Address":"192.168.70.2","port":8080}]}`))
+	}))
+	defer bsf.Close()
+
+	s := NewSelector(Config{UseNrf: true, BsfSvc: bsf.URL}, NewMemoryBindingStore())
+	url, err := s.Select(Selection{UeIpv4: "10.0.0.1", Dnn: "internet"})
+	if err != nil || url != "http://192.168.70.2:8080/npcf-policyauthorization/v1" {
+		t.Errorf("unexpected BSF selection %s %v", url, err)
+	}
+
+	url, err = s.bsfDiscoverPCFEndpoint(Selection{UeIpv4: "10.0.0.2"})
+	if err != nil || url != "" {
+		t.Errorf("expected no binding, got %s %v", url, err)
+	}
+}
+
+func TestEndpointOfBoundSession(t *testing.T) {
+	s := NewSelector(Config{}, NewMemoryBindingStore())
+	s.Bind("sess1", "http://pcf2/npcf-policyauthorization/v1")
+
+	url, err := s.EndpointOf("sess1")
+	if err != nil || url != "http://pcf2/npcf-policyauthorization/v1" {
+		t.Errorf("expected the bound PCF, got %s %v", url, err)
+	}
+
+	s.Unbind("sess1")
+	if _, err := s.EndpointOf("sess1"); err == nil {
+		t.Errorf("expected a discovery error once unbound")
+	}
+}
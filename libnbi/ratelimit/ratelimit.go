@@ -0,0 +1,170 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/invoker"
+)
+
+// maxIdleBuckets is the number of buckets above which the full ones are dropped
+const maxIdleBuckets = 1024
+
+// Limit is the token bucket of a client, Rate requests per second with bursts
+// of up to Burst requests. A zero rate leaves the client unlimited.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps a token bucket per client
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	overrides map[string]Limit
+	buckets   map[string]*bucket
+	now       func() time.Time
+}
+
+// CheckOverrides checks that the requests of the AFs with their own limit can
+// be told apart. They are only keyed on the afId once CAPIF authenticates the
+// invoker and the AF policy binds the AF to it, otherwise the AF is limited as
+// any other client and its override would never apply.
+func CheckOverrides(overrides map[string]Limit, capif bool, policies *afpolicy.Registry) error {
+	afIds := make([]string, 0, len(overrides))
+	for afId := range overrides {
+		afIds = append(afIds, afId)
+	}
+	sort.Strings(afIds)
+	for _, afId := range afIds {
+		if !capif {
+			return fmt.Errorf("limits of af %s: a per-AF rate needs the CAPIF service to authenticate the AF", afId)
+		}
+		if policy := policies.Policy(afId); policy == nil || len(policy.InvokerIds) == 0 {
+			return fmt.Errorf("limits of af %s: a per-AF rate needs an AF policy binding the AF to its invokerIds", afId)
+		}
+	}
+	return nil
+}
+
+func NewLimiter(limit Limit, overrides map[string]Limit) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		overrides: overrides,
+		buckets:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// Allow takes a token from the bucket of the client and, when the bucket is
+// empty, tells how long to wait for the next token
+func (l *Limiter) Allow(client string) (bool, time.Duration) {
+	limit := l.limitOf(client)
+	if limit.Rate <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[client]
+	if !ok {
+		l.prune(now)
+		b = &bucket{tokens: limit.burst(), last: now}
+		l.buckets[client] = b
+	}
+	b.refill(limit, now)
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+func (l *Limiter) limitOf(client string) Limit {
+	if limit, ok := l.overrides[client]; ok {
+		return limit
+	}
+	return l.limit
+}
+
+// prune drops the buckets refilled since the last request of their client,
+// which are the same as new ones, once there are too many of them
+func (l *Limiter) prune(now time.Time) {
+	if len(l.buckets) < maxIdleBuckets {
+		return
+	}
+	for client, b := range l.buckets {
+		limit := l.limitOf(client)
+		if limit.Rate <= 0 || b.tokens+now.Sub(b.last).Seconds()*limit.Rate >= limit.burst() {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// burst defaults to one second worth of requests
+func (limit Limit) burst() float64 {
+	if limit.Burst > 0 {
+		return float64(limit.Burst)
+	}
+	return math.Max(1, math.Ceil(limit.Rate))
+}
+
+func (b *bucket) refill(limit Limit, now time.Time) {
+	b.tokens = math.Min(limit.burst(), b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+}
+
+// RetryAfter formats the wait for the Retry-After header, in whole seconds
+func RetryAfter(wait time.Duration) int {
+	return max(1, int(math.Ceil(wait.Seconds())))
+}
+
+// ClientOf identifies the client of a request by the AF owning the resource,
+// once the AF policy bound it to the authenticated invoker, else by the CAPIF
+// invoker of the request, else by its remote address. The afId of the request
+// path alone is never trusted.
+func ClientOf(r *http.Request, boundAfId string) string {
+	if len(boundAfId) > 0 {
+		return boundAfId
+	}
+	if invokerId := invoker.Of(r); len(invokerId) > 0 {
+		return invokerId
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
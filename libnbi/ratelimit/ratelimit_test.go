@@ -0,0 +1,160 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/invoker"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := NewLimiter(Limit{Rate: 2, Burst: 3}, map[string]Limit{"af2": {Rate: 0}})
+	l.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Allow("af1"); !ok {
+			t.Fatalf("request %d: expected the burst to be allowed", i)
+		}
+	}
+	ok, wait := l.Allow("af1")
+	if ok {
+		t.Fatalf("expected the request above the burst to be rejected")
+	}
+	if wait != 500*time.Millisecond {
+		t.Errorf("got wait %s, wanted 500ms", wait)
+	}
+	if RetryAfter(wait) != 1 {
+		t.Errorf("got Retry-After %d, wanted 1", RetryAfter(wait))
+	}
+
+	/*the bucket refills at the rate*/
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.Allow("af1"); !ok {
+		t.Errorf("expected a request to be allowed after refill")
+	}
+	if ok, _ := l.Allow("af1"); ok {
+		t.Errorf("expected a single token after 500ms")
+	}
+
+	/*other AFs have their own bucket, or none when unlimited*/
+	if ok, _ := l.Allow("af3"); !ok {
+		t.Errorf("expected af3 to have its own bucket")
+	}
+	for i := 0; i < 100; i++ {
+		if ok, _ := l.Allow("af2"); !ok {
+			t.Fatalf("expected af2 to be unlimited")
+		}
+	}
+}
+
+func TestLimiterDefaultBurst(t *testing.T) {
+	l := NewLimiter(Limit{Rate: 0.5}, nil)
+	l.now = func() time.Time { return time.Unix(0, 0) }
+
+	if ok, _ := l.Allow("af1"); !ok {
+		t.Fatalf("expected the first request to be allowed")
+	}
+	ok, wait := l.Allow("af1")
+	if ok || wait != 2*time.Second {
+		t.Errorf("got %t, %s, wanted the second request to wait 2s", ok, wait)
+	}
+}
+
+// clientOf returns the client of a request passed through the invoker
+// middlewares, the CAPIF middleware having validated validatedInvoker if set
+func clientOf(r *http.Request, validatedInvoker string, boundAfId string) string {
+	var client string
+	capif := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(validatedInvoker) > 0 {
+			r.Header.Set(invoker.Header, validatedInvoker)
+		}
+		invoker.Bind(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client = ClientOf(r, boundAfId)
+		})).ServeHTTP(w, r)
+	})
+	invoker.Strip(capif).ServeHTTP(httptest.NewRecorder(), r)
+	return client
+}
+
+func TestClientOf(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:4321"
+
+	if got := clientOf(r, "invoker1", "af1"); got != "af1" {
+		t.Errorf("got client %s, wanted the bound af1", got)
+	}
+	if got := clientOf(r, "", ""); got != "10.0.0.1" {
+		t.Errorf("got client %s, wanted 10.0.0.1", got)
+	}
+	if got := clientOf(r, "invoker1", ""); got != "invoker1" {
+		t.Errorf("got client %s, wanted invoker1", got)
+	}
+	/*an invoker header sent by the client is not trusted*/
+	r.Header.Set(invoker.Header, "invoker2")
+	if got := clientOf(r, "", ""); got != "10.0.0.1" {
+		t.Errorf("got client %s for a spoofed invoker, wanted 10.0.0.1", got)
+	}
+}
+
+func TestCheckOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	content := "afs:\n  af1:\n    invokerIds: [invoker1]\n  af2:\n    dnns: [internet]\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	policies, err := afpolicy.NewRegistry(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bound := map[string]Limit{"af1": {Rate: 5}}
+	if err := CheckOverrides(bound, true, policies); err != nil {
+		t.Errorf("expected the override of the bound af1 to be accepted, got %s", err)
+	}
+	if err := CheckOverrides(nil, false, nil); err != nil {
+		t.Errorf("expected no overrides to be accepted, got %s", err)
+	}
+
+	/*the override of an AF its requests cannot be keyed on would never apply*/
+	unbound := []struct {
+		name      string
+		overrides map[string]Limit
+		capif     bool
+		policies  *afpolicy.Registry
+	}{
+		{"no CAPIF", bound, false, policies},
+		{"no invokerIds", map[string]Limit{"af2": {Rate: 5}}, true, policies},
+		{"no policy", map[string]Limit{"af3": {Burst: 2}}, true, policies},
+		{"no policy file", bound, true, nil},
+	}
+	for _, c := range unbound {
+		if err := CheckOverrides(c.overrides, c.capif, c.policies); err == nil {
+			t.Errorf("%s: expected the unbound override to be rejected", c.name)
+		}
+	}
+}
@@ -39,7 +39,7 @@ func main() {
 
 	app, err := traffInflApp.InitApplication("monitoring-event", getConfigPath())
 	if err != nil {
-		log.Printf("cannot initialize application")
+		log.Printf("cannot initialize application: %s", err)
 		return
 	}
 
@@ -15,6 +15,9 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/redis/go-redis/v9 v9.11.0
 	gitlab.eurecom.fr/open-exposure/nef/libcapif v1.2.0
+	gitlab.eurecom.fr/open-exposure/nef/libnbi v1.0.0
 	golang.org/x/exp v0.0.0-20241108190413-2d47ceb2692f
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+replace gitlab.eurecom.fr/open-exposure/nef/libnbi => ../libnbi
@@ -0,0 +1,238 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package area
+
+import (
+	"math"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// earthRadius is the mean radius of the earth, in meters
+const earthRadius = 6371000.0
+
+// Area is the location area of a subscription, made of tracking areas, cells
+// and geographic areas. A UE is in the area when it is in any of them.
+type Area struct {
+	tais   map[string]bool
+	cells  map[string]bool
+	shapes []models.GeographicArea
+}
+
+// New builds the area of the locationArea and locationArea5G of a
+// subscription. The tracking areas and cells are given either with their PLMN,
+// as <mcc><mnc><tac> and <mcc><mnc><cellId>, or without it. Civic addresses
+// are not supported.
+func New(locationArea *models.LocationArea, locationArea5G *models.LocationArea5G) *Area {
+	area := &Area{tais: make(map[string]bool), cells: make(map[string]bool)}
+
+	if locationArea != nil {
+		for _, tai := range locationArea.TrackingAreaIds {
+			area.tais[strings.ToLower(tai)] = true
+		}
+		for _, cellId := range locationArea.CellIds {
+			area.cells[strings.ToLower(cellId)] = true
+		}
+		area.shapes = append(area.shapes, locationArea.GeographicAreas...)
+	}
+	if locationArea5G != nil {
+		for _, tai := range locationArea5G.NwAreaInfo.Tais {
+			area.tais[taiKey(tai)] = true
+		}
+		for _, ncgi := range locationArea5G.NwAreaInfo.Ncgis {
+			area.cells[plmnKey(ncgi.PlmnId, ncgi.NrCellId)] = true
+		}
+		for _, ecgi := range locationArea5G.NwAreaInfo.Ecgis {
+			area.cells[plmnKey(ecgi.PlmnId, ecgi.EutraCellId)] = true
+		}
+		area.shapes = append(area.shapes, locationArea5G.GeographicAreas...)
+	}
+	return area
+}
+
+// IsEmpty tells whether the area has nothing the UE locations can be evaluated against
+func (area *Area) IsEmpty() bool {
+	return len(area.tais) == 0 && len(area.cells) == 0 && len(area.shapes) == 0
+}
+
+// Contains tells whether the UE is in the area, from its serving cell and its
+// geographic position, the latter being nil when unknown. known is false when
+// the UE was not found in the area but could not be evaluated against all of it.
+func (area *Area) Contains(userLocation *models.UserLocation, position *models.GeographicArea) (in bool, known bool) {
+	known = true
+
+	tais, cellIds := servingCell(userLocation)
+	if len(area.tais) > 0 || len(area.cells) > 0 {
+		if len(tais) == 0 {
+			known = false
+		}
+		for _, tai := range tais {
+			if area.tais[tai] {
+				return true, true
+			}
+		}
+		for _, cellId := range cellIds {
+			if area.cells[cellId] {
+				return true, true
+			}
+		}
+	}
+
+	if len(area.shapes) > 0 {
+		point, ok := Center(position)
+		if !ok {
+			return false, false
+		}
+		for i := range area.shapes {
+			if ShapeContains(&area.shapes[i], point) {
+				return true, true
+			}
+		}
+	}
+	return false, known
+}
+
+// servingCell returns the tracking area and the cell of the UE location, both
+// with and without PLMN
+func servingCell(userLocation *models.UserLocation) ([]string, []string) {
+	if userLocation == nil {
+		return nil, nil
+	}
+	if nrLoc := userLocation.NrLocation; nrLoc != nil {
+		return []string{taiKey(nrLoc.Tai), strings.ToLower(nrLoc.Tai.Tac)},
+			[]string{plmnKey(nrLoc.Ncgi.PlmnId, nrLoc.Ncgi.NrCellId), strings.ToLower(nrLoc.Ncgi.NrCellId)}
+	}
+	if eutraLoc := userLocation.EutraLocation; eutraLoc != nil {
+		return []string{taiKey(eutraLoc.Tai), strings.ToLower(eutraLoc.Tai.Tac)},
+			[]string{plmnKey(eutraLoc.Ecgi.PlmnId, eutraLoc.Ecgi.EutraCellId), strings.ToLower(eutraLoc.Ecgi.EutraCellId)}
+	}
+	return nil, nil
+}
+
+func taiKey(tai models.Tai) string {
+	return plmnKey(tai.PlmnId, tai.Tac)
+}
+
+func plmnKey(plmnId models.PlmnId1, id string) string {
+	return strings.ToLower(plmnId.Mcc + plmnId.Mnc + id)
+}
+
+// ------------------------------------------------------------------------------
+// Center returns the point a geographic area is centered on, ok is false when
+// there is none
+func Center(shape *models.GeographicArea) (point models.GeographicalCoordinates, ok bool) {
+	if shape == nil {
+		return point, false
+	}
+	if shape.Shape == models.SupportedGadShapesPOLYGON {
+		if len(shape.PointList) == 0 {
+			return point, false
+		}
+		for _, p := range shape.PointList {
+			point.Lat += p.Lat
+			point.Lon += p.Lon
+		}
+		point.Lat /= float64(len(shape.PointList))
+		point.Lon /= float64(len(shape.PointList))
+		return point, true
+	}
+	if len(shape.Shape) == 0 {
+		return point, false
+	}
+	if shape.Shape == models.SupportedGadShapesELLIPSOID_ARC {
+		/*the middle of the arc rather than its origin, the cell site*/
+		bearing := float64(shape.OffsetAngle) + float64(shape.IncludedAngle)/2
+		return Destination(shape.Point, bearing, float64(shape.InnerRadius)+float64(shape.UncertaintyRadius)/2), true
+	}
+	return shape.Point, true
+}
+
+// ShapeContains tells whether the point lies within the shape, the shapes
+// without a surface containing no point
+func ShapeContains(shape *models.GeographicArea, point models.GeographicalCoordinates) bool {
+	switch shape.Shape {
+	case models.SupportedGadShapesPOLYGON:
+		return polygonContains(shape.PointList, point)
+	case models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE:
+		return Distance(shape.Point, point) <= float64(shape.Uncertainty)
+	case models.SupportedGadShapesPOINT_UNCERTAINTY_ELLIPSE:
+		/*the ellipse is approximated by the circle of its semi-major axis*/
+		return Distance(shape.Point, point) <= float64(shape.UncertaintyEllipse.SemiMajor)
+	case models.SupportedGadShapesELLIPSOID_ARC:
+		distance := Distance(shape.Point, point)
+		if distance < float64(shape.InnerRadius) || distance > float64(shape.InnerRadius)+float64(shape.UncertaintyRadius) {
+			return false
+		}
+		/*angles are clockwise from north*/
+		angle := math.Mod(Bearing(shape.Point, point)-float64(shape.OffsetAngle)+360, 360)
+		return angle <= float64(shape.IncludedAngle)
+	}
+	return false
+}
+
+// polygonContains casts a ray from the point and counts the polygon edges it
+// crosses, the polygon being small enough for the coordinates to be planar
+func polygonContains(pointList []models.GeographicalCoordinates, point models.GeographicalCoordinates) bool {
+	in := false
+	for i, j := 0, len(pointList)-1; i < len(pointList); j, i = i, i+1 {
+		a, b := pointList[i], pointList[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Lon < (b.Lon-a.Lon)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Distance returns the great circle distance between two points, in meters
+func Distance(a models.GeographicalCoordinates, b models.GeographicalCoordinates) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLon := lat2-lat1, radians(b.Lon-a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Bearing returns the initial bearing from a to b, in degrees clockwise from north
+func Bearing(a models.GeographicalCoordinates, b models.GeographicalCoordinates) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Destination returns the point at the distance, in meters, from the origin
+// along the bearing, in degrees clockwise from north
+func Destination(origin models.GeographicalCoordinates, bearing float64, distance float64) models.GeographicalCoordinates {
+	lat1, lon1 := radians(origin.Lat), radians(origin.Lon)
+	theta, delta := radians(bearing), distance/earthRadius
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(lat1), math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2))
+	return models.GeographicalCoordinates{Lat: degrees(lat2), Lon: degrees(lon2)}
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
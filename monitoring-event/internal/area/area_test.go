@@ -0,0 +1,125 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package area
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func nrUserLocation(tac string, cellId string) *models.UserLocation {
+	plmnId := models.PlmnId1{Mcc: "001", Mnc: "01"}
+	return &models.UserLocation{NrLocation: &models.NrLocation{
+		Tai:  models.Tai{PlmnId: plmnId, Tac: tac},
+		Ncgi: models.Ncgi{PlmnId: plmnId, NrCellId: cellId},
+	}}
+}
+
+func pointArea(lat float64, lon float64) *models.GeographicArea {
+	return &models.GeographicArea{Shape: models.SupportedGadShapesPOINT, Point: models.GeographicalCoordinates{Lat: lat, Lon: lon}}
+}
+
+func TestContainsNetworkArea(t *testing.T) {
+	area := New(&models.LocationArea{TrackingAreaIds: []string{"001010001"}, CellIds: []string{"00000000A"}}, &models.LocationArea5G{
+		NwAreaInfo: models.NetworkAreaInfo{Ncgis: []models.Ncgi{{PlmnId: models.PlmnId1{Mcc: "001", Mnc: "01"}, NrCellId: "000000022"}}},
+	})
+
+	tests := []struct {
+		name         string
+		userLocation *models.UserLocation
+		wantedIn     bool
+		wantedKnown  bool
+	}{
+		{"tracking area with plmn", nrUserLocation("0001", "000000001"), true, true},
+		{"bare cell", nrUserLocation("0002", "00000000a"), true, true},
+		{"5G cell", nrUserLocation("0002", "000000022"), true, true},
+		{"outside", nrUserLocation("0002", "000000003"), false, true},
+		{"no location", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, known := area.Contains(tt.userLocation, nil)
+			if in != tt.wantedIn || known != tt.wantedKnown {
+				t.Errorf("got in=%v known=%v, wanted %v %v", in, known, tt.wantedIn, tt.wantedKnown)
+			}
+		})
+	}
+}
+
+func TestContainsGeographicArea(t *testing.T) {
+	square := models.GeographicArea{
+		Shape: models.SupportedGadShapesPOLYGON,
+		PointList: []models.GeographicalCoordinates{
+			{Lat: 43.61, Lon: 7.04}, {Lat: 43.61, Lon: 7.06}, {Lat: 43.63, Lon: 7.06}, {Lat: 43.63, Lon: 7.04},
+		},
+	}
+	circle := models.GeographicArea{
+		Shape:       models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE,
+		Point:       models.GeographicalCoordinates{Lat: 48.8566, Lon: 2.3522},
+		Uncertainty: 500,
+	}
+	area := New(&models.LocationArea{GeographicAreas: []models.GeographicArea{square, circle}}, nil)
+
+	tests := []struct {
+		name        string
+		position    *models.GeographicArea
+		wantedIn    bool
+		wantedKnown bool
+	}{
+		{"in polygon", pointArea(43.62, 7.05), true, true},
+		{"in circle", pointArea(48.8590, 2.3522), true, true},
+		{"outside", pointArea(48.8700, 2.3522), false, true},
+		{"unknown position", nil, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in, known := area.Contains(nrUserLocation("0001", "000000001"), tt.position)
+			if in != tt.wantedIn || known != tt.wantedKnown {
+				t.Errorf("got in=%v known=%v, wanted %v %v", in, known, tt.wantedIn, tt.wantedKnown)
+			}
+		})
+	}
+}
+
+func TestEllipsoidArc(t *testing.T) {
+	/* sector facing east, from 100 to 1100 meters */
+	arc := &models.GeographicArea{
+		Shape:             models.SupportedGadShapesELLIPSOID_ARC,
+		Point:             models.GeographicalCoordinates{Lat: 45.0, Lon: 5.0},
+		InnerRadius:       100,
+		UncertaintyRadius: 1000,
+		OffsetAngle:       45,
+		IncludedAngle:     90,
+	}
+	east := models.GeographicalCoordinates{Lat: 45.0, Lon: 5.005}
+	west := models.GeographicalCoordinates{Lat: 45.0, Lon: 4.995}
+	near := models.GeographicalCoordinates{Lat: 45.0, Lon: 5.0005}
+
+	if !ShapeContains(arc, east) {
+		t.Errorf("the point %.0fm east should be in the arc", Distance(arc.Point, east))
+	}
+	if ShapeContains(arc, west) {
+		t.Errorf("the point west should not be in the arc")
+	}
+	if ShapeContains(arc, near) {
+		t.Errorf("the point %.0fm away should be inside the inner radius", Distance(arc.Point, near))
+	}
+}
@@ -0,0 +1,349 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package area
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// defaultBeamwidth is the opening of a sector whose beamwidth is not given, in degrees
+const defaultBeamwidth = 120.0
+
+// maxPolygonPoints is the most points a GAD polygon may have
+const maxPolygonPoints = 15
+
+// Resolver gives the geographic area covered by the serving cell of a UE, as
+// one of the shapes supported by the AF, all shapes being supported when none
+// is given. ok is false when the cell is unknown, its location being then of
+// unknown accuracy.
+type Resolver interface {
+	Resolve(userLocation *models.UserLocation, shapes []models.SupportedGadShapes) (geographicArea *models.GeographicArea, ok bool)
+}
+
+// Cell is the site and the coverage of a cell
+type Cell struct {
+	Position  models.GeographicalCoordinates
+	Azimuth   *float64                         /*sector direction in degrees clockwise from north, nil when omnidirectional*/
+	Beamwidth float64                          /*sector opening in degrees*/
+	Radius    float64                          /*coverage radius in meters*/
+	Footprint []models.GeographicalCoordinates /*coverage polygon, when surveyed*/
+}
+
+// Catalogue resolves the cells it lists. The cells are identified by their
+// NCGI or ECGI, as <mcc><mnc><cellId>, or by their cell identity alone.
+type Catalogue struct {
+	cells map[string]*Cell
+}
+
+func NewCatalogue() *Catalogue {
+	return &Catalogue{cells: make(map[string]*Cell)}
+}
+
+// LoadCatalogue reads a cell catalogue, from a CSV file or from a GeoJSON
+// feature collection
+func LoadCatalogue(path string) (*Catalogue, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return readCsvCatalogue(file)
+	case ".geojson", ".json":
+		return readGeoJsonCatalogue(file)
+	}
+	return nil, fmt.Errorf("unsupported cell catalogue format %s", filepath.Ext(path))
+}
+
+// Add lists a cell under its identifier
+func (catalogue *Catalogue) Add(cellId string, cell Cell) error {
+	if len(cellId) == 0 {
+		return errors.New("missing cell id")
+	}
+	if cell.Radius <= 0 {
+		return fmt.Errorf("cell %s: radius must be positive", cellId)
+	}
+	if len(cell.Footprint) > maxPolygonPoints {
+		return fmt.Errorf("cell %s: footprint has more than %d points", cellId, maxPolygonPoints)
+	}
+	if cell.Beamwidth <= 0 || cell.Beamwidth > 360 {
+		cell.Beamwidth = defaultBeamwidth
+	}
+	catalogue.cells[strings.ToLower(cellId)] = &cell
+	return nil
+}
+
+// Len returns the number of cells listed
+func (catalogue *Catalogue) Len() int {
+	if catalogue == nil {
+		return 0
+	}
+	return len(catalogue.cells)
+}
+
+// Resolve gives the coverage of the serving cell, a nil catalogue knowing no cell
+func (catalogue *Catalogue) Resolve(userLocation *models.UserLocation, shapes []models.SupportedGadShapes) (*models.GeographicArea, bool) {
+	if catalogue == nil {
+		return nil, false
+	}
+	_, cellIds := servingCell(userLocation)
+	for _, cellId := range cellIds {
+		if cell, ok := catalogue.cells[cellId]; ok {
+			return cell.Shape(shapes)
+		}
+	}
+	return nil, false
+}
+
+// ------------------------------------------------------------------------------
+// Shape describes the coverage of the cell with the first supported shape:
+// its surveyed footprint, its sector as an arc or a polygon, or the circle
+// around its site
+func (cell *Cell) Shape(shapes []models.SupportedGadShapes) (*models.GeographicArea, bool) {
+	var candidates []models.SupportedGadShapes
+	if len(cell.Footprint) > 0 {
+		candidates = append(candidates, models.SupportedGadShapesPOLYGON)
+	}
+	if cell.Azimuth != nil && cell.Beamwidth < 360 {
+		candidates = append(candidates, models.SupportedGadShapesELLIPSOID_ARC, models.SupportedGadShapesPOLYGON)
+	}
+	candidates = append(candidates, models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE, models.SupportedGadShapesPOLYGON)
+
+	for _, shape := range candidates {
+		if !supports(shapes, shape) {
+			continue
+		}
+		switch shape {
+		case models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE:
+			return &models.GeographicArea{Shape: shape, Point: cell.Position, Uncertainty: float32(cell.Radius)}, true
+		case models.SupportedGadShapesELLIPSOID_ARC:
+			return &models.GeographicArea{
+				Shape:             shape,
+				Point:             cell.Position,
+				UncertaintyRadius: float32(cell.Radius),
+				OffsetAngle:       int32(math.Round(math.Mod(*cell.Azimuth-cell.Beamwidth/2+360, 360))),
+				IncludedAngle:     int32(math.Round(cell.Beamwidth)),
+			}, true
+		case models.SupportedGadShapesPOLYGON:
+			return &models.GeographicArea{Shape: shape, PointList: cell.polygon()}, true
+		}
+	}
+	return nil, false
+}
+
+// polygon returns the footprint of the cell, or approximates its sector or
+// its circle with a polygon
+func (cell *Cell) polygon() []models.GeographicalCoordinates {
+	if len(cell.Footprint) > 0 {
+		return cell.Footprint
+	}
+	if cell.Azimuth != nil && cell.Beamwidth < 360 {
+		/*the site and the arc of the sector*/
+		start := *cell.Azimuth - cell.Beamwidth/2
+		points := []models.GeographicalCoordinates{cell.Position}
+		for i := 0; i < maxPolygonPoints-1; i++ {
+			bearing := start + cell.Beamwidth*float64(i)/float64(maxPolygonPoints-2)
+			points = append(points, Destination(cell.Position, bearing, cell.Radius))
+		}
+		return points
+	}
+	points := make([]models.GeographicalCoordinates, 0, maxPolygonPoints)
+	for i := 0; i < maxPolygonPoints; i++ {
+		points = append(points, Destination(cell.Position, 360*float64(i)/maxPolygonPoints, cell.Radius))
+	}
+	return points
+}
+
+func supports(shapes []models.SupportedGadShapes, shape models.SupportedGadShapes) bool {
+	if len(shapes) == 0 {
+		return true
+	}
+	for _, s := range shapes {
+		if s == shape {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------------------------
+// readCsvCatalogue reads a catalogue whose header names the columns, among
+// cellId, lat, lon, radius and the optional azimuth and beamwidth
+func readCsvCatalogue(r io.Reader) (*Catalogue, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read cell catalogue header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"cellId", "lat", "lon", "radius"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("cell catalogue has no %s column", name)
+		}
+	}
+
+	catalogue := NewCatalogue()
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return catalogue, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		cell, err := csvCell(field)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := catalogue.Add(field("cellId"), cell); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func csvCell(field func(name string) string) (Cell, error) {
+	var cell Cell
+	var err error
+	if cell.Position.Lat, err = parseFloat("lat", field("lat")); err != nil {
+		return cell, err
+	}
+	if cell.Position.Lon, err = parseFloat("lon", field("lon")); err != nil {
+		return cell, err
+	}
+	if cell.Radius, err = parseFloat("radius", field("radius")); err != nil {
+		return cell, err
+	}
+	if value := field("azimuth"); len(value) > 0 {
+		azimuth, err := parseFloat("azimuth", value)
+		if err != nil {
+			return cell, err
+		}
+		cell.Azimuth = &azimuth
+	}
+	if value := field("beamwidth"); len(value) > 0 {
+		if cell.Beamwidth, err = parseFloat("beamwidth", value); err != nil {
+			return cell, err
+		}
+	}
+	return cell, nil
+}
+
+func parseFloat(name string, value string) (float64, error) {
+	if len(value) == 0 {
+		return 0, fmt.Errorf("missing %s", name)
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", name, value)
+	}
+	return f, nil
+}
+
+type geoJsonCollection struct {
+	Features []struct {
+		Geometry struct {
+			Type        string          `json:"type"`
+			Coordinates json.RawMessage `json:"coordinates"`
+		} `json:"geometry"`
+		Properties struct {
+			CellId    string   `json:"cellId"`
+			Azimuth   *float64 `json:"azimuth"`
+			Beamwidth float64  `json:"beamwidth"`
+			Radius    float64  `json:"radius"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+// readGeoJsonCatalogue reads a feature collection of cells, each feature being
+// the Point of the cell site or the Polygon of the cell footprint, with the
+// cellId, radius, azimuth and beamwidth properties
+func readGeoJsonCatalogue(r io.Reader) (*Catalogue, error) {
+	var collection geoJsonCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, fmt.Errorf("malformed cell catalogue: %w", err)
+	}
+
+	catalogue := NewCatalogue()
+	for i, feature := range collection.Features {
+		props := feature.Properties
+		cell := Cell{Azimuth: props.Azimuth, Beamwidth: props.Beamwidth, Radius: props.Radius}
+
+		switch feature.Geometry.Type {
+		case "Point":
+			var point [2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &point); err != nil {
+				return nil, fmt.Errorf("feature %d: malformed point: %w", i, err)
+			}
+			/*GeoJSON positions are longitude first*/
+			cell.Position = models.GeographicalCoordinates{Lat: point[1], Lon: point[0]}
+		case "Polygon":
+			var rings [][][2]float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil || len(rings) == 0 || len(rings[0]) < 4 {
+				return nil, fmt.Errorf("feature %d: malformed polygon", i)
+			}
+			/*the outer ring, without its closing position*/
+			ring := rings[0][:len(rings[0])-1]
+			for _, position := range ring {
+				cell.Footprint = append(cell.Footprint, models.GeographicalCoordinates{Lat: position[1], Lon: position[0]})
+			}
+			cell.Position, _ = Center(&models.GeographicArea{Shape: models.SupportedGadShapesPOLYGON, PointList: cell.Footprint})
+			if cell.Radius == 0 {
+				for _, point := range cell.Footprint {
+					cell.Radius = math.Max(cell.Radius, Distance(cell.Position, point))
+				}
+			}
+		default:
+			return nil, fmt.Errorf("feature %d: unsupported geometry %q", i, feature.Geometry.Type)
+		}
+
+		if err := catalogue.Add(props.CellId, cell); err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+	}
+	return catalogue, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package area
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+const csvCatalogue = `# cells of the test site
+cellId,lat,lon,azimuth,beamwidth,radius
+00101000000001,43.6145,7.0713,90,60,800
+000000002,43.6200,7.0500,,,300
+`
+
+const geoJsonCatalogue = `{"type":"FeatureCollection","features":[{
+	"type":"Feature",
+	"geometry":{"type":"Polygon","coordinates":[[[7.04,43.61],[7.06,43.61],[7.06,43.63],[7.04,43.63],[7.04,43.61]]]},
+	"properties":{"cellId":"000000003"}
+}]}`
+
+func writeCatalogue(t *testing.T, name string, content string) *Catalogue {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	catalogue, err := LoadCatalogue(path)
+	if err != nil {
+		t.Fatalf("could not load %s: %v", name, err)
+	}
+	return catalogue
+}
+
+func TestResolveCsvCatalogue(t *testing.T) {
+	catalogue := writeCatalogue(t, "cells.csv", csvCatalogue)
+	if catalogue.Len() != 2 {
+		t.Fatalf("got %d cells, wanted 2", catalogue.Len())
+	}
+
+	tests := []struct {
+		name        string
+		cellId      string
+		shapes      []models.SupportedGadShapes
+		wantedShape models.SupportedGadShapes
+	}{
+		{"sector", "000000001", nil, models.SupportedGadShapesELLIPSOID_ARC},
+		{"sector as polygon", "000000001", []models.SupportedGadShapes{models.SupportedGadShapesPOLYGON}, models.SupportedGadShapesPOLYGON},
+		{"sector as circle", "000000001", []models.SupportedGadShapes{models.SupportedGadShapesPOINT, models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE}, models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE},
+		{"omnidirectional", "000000002", nil, models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE},
+		{"no supported shape", "000000002", []models.SupportedGadShapes{models.SupportedGadShapesPOINT}, ""},
+		{"unknown cell", "000000009", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shape, ok := catalogue.Resolve(nrUserLocation("0001", tt.cellId), tt.shapes)
+			if !ok {
+				if len(tt.wantedShape) > 0 {
+					t.Errorf("could not resolve, wanted %s", tt.wantedShape)
+				}
+				return
+			}
+			if shape.Shape != tt.wantedShape {
+				t.Errorf("got %s, wanted %s", shape.Shape, tt.wantedShape)
+			}
+			if shape.Shape == models.SupportedGadShapesPOLYGON && len(shape.PointList) > maxPolygonPoints {
+				t.Errorf("got a polygon of %d points", len(shape.PointList))
+			}
+		})
+	}
+}
+
+func TestSectorCoverage(t *testing.T) {
+	catalogue := writeCatalogue(t, "cells.csv", csvCatalogue)
+	site := models.GeographicalCoordinates{Lat: 43.6145, Lon: 7.0713}
+	east := Destination(site, 90, 500)
+	west := Destination(site, 270, 500)
+
+	for _, shapes := range [][]models.SupportedGadShapes{{models.SupportedGadShapesELLIPSOID_ARC}, {models.SupportedGadShapesPOLYGON}} {
+		shape, _ := catalogue.Resolve(nrUserLocation("0001", "000000001"), shapes)
+		if !ShapeContains(shape, east) || ShapeContains(shape, west) {
+			t.Errorf("%s does not cover the sector facing east", shape.Shape)
+		}
+	}
+
+	arc, _ := catalogue.Resolve(nrUserLocation("0001", "000000001"), nil)
+	if center, _ := Center(arc); !ShapeContains(arc, center) {
+		t.Errorf("the center of the arc is not in the arc")
+	}
+}
+
+func TestResolveGeoJsonCatalogue(t *testing.T) {
+	catalogue := writeCatalogue(t, "cells.geojson", geoJsonCatalogue)
+
+	shape, ok := catalogue.Resolve(nrUserLocation("0001", "000000003"), nil)
+	if !ok || shape.Shape != models.SupportedGadShapesPOLYGON || len(shape.PointList) != 4 {
+		t.Fatalf("got %+v, wanted the footprint polygon", shape)
+	}
+	if shape.PointList[0].Lat != 43.61 || shape.PointList[0].Lon != 7.04 {
+		t.Errorf("got %+v, positions are longitude first", shape.PointList[0])
+	}
+
+	circle, ok := catalogue.Resolve(nrUserLocation("0001", "000000003"), []models.SupportedGadShapes{models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE})
+	if !ok || !ShapeContains(circle, models.GeographicalCoordinates{Lat: 43.611, Lon: 7.041}) {
+		t.Errorf("got %+v, wanted the circle around the footprint", circle)
+	}
+}
+
+func TestLoadInvalidCatalogue(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wanted  string
+	}{
+		{"missing column", "cells.csv", "cellId,lat,lon\n1,43,7\n", "no radius column"},
+		{"invalid value", "cells.csv", "cellId,lat,lon,radius\n1,north,7,100\n", "line 2: invalid lat"},
+		{"no radius", "cells.csv", "cellId,lat,lon,radius\n1,43,7,0\n", "radius must be positive"},
+		{"unsupported geometry", "cells.geojson", `{"features":[{"geometry":{"type":"LineString","coordinates":[]}}]}`, "unsupported geometry"},
+		{"unsupported format", "cells.txt", "", "unsupported cell catalogue format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadCatalogue(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wanted) {
+				t.Errorf("got error %v, wanted %q", err, tt.wanted)
+			}
+		})
+	}
+}
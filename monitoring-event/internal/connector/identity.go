@@ -25,14 +25,54 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"time"
 )
 
 // ------------------------------------------------------------------------------
 func (c *Connector) LookupExternalId(afId string, externalId string) (string, error) {
+	return c.resolveSupi("externalId", externalId)
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) LookupMsisdn(msisdn string) (string, error) {
+	return c.resolveSupi("msisdn", msisdn)
+}
+
+// ------------------------------------------------------------------------------
+// ExternalIdOf asks the identity service for the externalId of a SUPI issued to
+// the AF, so that the UEs known by the NEF only are never reported by SUPI
+func (c *Connector) ExternalIdOf(afId string, supi string) (string, error) {
+	val, err := c.identityGet("/externalId", url.Values{"afId": {afId}, "supi": {supi}})
+	if err != nil {
+		return "", err
+	}
+	externalId, ok := val["externalId"].(string)
+	if !ok || len(externalId) == 0 {
+		return "", fmt.Errorf("no externalId for the supi %s", supi)
+	}
+	return externalId, nil
+}
+
+// ------------------------------------------------------------------------------
+// resolveSupi asks the identity service for the SUPI of a UE identifier
+func (c *Connector) resolveSupi(param string, value string) (string, error) {
+	val, err := c.identityGet("/resolve", url.Values{param: {value}})
+	if err != nil {
+		return "", err
+	}
+	supi, ok := val["Supi"].(string)
+	if !ok || len(supi) == 0 {
+		return "", fmt.Errorf("the %s %s was not found", param, value)
+	}
+	return supi, nil
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) identityGet(path string, query url.Values) (map[string]interface{}, error) {
 	/* Execute client code for the 3GPP target NF*/
 	// The URL you want to GET
-	url := c.Cfg().Sbi.IdentitySvc + "/resolve?externalId=" + externalId
+	uri := c.Cfg().Sbi.IdentitySvc + path + "?" + query.Encode()
 
 	// Create a custom HTTP client with a timeout
 	client := &http.Client{
@@ -40,9 +80,9 @@ func (c *Connector) LookupExternalId(afId string, externalId string) (string, er
 	}
 
 	// Create a new GET request
-	req, err := http.NewRequest("GET", url, nil)
+	req, err := http.NewRequest("GET", uri, nil)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Optionally, add headers
@@ -51,7 +91,7 @@ func (c *Connector) LookupExternalId(afId string, externalId string) (string, er
 	// Send the GET request
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	defer func(body io.ReadCloser) {
 		if err := body.Close(); err != nil {
@@ -62,21 +102,20 @@ func (c *Connector) LookupExternalId(afId string, externalId string) (string, er
 	// Check the HTTP status code
 	if resp.StatusCode != http.StatusOK {
 		if resp.StatusCode == http.StatusNotFound {
-			return "", fmt.Errorf("the external ID %s was not found", externalId)
+			return nil, fmt.Errorf("the %s was not found", query.Encode())
 		}
-		return "", fmt.Errorf("error: received status code %d", resp.StatusCode)
+		return nil, fmt.Errorf("error: received status code %d", resp.StatusCode)
 	}
 
 	// Read the response body
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("error reading response body")
+		return nil, fmt.Errorf("error reading response body")
 	}
 
 	var val map[string]interface{}
 	if err := json.Unmarshal(body, &val); err != nil {
-		return "", fmt.Errorf("error parsing response body")
+		return nil, fmt.Errorf("error parsing response body")
 	}
-
-	return val["Supi"].(string), nil
+	return val, nil
 }
@@ -50,24 +50,35 @@ func (r *Connector) QueryUEInfo(imsi string) (*models.UeInfo, error) {
 
 func (r *Connector) QueryUEsInfo() ([]*models.UeInfo, error) {
 
-	keys, _, err := r.redisClient.Scan(r.ctx, 0, "user:*", 0).Result()
-	if err != nil {
-		log.Fatalf("Failed to scan: %v", err)
-	}
-
 	var result []*models.UeInfo
 
-	for _, key := range keys {
-		key = strings.Split(key, ":")[1]
+	iter := r.redisClient.Scan(r.ctx, 0, "user:*", 0).Iterator()
+	for iter.Next(r.ctx) {
+		key := strings.Split(iter.Val(), ":")[1]
 		ue, err := r.QueryUEInfo(key)
 		if err == nil {
 			result = append(result, ue)
 		}
 	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan UE profiles: %w", err)
+	}
 
 	return result, nil
 }
 
+// GetGroupMembers returns the SUPIs of the members of an external group, kept
+// as a set under group:<extGroupId> beside the user:<imsi> entries
+func (r *Connector) GetGroupMembers(extGroupId string) ([]string, error) {
+	key := fmt.Sprintf("group:%s", extGroupId)
+	members, err := r.redisClient.SMembers(r.ctx, key).Result()
+	if err != nil {
+		log.Printf("GetGroupMembers: Redis query failed for key=%s, error=%v", key, err)
+		return nil, fmt.Errorf("failed to get group members: %w", err)
+	}
+	return members, nil
+}
+
 func (r *Connector) SubscribeUserInfo(imsi string) (*redis.PubSub, error) {
 	sub := r.redisClient.Subscribe(r.ctx, fmt.Sprintf("user:%s", imsi))
 	if sub == nil {
@@ -77,8 +88,12 @@ func (r *Connector) SubscribeUserInfo(imsi string) (*redis.PubSub, error) {
 	return sub, nil
 }
 
-func (r *Connector) SubscribeUserEvent(imsi string, eventType string) (*redis.PubSub, error) {
-	sub := r.redisClient.Subscribe(r.ctx, fmt.Sprintf("user:%s:%s", imsi, eventType))
+func (r *Connector) SubscribeUserEvent(imsi string, eventTypes ...string) (*redis.PubSub, error) {
+	channels := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		channels = append(channels, fmt.Sprintf("user:%s:%s", imsi, eventType))
+	}
+	sub := r.redisClient.Subscribe(r.ctx, channels...)
 	if sub == nil {
 		return nil, fmt.Errorf("failed to subscribe to user info for %s", imsi)
 	}
@@ -21,6 +21,7 @@ package contexts
 
 import (
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/google/uuid"
@@ -34,6 +35,7 @@ type AppFunctionCtx struct {
 	Mu      sync.RWMutex
 	lastId  uint64
 	TransId uint64
+	store   SubscriptionStore
 }
 
 func NewAf(afId string) *AppFunctionCtx {
@@ -73,12 +75,44 @@ func (afCtx *AppFunctionCtx) DeleteAfscription(subId string) error {
 	_, ok := afCtx.subs[subId]
 	if ok {
 		delete(afCtx.subs, subId)
+		if afCtx.store != nil {
+			if err := afCtx.store.Delete(afCtx.afId, subId); err != nil {
+				log.Printf("could not delete stored subscription %s: %s", subId, err)
+			}
+		}
 		return nil
 	} else {
 		return fmt.Errorf("could not find monitoring event subscription")
 	}
 }
 
+// SaveAfSubscription persists the current state of the subscription, to be
+// called once the subscription notification handler is started.
+func (afCtx *AppFunctionCtx) SaveAfSubscription(sub *AfSubscriptionCtx) error {
+	if afCtx.store == nil {
+		return nil
+	}
+	return afCtx.store.Save(&SubscriptionRecord{
+		AfId:             afCtx.afId,
+		SubId:            sub.subId,
+		Supi:             sub.supi,
+		RemainingReports: sub.remainingReports,
+		Members:          sub.members,
+		Data:             sub.data,
+	})
+}
+
+func (afCtx *AppFunctionCtx) restoreAfSubscription(record *SubscriptionRecord) *AfSubscriptionCtx {
+	loc := createMonitoringEventLocation(afCtx.afId, record.SubId)
+	sub := NewAfSubscriptionCtx(record.SubId, loc, record.Supi, record.Data)
+	if sub != nil {
+		sub.remainingReports = record.RemainingReports
+		sub.members = record.Members
+		afCtx.subs[record.SubId] = sub
+	}
+	return sub
+}
+
 func (afCtx *AppFunctionCtx) newSubscriptionId() string {
 	return uuid.New().String()
 }
@@ -34,12 +34,18 @@ type AfSubscriptionCtx struct {
 	eventType    models.MonitoringType
 	notifHandler *handlers.NotificationHandler
 	supi         string
+
+	remainingReports int32 /*reports left out of maximumNumberOfReports, 0 when unlimited*/
+
+	members        map[string]handlers.GroupMember /*members of a group subscription by SUPI, nil for a single UE*/
+	memberHandlers map[string]*handlers.NotificationHandler
+	groupReporter  *handlers.GroupReporter
 }
 
 func NewAfSubscriptionCtx(subId string, loc string, supi string, data *models.MonitoringEventSubscription) *AfSubscriptionCtx {
 	/* derive other parameters from the subscription data */
 
-	if len(supi) == 0 || len(loc) == 0 || data == nil || len(data.NotificationDestination) == 0 || len(data.MonitoringType) == 0 {
+	if data == nil || (len(supi) == 0 && len(data.ExternalGroupId) == 0 && !handlers.IsUeCountType(data.MonitoringType)) || len(loc) == 0 || len(data.NotificationDestination) == 0 || len(data.MonitoringType) == 0 {
 		return nil
 	}
 
@@ -84,3 +90,50 @@ func (subCtx *AfSubscriptionCtx) GetSubscriptionData() models.MonitoringEventSub
 		return *subCtx.data
 	}
 }
+
+func (subCtx *AfSubscriptionCtx) GetSupi() string {
+	return subCtx.supi
+}
+
+func (subCtx *AfSubscriptionCtx) GetRemainingReports() int32 {
+	return subCtx.remainingReports
+}
+
+func (subCtx *AfSubscriptionCtx) SetRemainingReports(remainingReports int32) {
+	subCtx.remainingReports = remainingReports
+}
+
+func (subCtx *AfSubscriptionCtx) IsGroup() bool {
+	return subCtx.members != nil
+}
+
+func (subCtx *AfSubscriptionCtx) GetMembers() map[string]handlers.GroupMember {
+	return subCtx.members
+}
+
+func (subCtx *AfSubscriptionCtx) SetMembers(members map[string]handlers.GroupMember) {
+	subCtx.members = members
+}
+
+// SetGroupNotificationHandlers sets the handlers of the group members, by SUPI,
+// and the reporter gathering their reports
+func (subCtx *AfSubscriptionCtx) SetGroupNotificationHandlers(reporter *handlers.GroupReporter, memberHandlers map[string]*handlers.NotificationHandler) {
+	subCtx.groupReporter = reporter
+	subCtx.memberHandlers = memberHandlers
+}
+
+// StopNotifications stops the notification handlers of the subscription,
+// returning false when none was running
+func (subCtx *AfSubscriptionCtx) StopNotifications() bool {
+	stopped := false
+	if subCtx.notifHandler != nil {
+		stopped = subCtx.notifHandler.Stop()
+	}
+	for _, notifHandler := range subCtx.memberHandlers {
+		stopped = notifHandler.Stop() || stopped
+	}
+	if subCtx.groupReporter != nil {
+		subCtx.groupReporter.Stop()
+	}
+	return stopped
+}
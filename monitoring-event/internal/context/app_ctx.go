@@ -19,17 +19,21 @@
 
 package contexts
 
+import "log"
+
 type app interface {
 }
 
 type MonitoringEventCtx struct {
 	app
 	AppFunc map[string]*AppFunctionCtx
+	store   SubscriptionStore
 }
 
-func NewMonitoringEventCtx(app app) *MonitoringEventCtx {
+func NewMonitoringEventCtx(app app, store SubscriptionStore) *MonitoringEventCtx {
 	ctx := &MonitoringEventCtx{app: app,
 		AppFunc: make(map[string]*AppFunctionCtx),
+		store:   store,
 	}
 	return ctx
 }
@@ -49,7 +53,30 @@ func (c *MonitoringEventCtx) AddAf(id string) *AppFunctionCtx {
 		return nil
 	} else {
 		newCtx := NewAf(id)
+		newCtx.store = c.store
 		c.AppFunc[id] = newCtx
 		return newCtx
 	}
 }
+
+// Restore reloads the subscriptions persisted in the store, their
+// notification handlers are to be started again by the caller
+func (c *MonitoringEventCtx) Restore() error {
+	if c.store == nil {
+		return nil
+	}
+	records, err := c.store.LoadAll()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		af := c.GetAf(record.AfId)
+		if af == nil {
+			af = c.AddAf(record.AfId)
+		}
+		if sub := af.restoreAfSubscription(record); sub == nil {
+			log.Printf("could not restore invalid subscription %s", record.SubId)
+		}
+	}
+	return nil
+}
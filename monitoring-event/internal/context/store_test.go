@@ -0,0 +1,115 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package contexts
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/handlers"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func TestRestoreSubscriptions(t *testing.T) {
+	afId := "af-test"
+	supi := "001010000000001"
+	store := NewMemoryStore()
+	ctx := NewMonitoringEventCtx(nil, store)
+	af := ctx.AddAf(afId)
+
+	/* suscription data */
+	data := &models.MonitoringEventSubscription{
+		NotificationDestination: "http://af/notify",
+		MonitoringType:          models.MonitoringTypeLocationReporting,
+	}
+
+	subCtx := af.NewAfSubscription(supi, data)
+	if subCtx == nil {
+		t.Fatalf("got nil subscriptionContext")
+	}
+	subCtx.SetRemainingReports(4)
+	if err := af.SaveAfSubscription(subCtx); err != nil {
+		t.Fatalf("error while saving subscription: %v", err)
+	}
+
+	/* a new context on the same store, as after a restart */
+	restored := NewMonitoringEventCtx(nil, store)
+	if err := restored.Restore(); err != nil {
+		t.Fatalf("error while restoring subscriptions: %v", err)
+	}
+	restoredSub := restored.GetAf(afId).GetAfSubscription(subCtx.subId)
+	if restoredSub == nil {
+		t.Fatalf("subscription %s not restored", subCtx.subId)
+	}
+	if restoredSub.GetLocation() != subCtx.GetLocation() || restoredSub.GetSupi() != supi {
+		t.Errorf("got restored subscription %s/%s, wanted %s/%s", restoredSub.GetLocation(), restoredSub.GetSupi(), subCtx.GetLocation(), supi)
+	}
+	if restoredSub.GetRemainingReports() != 4 {
+		t.Errorf("got %d remaining reports, wanted 4", restoredSub.GetRemainingReports())
+	}
+	if restoredSub.GetEventType() != models.MonitoringTypeLocationReporting || restoredSub.GetNotificationUri() != "http://af/notify" {
+		t.Errorf("got restored event %s to %s", restoredSub.GetEventType(), restoredSub.GetNotificationUri())
+	}
+
+	/* deleted subscriptions are not restored anymore */
+	if err := restored.GetAf(afId).DeleteAfscription(subCtx.subId); err != nil {
+		t.Errorf("error while deleting subscription: %v", err)
+	}
+	records, _ := store.LoadAll()
+	if len(records) != 0 {
+		t.Errorf("got %d stored subscriptions, wanted 0", len(records))
+	}
+}
+
+func TestRestoreGroupSubscription(t *testing.T) {
+	afId := "af-test"
+	store := NewMemoryStore()
+	ctx := NewMonitoringEventCtx(nil, store)
+	af := ctx.AddAf(afId)
+
+	data := &models.MonitoringEventSubscription{
+		ExternalGroupId:         "fleet@example.com",
+		NotificationDestination: "http://af/notify",
+		MonitoringType:          models.MonitoringTypeLocationReporting,
+	}
+	subCtx := af.NewAfSubscription("", data)
+	if subCtx == nil {
+		t.Fatalf("got nil subscriptionContext for a group")
+	}
+	subCtx.SetMembers(map[string]handlers.GroupMember{
+		"001010000000001": {ExternalId: "truck1@example.com"},
+		"001010000000002": {Msisdn: "33600000002"},
+	})
+	if err := af.SaveAfSubscription(subCtx); err != nil {
+		t.Fatalf("error while saving subscription: %v", err)
+	}
+
+	restored := NewMonitoringEventCtx(nil, store)
+	if err := restored.Restore(); err != nil {
+		t.Fatalf("error while restoring subscriptions: %v", err)
+	}
+	restoredSub := restored.GetAf(afId).GetAfSubscription(subCtx.subId)
+	if restoredSub == nil || !restoredSub.IsGroup() {
+		t.Fatalf("group subscription %s not restored", subCtx.subId)
+	}
+	members := restoredSub.GetMembers()
+	if len(members) != 2 || members["001010000000001"].ExternalId != "truck1@example.com" || members["001010000000002"].Msisdn != "33600000002" {
+		t.Errorf("got restored members %+v", members)
+	}
+}
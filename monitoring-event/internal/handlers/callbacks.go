@@ -31,60 +31,67 @@ import (
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
 )
 
-func HandleLocationReport(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error {
-
-	var jsonData []byte
-
-	/* marshall interface into json */
-	jsonData, err := json.Marshal(patch.Data)
-	if err != nil {
-		return fmt.Errorf("malformed core network event data: %s", err.Error())
-	}
-
-	locInfo := models.Location{}
-	if err := json.Unmarshal(jsonData, &locInfo); err != nil {
-		return fmt.Errorf("malformed core network event data: %s", err.Error())
-	}
+// NewLocationCallback returns the event callback of a LOCATION_REPORTING
+// subscription, the geographic area of each location being given by position
+func NewLocationCallback(position PositionFun) callbackFun {
+	return func(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
+		locInfo := models.Location{}
+		if err := decodePatch(patch, &locInfo); err != nil {
+			return nil, err
+		}
 
-	nrLoc := locInfo.UserLocation.NrLocation
+		report := models.MonitoringEventReport{
+			ExternalId:     &patch.Imsi,
+			MonitoringType: models.MonitoringTypeLocationReporting,
+			LocationInfo:   LocationInfo(&locInfo, position(&locInfo)),
+			EventTime:      time.Unix(locInfo.TimeStamp, 0),
+		}
+		if nrLoc := locInfo.UserLocation.NrLocation; nrLoc != nil {
+			report.PlmnId = &models.PlmnId{
+				Mcc: nrLoc.Tai.PlmnId.Mcc,
+				Mnc: nrLoc.Tai.PlmnId.Mnc,
+			}
+		}
 
-	report := models.MonitoringEventReport{
-		ExternalId:     &patch.Imsi,
-		MonitoringType: models.MonitoringTypeLocationReporting,
-		PlmnId: &models.PlmnId{
-			Mcc: nrLoc.Tai.PlmnId.Mcc,
-			Mnc: nrLoc.Tai.PlmnId.Mnc,
-		},
-		LocationInfo: &models.LocationInfo{
-			CellId:            nrLoc.Ncgi.NrCellId,
-			AgeOfLocationInfo: int32(time.Now().Unix() - locInfo.TimeStamp),
-			UserLocation:      &locInfo.UserLocation,
-			GeographicArea:    locInfo.GeographicArea,
-			PositionMethod:    models.PositioningMethodCellID,
-		},
-		EventTime: time.Unix(locInfo.TimeStamp, 0),
+		return &report, nil
 	}
+}
 
-	monitoringEvent := models.MonitoringNotification{
-		Subscription: subscriptionLocation,
-	}
-	monitoringEvent.MonitoringEventReports = append(monitoringEvent.MonitoringEventReports, report)
-	return sendNotification(notificationUri, monitoringEvent)
+// LocationInfo describes the cell based location of a UE. Without a
+// geographic area the accuracy of the location is unknown, which is told by
+// an unfulfilled accuracy.
+func LocationInfo(loc *models.Location, geographicArea *models.GeographicArea) *models.LocationInfo {
+	locationInfo := &models.LocationInfo{
+		AgeOfLocationInfo: int32(time.Now().Unix() - loc.TimeStamp),
+		UserLocation:      &loc.UserLocation,
+		GeographicArea:    geographicArea,
+		PositionMethod:    models.PositioningMethodCellID,
+	}
+	if nrLoc := loc.UserLocation.NrLocation; nrLoc != nil {
+		locationInfo.CellId = nrLoc.Ncgi.NrCellId
+	} else if eutraLoc := loc.UserLocation.EutraLocation; eutraLoc != nil {
+		locationInfo.CellId = eutraLoc.Ecgi.EutraCellId
+	}
+	if geographicArea == nil {
+		notFulfilled := models.AccuracyFulfilmentIndicatorRequestedAccuracyNotFulfilled
+		locationInfo.QosFulfilInd = &notFulfilled
+	}
+	return locationInfo
 }
 
-func HandleDDDSReport(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error {
+func HandleDDDSReport(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
 
 	var jsonData []byte
 
 	/* marshall interface into json */
 	jsonData, err := json.Marshal(patch.Data)
 	if err != nil {
-		return fmt.Errorf("malformed core network event data: %s", err.Error())
+		return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 	}
 
 	ddds := models.Ddds{}
 	if err := json.Unmarshal(jsonData, &ddds); err != nil {
-		return fmt.Errorf("malformed core network event data: %s", err.Error())
+		return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 	}
 
 	var pduSessInfo *models.PduSessionInformation
@@ -107,30 +114,16 @@ func HandleDDDSReport(subscriptionLocation string, userId string, notificationUr
 		EventTime:      time.Unix(ddds.TimeStamp, 0),
 	}
 
-	monitoringEvent := models.MonitoringNotification{
-		Subscription: subscriptionLocation,
-	}
-	monitoringEvent.MonitoringEventReports = append(monitoringEvent.MonitoringEventReports, report)
-	return sendNotification(notificationUri, monitoringEvent)
-}
-
-func HandleRegistrationReport(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error {
-	log.Printf("handleRegistrationReport: notificationUri=%s, identity=%s, patch=%+v", notificationUri, patch.Imsi, patch)
-	return nil
+	return &report, nil
 }
 
-func HandleConnectivityReport(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error {
-	log.Printf("handleConnectivityReport: notificationUri=%s, identity=%s, patch=%+v", notificationUri, patch.Imsi, patch)
-	return nil
-}
-
-func HandlePdnStatusReport(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error {
+func HandlePdnStatusReport(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
 	var jsonData []byte
 
 	/* marshall interface into json */
 	jsonData, err := json.Marshal(patch.Data)
 	if err != nil {
-		return fmt.Errorf("malformed core network event data: %s", err.Error())
+		return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 	}
 
 	var pduSessInfo *models.PduSessionInformation
@@ -140,7 +133,7 @@ func HandlePdnStatusReport(subscriptionLocation string, userId string, notificat
 	if patch.Type == string(models.CORENETWORKEVENT_PDU_SES_EST) {
 		pdu_est := models.PduSesEst{}
 		if err := json.Unmarshal(jsonData, &pdu_est); err != nil {
-			return fmt.Errorf("malformed core network event data: %s", err.Error())
+			return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 		}
 		if pdu_est.Dnn != nil && pdu_est.Snssai != nil && pdu_est.AdIpv4Addr != nil {
 			pduSessInfo = &models.PduSessionInformation{
@@ -152,13 +145,13 @@ func HandlePdnStatusReport(subscriptionLocation string, userId string, notificat
 			eventTime = time.Unix(pdu_est.TimeStamp, 0)
 
 		} else {
-			return fmt.Errorf("malformed core network event data: %s", err.Error())
+			return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 		}
 
 	} else {
 		pdu_rel := models.PduSesRel{}
 		if err := json.Unmarshal(jsonData, &pdu_rel); err != nil {
-			return fmt.Errorf("malformed core network event data: %s", err.Error())
+			return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 		}
 		if pdu_rel.Dnn != nil && pdu_rel.Snssai != nil && pdu_rel.Ipv4Addr != nil {
 			pduSessInfo = &models.PduSessionInformation{
@@ -170,7 +163,7 @@ func HandlePdnStatusReport(subscriptionLocation string, userId string, notificat
 			eventTime = time.Unix(pdu_rel.TimeStamp, 0)
 
 		} else {
-			return fmt.Errorf("malformed core network event data: %s", err.Error())
+			return nil, fmt.Errorf("malformed core network event data: %s", err.Error())
 		}
 	}
 
@@ -189,10 +182,15 @@ func HandlePdnStatusReport(subscriptionLocation string, userId string, notificat
 		PdnConnInfoList: &[]models.PdnConnectionInformation{pdnInfo},
 	}
 
+	return &report, nil
+}
+
+// NotifyCancellation informs the AF that the subscription was cancelled by the NEF
+func NotifyCancellation(subscriptionLocation string, notificationUri string) error {
 	monitoringEvent := models.MonitoringNotification{
 		Subscription: subscriptionLocation,
+		CancelInd:    true,
 	}
-	monitoringEvent.MonitoringEventReports = append(monitoringEvent.MonitoringEventReports, report)
 	return sendNotification(notificationUri, monitoringEvent)
 }
 
@@ -218,7 +216,7 @@ func sendNotification(notificationUri string, v any) error {
 		}
 	}(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		return fmt.Errorf("failed to send notification, status code: %d", resp.StatusCode)
 	}
 	return err
@@ -0,0 +1,136 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// IsUeCountType tells whether the monitoring type reports a number of UEs or
+// PDU sessions, rather than the events of given UEs
+func IsUeCountType(monitoringType models.MonitoringType) bool {
+	switch monitoringType {
+	case models.MonitoringTypeNumberOfUesInAnArea, models.MonitoringTypeNumOfRegdUes, models.MonitoringTypeNumOfEstdPduSessions:
+		return true
+	}
+	return false
+}
+
+// UeCountReport counts the UEs or the PDU sessions of the monitoring type
+// among all the UEs known to the core network. inArea tells whether a UE is
+// located in the locationArea of a NUMBER_OF_UES_IN_AN_AREA subscription.
+func UeCountReport(data *models.MonitoringEventSubscription, ues []*models.UeInfo, inArea func(ue *models.UeInfo) bool) (*models.MonitoringEventReport, error) {
+	report := &models.MonitoringEventReport{
+		MonitoringType: data.MonitoringType,
+		EventTime:      time.Now(),
+	}
+	snssai := sliceFilter(data)
+
+	switch data.MonitoringType {
+	case models.MonitoringTypeNumberOfUesInAnArea:
+		count := int32(0)
+		for _, ue := range ues {
+			if !isDeregistered(ue) && ue.Location != nil && inArea(ue) {
+				count++
+			}
+		}
+		/*only the count, the AF does not track the UEs in the area*/
+		report.UePerLocationReport = &models.UePerLocationReport{UeCount: count}
+
+	case models.MonitoringTypeNumOfRegdUes:
+		count := int32(0)
+		for _, ue := range ues {
+			if !isRegistered(ue) {
+				continue
+			}
+			/*the slices of a UE are only known from its PDU sessions*/
+			if snssai != nil && len(establishedSessions(ue, "", snssai)) == 0 {
+				continue
+			}
+			count++
+		}
+		report.NSStatusInfo = &models.SacEventStatus{
+			ReachedNumUes: &models.SacInfo{NumericValNumUes: &count, UesWithPduSessionInd: snssai != nil},
+		}
+
+	case models.MonitoringTypeNumOfEstdPduSessions:
+		count := int32(0)
+		for _, ue := range ues {
+			count += int32(len(establishedSessions(ue, data.Dnn, snssai)))
+		}
+		report.NSStatusInfo = &models.SacEventStatus{
+			ReachedNumPduSess: &models.SacInfo{NumericValNumPduSess: &count},
+		}
+
+	default:
+		return nil, fmt.Errorf("monitoring event type %s is not a UE count", data.MonitoringType)
+	}
+	return report, nil
+}
+
+// isRegistered tells whether the UE is registered, as last reported by the AMF
+// or as implied by an established PDU session
+func isRegistered(ue *models.UeInfo) bool {
+	if ue.RegistrationInfo != nil {
+		return ue.RegistrationInfo.RmInfo.RmState == models.RmStateRegistered
+	}
+	return len(establishedSessions(ue, "", nil)) > 0
+}
+
+func isDeregistered(ue *models.UeInfo) bool {
+	return ue.RegistrationInfo != nil && ue.RegistrationInfo.RmInfo.RmState == models.RmStateDeregistered
+}
+
+// establishedSessions returns the PDU sessions of the UE that were not released
+// since, filtered on the dnn and slice when given
+func establishedSessions(ue *models.UeInfo, dnn string, snssai *models.Snssai) []*models.PduSesEst {
+	sessions := []*models.PduSesEst{}
+	for pduId, pduSessEst := range ue.PduSessEst {
+		if pduSessRel := ue.PduSessRel[pduId]; pduSessRel != nil && pduSessRel.TimeStamp > pduSessEst.TimeStamp {
+			continue
+		}
+		if len(dnn) > 0 && (pduSessEst.Dnn == nil || !strings.EqualFold(*pduSessEst.Dnn, dnn)) {
+			continue
+		}
+		if snssai != nil && (pduSessEst.Snssai == nil || !sameSlice(*pduSessEst.Snssai, *snssai)) {
+			continue
+		}
+		sessions = append(sessions, pduSessEst)
+	}
+	return sessions
+}
+
+// sliceFilter returns the slice of the subscription, nil when none was given
+func sliceFilter(data *models.MonitoringEventSubscription) *models.Snssai {
+	if data.Snssai.Sst == 0 && len(data.Snssai.Sd) == 0 {
+		return nil
+	}
+	return &data.Snssai
+}
+
+// sameSlice matches a slice against the filter, a filter without sd matching
+// all the slices of its sst
+func sameSlice(snssai models.Snssai, filter models.Snssai) bool {
+	return snssai.Sst == filter.Sst && (len(filter.Sd) == 0 || strings.EqualFold(snssai.Sd, filter.Sd))
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func sessionEst(dnn string, sst int32, timeStamp int64) *models.PduSesEst {
+	return &models.PduSesEst{Dnn: &dnn, Snssai: &models.Snssai{Sst: sst}, TimeStamp: timeStamp}
+}
+
+func countUes() []*models.UeInfo {
+	registered := &models.RegInfo{RmInfo: models.RmInfo{RmState: models.RmStateRegistered}}
+	deregistered := &models.RegInfo{RmInfo: models.RmInfo{RmState: models.RmStateDeregistered}}
+	return []*models.UeInfo{
+		{
+			RegistrationInfo: registered,
+			PduSessEst:       map[string]*models.PduSesEst{"1": sessionEst("internet", 1, 100), "2": sessionEst("ims", 2, 100)},
+			Location:         &models.Location{},
+		},
+		{
+			/*registered as it holds a PDU session, the other one was released*/
+			PduSessEst: map[string]*models.PduSesEst{"1": sessionEst("internet", 1, 100), "2": sessionEst("internet", 1, 100)},
+			PduSessRel: map[string]*models.PduSesRel{"2": {TimeStamp: 200}},
+			Location:   &models.Location{},
+		},
+		{
+			RegistrationInfo: registered,
+			Location:         &models.Location{},
+		},
+		{
+			RegistrationInfo: deregistered,
+			Location:         &models.Location{},
+		},
+	}
+}
+
+func TestUeCountReport(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   models.MonitoringEventSubscription
+		wanted int32
+	}{
+		{"registered UEs", models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumOfRegdUes}, 3},
+		{"registered UEs of a slice", models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumOfRegdUes, Snssai: models.Snssai{Sst: 2}}, 1},
+		{"PDU sessions", models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumOfEstdPduSessions}, 3},
+		{"PDU sessions of a dnn", models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumOfEstdPduSessions, Dnn: "internet"}, 2},
+		{"UEs in the area", models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumberOfUesInAnArea}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := UeCountReport(&tt.data, countUes(), func(ue *models.UeInfo) bool { return true })
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			var got *int32
+			switch {
+			case report.UePerLocationReport != nil:
+				got = &report.UePerLocationReport.UeCount
+			case report.NSStatusInfo != nil && report.NSStatusInfo.ReachedNumUes != nil:
+				got = report.NSStatusInfo.ReachedNumUes.NumericValNumUes
+			case report.NSStatusInfo != nil && report.NSStatusInfo.ReachedNumPduSess != nil:
+				got = report.NSStatusInfo.ReachedNumPduSess.NumericValNumPduSess
+			}
+			if got == nil || *got != tt.wanted {
+				t.Errorf("got report %+v, wanted a count of %d", report, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestUeCountReportArea(t *testing.T) {
+	data := &models.MonitoringEventSubscription{MonitoringType: models.MonitoringTypeNumberOfUesInAnArea}
+	ues := countUes()
+	report, _ := UeCountReport(data, ues, func(ue *models.UeInfo) bool { return ue == ues[0] })
+	if report.UePerLocationReport.UeCount != 1 || len(report.UePerLocationReport.ExternalIds) != 0 {
+		t.Errorf("got %+v, wanted an anonymous count of 1", report.UePerLocationReport)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// GroupMember identifies a member of a group subscription towards the AF
+type GroupMember struct {
+	ExternalId string `json:"externalId,omitempty"`
+	Msisdn     string `json:"msisdn,omitempty"`
+}
+
+// Stamp sets the member identifiers known to the AF in the report
+func (member GroupMember) Stamp(report *models.MonitoringEventReport) {
+	if len(member.ExternalId) > 0 {
+		externalId := member.ExternalId
+		report.ExternalId = &externalId
+	}
+	if len(member.Msisdn) > 0 {
+		msisdn := member.Msisdn
+		report.Msisdn = &msisdn
+	}
+}
+
+// GroupReporter gathers the reports of the members of a group subscription.
+// Without guard time each report is notified on its own, otherwise the reports
+// received within groupReportGuardTime of the first one are notified together.
+type GroupReporter struct {
+	mu                   sync.Mutex
+	subscriptionLocation string
+	notificationUri      string
+	guardTime            time.Duration
+	pending              []models.MonitoringEventReport
+	timer                *time.Timer
+	stopped              bool
+	remainingReports     int32 /*notifications left before the subscription ends, 0 when unlimited*/
+	onReport             func(remainingReports int32)
+}
+
+func NewGroupReporter(subscriptionLocation string, notificationUri string, guardTime int32) *GroupReporter {
+	return &GroupReporter{
+		subscriptionLocation: subscriptionLocation,
+		notificationUri:      notificationUri,
+		guardTime:            time.Duration(guardTime) * time.Second,
+	}
+}
+
+// SetReportLimit bounds the number of notifications sent for the group, a
+// batch of member reports counting as one notification
+func (reporter *GroupReporter) SetReportLimit(remainingReports int32, onReport func(remainingReports int32)) {
+	reporter.remainingReports = remainingReports
+	reporter.onReport = onReport
+}
+
+// Report queues the report of a member, to be notified at the end of the guard time
+func (reporter *GroupReporter) Report(report *models.MonitoringEventReport) {
+	reporter.mu.Lock()
+	if reporter.stopped {
+		reporter.mu.Unlock()
+		return
+	}
+	reporter.pending = append(reporter.pending, *report)
+	if reporter.guardTime > 0 {
+		if reporter.timer == nil {
+			reporter.timer = time.AfterFunc(reporter.guardTime, reporter.Flush)
+		}
+		reporter.mu.Unlock()
+		return
+	}
+	reporter.mu.Unlock()
+	reporter.Flush()
+}
+
+// Flush notifies the pending reports at once
+func (reporter *GroupReporter) Flush() {
+	reporter.mu.Lock()
+	reporter.timer = nil
+	if reporter.stopped || len(reporter.pending) == 0 {
+		reporter.mu.Unlock()
+		return
+	}
+	monitoringEvent := models.MonitoringNotification{
+		Subscription:           reporter.subscriptionLocation,
+		MonitoringEventReports: reporter.pending,
+	}
+	reporter.pending = nil
+
+	counted := reporter.remainingReports > 0
+	if counted {
+		if reporter.remainingReports == 1 {
+			monitoringEvent.CancelInd = true
+			/*nothing is sent after the cancellation indication*/
+			reporter.stopped = true
+		}
+		reporter.remainingReports--
+	}
+	remainingReports := reporter.remainingReports
+	reporter.mu.Unlock()
+
+	/*sent out of the lock, the report callback may stop the reporter*/
+	if err := sendNotification(reporter.notificationUri, monitoringEvent); err != nil {
+		log.Printf("notification failed: %s", err.Error())
+	}
+	if counted && reporter.onReport != nil {
+		reporter.onReport(remainingReports)
+	}
+}
+
+// Stop drops the pending reports, no notification is sent afterwards
+func (reporter *GroupReporter) Stop() {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+
+	reporter.stopped = true
+	reporter.pending = nil
+	if reporter.timer != nil {
+		reporter.timer.Stop()
+		reporter.timer = nil
+	}
+}
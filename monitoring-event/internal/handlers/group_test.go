@@ -0,0 +1,147 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+type notificationRecorder struct {
+	mu            sync.Mutex
+	notifications []models.MonitoringNotification
+}
+
+func (rec *notificationRecorder) serve(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := models.MonitoringNotification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("malformed notification: %v", err)
+		}
+		rec.mu.Lock()
+		rec.notifications = append(rec.notifications, notification)
+		rec.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func (rec *notificationRecorder) get() []models.MonitoringNotification {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]models.MonitoringNotification{}, rec.notifications...)
+}
+
+func memberReport(member GroupMember) *models.MonitoringEventReport {
+	report := &models.MonitoringEventReport{MonitoringType: models.MonitoringTypeLocationReporting}
+	member.Stamp(report)
+	return report
+}
+
+func TestGroupReporterWithoutGuardTime(t *testing.T) {
+	rec := &notificationRecorder{}
+	server := rec.serve(t)
+	defer server.Close()
+
+	reporter := NewGroupReporter("/sub-test", server.URL, 0)
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Report(memberReport(GroupMember{Msisdn: "33600000002"}))
+
+	notifications := rec.get()
+	if len(notifications) != 2 {
+		t.Fatalf("got %d notifications, wanted 2", len(notifications))
+	}
+	if *notifications[0].MonitoringEventReports[0].ExternalId != "truck1@example.com" || *notifications[1].MonitoringEventReports[0].Msisdn != "33600000002" {
+		t.Errorf("unexpected notifications %+v", notifications)
+	}
+}
+
+func TestGroupReporterGuardTime(t *testing.T) {
+	rec := &notificationRecorder{}
+	server := rec.serve(t)
+	defer server.Close()
+
+	reporter := NewGroupReporter("/sub-test", server.URL, 3600)
+	counts := []int32{}
+	reporter.SetReportLimit(2, func(remainingReports int32) {
+		counts = append(counts, remainingReports)
+	})
+
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck2@example.com"}))
+	if len(rec.get()) != 0 {
+		t.Fatalf("reports should be held until the end of the guard time")
+	}
+
+	/* end of the guard window */
+	reporter.Flush()
+	notifications := rec.get()
+	if len(notifications) != 1 || len(notifications[0].MonitoringEventReports) != 2 || notifications[0].CancelInd {
+		t.Fatalf("got notifications %+v, wanted one with 2 reports", notifications)
+	}
+
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Flush()
+	notifications = rec.get()
+	if len(notifications) != 2 || !notifications[1].CancelInd {
+		t.Fatalf("the last notification should carry cancelInd, got %+v", notifications)
+	}
+	if len(counts) != 2 || counts[0] != 1 || counts[1] != 0 {
+		t.Errorf("got remaining reports %v, wanted [1 0]", counts)
+	}
+
+	/* nothing is sent after the cancellation */
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Flush()
+	if len(rec.get()) != 2 {
+		t.Errorf("got a notification after the cancellation")
+	}
+}
+
+func TestGroupReporterTimer(t *testing.T) {
+	rec := &notificationRecorder{}
+	server := rec.serve(t)
+	defer server.Close()
+
+	reporter := NewGroupReporter("/sub-test", server.URL, 1)
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck2@example.com"}))
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(rec.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	notifications := rec.get()
+	if len(notifications) != 1 || len(notifications[0].MonitoringEventReports) != 2 {
+		t.Fatalf("got notifications %+v, wanted one with 2 reports", notifications)
+	}
+
+	reporter.Report(memberReport(GroupMember{ExternalId: "truck1@example.com"}))
+	reporter.Stop()
+	time.Sleep(1200 * time.Millisecond)
+	if len(rec.get()) != 1 {
+		t.Errorf("pending reports should be dropped on stop")
+	}
+}
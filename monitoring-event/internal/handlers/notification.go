@@ -23,12 +23,15 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
 )
 
-type callbackFun func(subscriptionLocation string, userId string, notificationUri string, patch *models.UeInfoPatch) error
+type callbackFun func(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error)
+
+type pollFun func() (*models.MonitoringEventReport, error)
 
 type NotificationHandler struct {
 	active               bool
@@ -39,6 +42,12 @@ type NotificationHandler struct {
 	callback             callbackFun
 	cancelFunc           context.CancelFunc
 	subscriptionLocation string
+	remainingReports     int32 /*reports left before the subscription ends, 0 when unlimited*/
+	onReport             func(remainingReports int32)
+	groupReporter        *GroupReporter /*set for the members of a group subscription*/
+	member               GroupMember
+	period               time.Duration /*reporting period of the handlers polling for their reports*/
+	poll                 pollFun
 }
 
 func NewNotificationHandler(subscriptionLocation string, identiy string, notificationUri string, sub *redis.PubSub) *NotificationHandler {
@@ -55,6 +64,11 @@ func NewNotificationHandler(subscriptionLocation string, identiy string, notific
 }
 
 func (notifHandler *NotificationHandler) Start() bool {
+	if notifHandler.poll != nil {
+		notifHandler.ctx, notifHandler.cancelFunc = context.WithCancel(context.Background())
+		go notifHandler.runPeriodic()
+		return true
+	}
 	if notifHandler.callback == nil {
 		log.Printf("NotificationHandler: callback is not set")
 		return false
@@ -84,11 +98,20 @@ func (notifHandler *NotificationHandler) Start() bool {
 					log.Printf("error in update: %s ", err.Error())
 					continue
 				}
-				err := notifHandler.callback(notifHandler.subscriptionLocation, notifHandler.userId, notifHandler.notificationUri, patch)
+				report, err := notifHandler.callback(notifHandler.subscriptionLocation, notifHandler.userId, patch)
 				if err != nil {
 					log.Printf("callback returned: %s", err.Error())
 					continue
 				}
+				if report == nil {
+					continue
+				}
+				if notifHandler.groupReporter != nil {
+					notifHandler.member.Stamp(report)
+					notifHandler.groupReporter.Report(report)
+					continue
+				}
+				notifHandler.notify(report)
 
 			case <-notifHandler.ctx.Done():
 				return
@@ -99,6 +122,49 @@ func (notifHandler *NotificationHandler) Start() bool {
 	return true
 }
 
+// runPeriodic notifies the report polled at each reporting period
+func (notifHandler *NotificationHandler) runPeriodic() {
+	ticker := time.NewTicker(notifHandler.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			report, err := notifHandler.poll()
+			if err != nil {
+				log.Printf("periodic report failed: %s", err.Error())
+				continue
+			}
+			notifHandler.notify(report)
+
+		case <-notifHandler.ctx.Done():
+			return
+		}
+	}
+}
+
+// notify sends the report to the AF and counts it down from the maximum number
+// of reports, the last report requests the AF to cancel the subscription.
+func (notifHandler *NotificationHandler) notify(report *models.MonitoringEventReport) {
+	monitoringEvent := models.MonitoringNotification{
+		Subscription:           notifHandler.subscriptionLocation,
+		MonitoringEventReports: []models.MonitoringEventReport{*report},
+	}
+	if notifHandler.remainingReports == 1 {
+		monitoringEvent.CancelInd = true
+	}
+	if err := sendNotification(notifHandler.notificationUri, monitoringEvent); err != nil {
+		log.Printf("notification failed: %s", err.Error())
+	}
+
+	if notifHandler.remainingReports > 0 {
+		notifHandler.remainingReports--
+		if notifHandler.onReport != nil {
+			notifHandler.onReport(notifHandler.remainingReports)
+		}
+	}
+}
+
 func (notifHandler *NotificationHandler) Stop() bool {
 	if notifHandler.cancelFunc != nil {
 		notifHandler.cancelFunc()
@@ -116,3 +182,25 @@ func (notifHandler *NotificationHandler) SetEventCallback(callback callbackFun)
 	notifHandler.callback = callback
 	log.Printf("NotificationHandler: callback set for %s", notifHandler.userId)
 }
+
+// SetReportLimit bounds the number of reports sent by the handler, onReport is
+// called after each report with the number of reports left, 0 meaning the
+// subscription is exhausted.
+func (notifHandler *NotificationHandler) SetReportLimit(remainingReports int32, onReport func(remainingReports int32)) {
+	notifHandler.remainingReports = remainingReports
+	notifHandler.onReport = onReport
+}
+
+// SetGroupReporter hands the reports of a group member over to the reporter of
+// the group, which notifies the AF in place of the handler
+func (notifHandler *NotificationHandler) SetGroupReporter(reporter *GroupReporter, member GroupMember) {
+	notifHandler.groupReporter = reporter
+	notifHandler.member = member
+}
+
+// SetPeriodicReport makes the handler report every period with the report
+// returned by poll, in place of relaying core network events
+func (notifHandler *NotificationHandler) SetPeriodicReport(period time.Duration, poll pollFun) {
+	notifHandler.period = period
+	notifHandler.poll = poll
+}
@@ -0,0 +1,65 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func TestNotifyReportLimit(t *testing.T) {
+	notifications := []models.MonitoringNotification{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notification := models.MonitoringNotification{}
+		if err := json.NewDecoder(r.Body).Decode(&notification); err != nil {
+			t.Errorf("malformed notification: %v", err)
+		}
+		notifications = append(notifications, notification)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	notifHandler := NewNotificationHandler("/sub-test", "ext-test", server.URL, nil)
+	counts := []int32{}
+	notifHandler.SetReportLimit(2, func(remainingReports int32) {
+		counts = append(counts, remainingReports)
+	})
+
+	report := &models.MonitoringEventReport{MonitoringType: models.MonitoringTypeLocationReporting}
+	notifHandler.notify(report)
+	notifHandler.notify(report)
+
+	if len(notifications) != 2 {
+		t.Fatalf("got %d notifications, wanted 2", len(notifications))
+	}
+	if notifications[0].CancelInd || !notifications[1].CancelInd {
+		t.Errorf("only the last notification should carry cancelInd, got %v %v", notifications[0].CancelInd, notifications[1].CancelInd)
+	}
+	if notifications[1].Subscription != "/sub-test" || len(notifications[1].MonitoringEventReports) != 1 {
+		t.Errorf("unexpected notification %+v", notifications[1])
+	}
+	if len(counts) != 2 || counts[0] != 1 || counts[1] != 0 {
+		t.Errorf("got remaining reports %v, wanted [1 0]", counts)
+	}
+}
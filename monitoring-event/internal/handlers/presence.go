@@ -0,0 +1,182 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/area"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// PresenceState is the presence of a UE in the area of interest of a
+// subscription
+type PresenceState string
+
+const (
+	PresenceInArea    PresenceState = "IN_AREA"
+	PresenceOutOfArea PresenceState = "OUT_OF_AREA"
+	PresenceUnknown   PresenceState = "UNKNOWN"
+)
+
+// PositionFun gives the geographic position of a UE location, nil when unknown
+type PositionFun func(loc *models.Location) *models.GeographicArea
+
+// Presence is the presence of a UE in an area of interest, with the location
+// it was derived from when there is one
+type Presence struct {
+	State     PresenceState
+	Cause     models.LocationFailureCause
+	Location  *models.Location
+	TimeStamp int64
+}
+
+// presenceTracker keeps the last registration and location reports of a UE
+// to evaluate its presence in an area of interest
+type presenceTracker struct {
+	area         *area.Area
+	position     PositionFun
+	deregistered bool
+	regTime      int64
+	location     *models.Location
+}
+
+func newPresenceTracker(data *models.MonitoringEventSubscription, ue *models.UeInfo, position PositionFun) *presenceTracker {
+	tracker := &presenceTracker{
+		area:     area.New(&data.LocationArea, &data.LocationArea5G),
+		position: position,
+	}
+	if ue == nil {
+		return tracker
+	}
+	if ue.RegistrationInfo != nil {
+		tracker.setRegistration(ue.RegistrationInfo)
+	}
+	if !tracker.deregistered {
+		tracker.location = ue.Location
+	}
+	return tracker
+}
+
+// UePresence evaluates the presence of a UE from the UE info stored by the
+// core network service, ue may be nil when nothing is stored yet
+func UePresence(data *models.MonitoringEventSubscription, ue *models.UeInfo, position PositionFun) *Presence {
+	return newPresenceTracker(data, ue, position).presence()
+}
+
+// presence returns the presence given by the last location report, stamped
+// with its time. A deregistered UE, or one that could not be located against
+// the whole area, is in an unknown presence state.
+func (tracker *presenceTracker) presence() *Presence {
+	if tracker.deregistered {
+		return &Presence{State: PresenceUnknown, Cause: models.LocationFailureCauseNotRegisteredUe, TimeStamp: tracker.regTime}
+	}
+	loc := tracker.location
+	if loc == nil {
+		return &Presence{State: PresenceUnknown, Cause: models.LocationFailureCauseUnspecified, TimeStamp: tracker.regTime}
+	}
+
+	presence := &Presence{State: PresenceOutOfArea, Location: loc, TimeStamp: loc.TimeStamp}
+	in, known := tracker.area.Contains(&loc.UserLocation, tracker.position(loc))
+	switch {
+	case in:
+		presence.State = PresenceInArea
+	case !known:
+		presence.State = PresenceUnknown
+		presence.Cause = models.LocationFailureCauseUnspecified
+	}
+	return presence
+}
+
+// update applies a registration or location report, other reports are ignored
+func (tracker *presenceTracker) update(patch *models.UeInfoPatch) error {
+	switch models.CoreNetworkEvent(patch.Type) {
+	case models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT:
+		regInfo := models.RegInfo{}
+		if err := decodePatch(patch, &regInfo); err != nil {
+			return err
+		}
+		tracker.setRegistration(&regInfo)
+	case models.CORENETWORKEVENT_LOCATION_REPORT:
+		loc := models.Location{}
+		if err := decodePatch(patch, &loc); err != nil {
+			return err
+		}
+		tracker.location = &loc
+	}
+	return nil
+}
+
+func (tracker *presenceTracker) setRegistration(regInfo *models.RegInfo) {
+	tracker.regTime = regInfo.TimeStamp
+	tracker.deregistered = regInfo.RmInfo.RmState == models.RmStateDeregistered
+	if tracker.deregistered {
+		/*the UE is located again once registered*/
+		tracker.location = nil
+	}
+}
+
+// PresenceReport builds the AREA_OF_INTEREST report of a UE. uavPresInd tells
+// whether the UE is in the area, it is left out with a locFailureCause when
+// the presence is unknown.
+func PresenceReport(externalId string, presence *Presence) *models.MonitoringEventReport {
+	report := &models.MonitoringEventReport{
+		ExternalId:     &externalId,
+		MonitoringType: models.MonitoringTypeAreaOfInterest,
+		EventTime:      eventTime(presence.TimeStamp),
+	}
+	if presence.State == PresenceUnknown {
+		cause := presence.Cause
+		report.LocFailureCause = &cause
+	} else {
+		inArea := presence.State == PresenceInArea
+		report.UavPresInd = &inArea
+	}
+	if loc := presence.Location; loc != nil {
+		report.LocationInfo = &models.LocationInfo{
+			UserLocation:   &loc.UserLocation,
+			GeographicArea: loc.GeographicArea,
+		}
+		if nrLoc := loc.UserLocation.NrLocation; nrLoc != nil {
+			report.LocationInfo.CellId = nrLoc.Ncgi.NrCellId
+		} else if eutraLoc := loc.UserLocation.EutraLocation; eutraLoc != nil {
+			report.LocationInfo.CellId = eutraLoc.Ecgi.EutraCellId
+		}
+	}
+	return report
+}
+
+// NewPresenceCallback returns the event callback of an AREA_OF_INTEREST
+// subscription, fed with the registration and location reports of the UE. A report is produced only when the UE enters or leaves the area,
+// or when its presence becomes unknown.
+func NewPresenceCallback(data models.MonitoringEventSubscription, ue *models.UeInfo, position PositionFun) callbackFun {
+	tracker := newPresenceTracker(&data, ue, position)
+	state := tracker.presence().State
+
+	return func(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
+		if err := tracker.update(patch); err != nil {
+			return nil, err
+		}
+		presence := tracker.presence()
+		if presence.State == state {
+			return nil, nil
+		}
+		state = presence.State
+		return PresenceReport(data.ExternalId, presence), nil
+	}
+}
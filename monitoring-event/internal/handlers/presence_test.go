@@ -0,0 +1,121 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func locationPatch(tac string, cellId string, timeStamp int64) *models.UeInfoPatch {
+	plmnId := map[string]interface{}{"mcc": "001", "mnc": "01"}
+	return &models.UeInfoPatch{
+		Type: string(models.CORENETWORKEVENT_LOCATION_REPORT),
+		Data: map[string]interface{}{
+			"UserLocation": map[string]interface{}{"nrLocation": map[string]interface{}{
+				"tai":  map[string]interface{}{"plmnId": plmnId, "tac": tac},
+				"ncgi": map[string]interface{}{"plmnId": plmnId, "nrCellId": cellId},
+			}},
+			"TimeStamp": timeStamp,
+		},
+	}
+}
+
+func noPosition(loc *models.Location) *models.GeographicArea {
+	return nil
+}
+
+func TestPresenceCallback(t *testing.T) {
+	data := models.MonitoringEventSubscription{
+		ExternalId:     "ue@nef",
+		MonitoringType: models.MonitoringTypeAreaOfInterest,
+		LocationArea:   models.LocationArea{TrackingAreaIds: []string{"001010001"}},
+	}
+
+	tests := []struct {
+		name          string
+		patches       []*models.UeInfoPatch
+		wantedReports []PresenceState
+	}{
+		{
+			name:          "enter and leave",
+			patches:       []*models.UeInfoPatch{locationPatch("0002", "000000001", 100), locationPatch("0001", "000000002", 101), locationPatch("0001", "000000003", 102), locationPatch("0002", "000000001", 103)},
+			wantedReports: []PresenceState{PresenceOutOfArea, PresenceInArea, "", PresenceOutOfArea},
+		},
+		{
+			name:          "unknown once deregistered",
+			patches:       []*models.UeInfoPatch{locationPatch("0001", "000000001", 100), registrationPatch(models.RmStateDeregistered, 200), registrationPatch(models.RmStateRegistered, 300), locationPatch("0001", "000000001", 301)},
+			wantedReports: []PresenceState{PresenceInArea, PresenceUnknown, "", PresenceInArea},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback := NewPresenceCallback(data, nil, noPosition)
+			for i, patch := range tt.patches {
+				report, err := callback("loc", "imsi", patch)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				var got PresenceState
+				switch {
+				case report == nil:
+				case report.LocFailureCause != nil:
+					got = PresenceUnknown
+				case report.UavPresInd != nil && *report.UavPresInd:
+					got = PresenceInArea
+				case report.UavPresInd != nil:
+					got = PresenceOutOfArea
+				}
+				if got != tt.wantedReports[i] {
+					t.Errorf("patch %d: got report %q, wanted %q", i, got, tt.wantedReports[i])
+				}
+				if report != nil && report.LocationInfo != nil && !report.EventTime.Equal(eventTime(tt.patches[i].Data["TimeStamp"].(int64))) {
+					t.Errorf("patch %d: got event time %v, wanted the one of the location report", i, report.EventTime)
+				}
+			}
+		})
+	}
+}
+
+func TestUePresence(t *testing.T) {
+	data := &models.MonitoringEventSubscription{
+		LocationArea: models.LocationArea{GeographicAreas: []models.GeographicArea{{
+			Shape:       models.SupportedGadShapesPOINT_UNCERTAINTY_CIRCLE,
+			Point:       models.GeographicalCoordinates{Lat: 48.8566, Lon: 2.3522},
+			Uncertainty: 1000,
+		}}},
+	}
+	ue := &models.UeInfo{Location: &models.Location{TimeStamp: 100}}
+
+	if presence := UePresence(data, ue, noPosition); presence.State != PresenceUnknown {
+		t.Errorf("got %s without position, wanted %s", presence.State, PresenceUnknown)
+	}
+	position := func(loc *models.Location) *models.GeographicArea {
+		return &models.GeographicArea{Shape: models.SupportedGadShapesPOINT, Point: models.GeographicalCoordinates{Lat: 48.86, Lon: 2.35}}
+	}
+	if presence := UePresence(data, ue, position); presence.State != PresenceInArea {
+		t.Errorf("got %s, wanted %s", presence.State, PresenceInArea)
+	}
+	if presence := UePresence(data, nil, position); presence.State != PresenceUnknown {
+		t.Errorf("got %s without stored location, wanted %s", presence.State, PresenceUnknown)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// UeState is the registration and connectivity state of a UE, as last
+// reported by the AMF
+type UeState struct {
+	RmState   models.RmState
+	CmState   models.CmState
+	TimeStamp int64
+}
+
+// NewUeState seeds the state from the UE info stored by the core network
+// service, ue may be nil when nothing is stored yet
+func NewUeState(ue *models.UeInfo) *UeState {
+	state := &UeState{}
+	if ue == nil {
+		return state
+	}
+	if ue.RegistrationInfo != nil {
+		state.RmState = ue.RegistrationInfo.RmInfo.RmState
+		state.TimeStamp = ue.RegistrationInfo.TimeStamp
+	}
+	if ue.ConnectivityInfo != nil {
+		state.CmState = ue.ConnectivityInfo.CmInfo.CmState
+		if ue.ConnectivityInfo.TimeStamp > state.TimeStamp {
+			state.TimeStamp = ue.ConnectivityInfo.TimeStamp
+		}
+	}
+	return state
+}
+
+// Update applies a registration or connectivity state report, other reports
+// are ignored and leave the state untouched
+func (state *UeState) Update(patch *models.UeInfoPatch) error {
+	switch models.CoreNetworkEvent(patch.Type) {
+	case models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT:
+		regInfo := models.RegInfo{}
+		if err := decodePatch(patch, &regInfo); err != nil {
+			return err
+		}
+		state.RmState = regInfo.RmInfo.RmState
+		state.TimeStamp = regInfo.TimeStamp
+		if state.RmState == models.RmStateDeregistered {
+			/*a deregistered UE has no NAS signalling connection*/
+			state.CmState = models.CmStateIdle
+		}
+	case models.CORENETWORKEVENT_CONNECTIVITY_STATE_REPORT:
+		connInfo := models.ConnInfo{}
+		if err := decodePatch(patch, &connInfo); err != nil {
+			return err
+		}
+		state.CmState = connInfo.CmInfo.CmState
+		state.TimeStamp = connInfo.TimeStamp
+	}
+	return nil
+}
+
+// Reachable tells whether the UE can be reached for the reachability type. A
+// registered UE is reachable for SMS, which are delivered over NAS after
+// paging. It is reachable for downlink data when connected, or when idle if
+// the maximumLatency of the AF leaves the pagingLatency seconds to page it.
+func (state *UeState) Reachable(reachabilityType models.ReachabilityType, maximumLatency int32, pagingLatency int32) bool {
+	if state.RmState != models.RmStateRegistered {
+		return false
+	}
+	if reachabilityType == models.ReachabilityTypeSms {
+		return true
+	}
+	return state.CmState == models.CmStateConnected || (maximumLatency > 0 && maximumLatency >= pagingLatency)
+}
+
+// ReachabilityReport builds the UE_REACHABILITY report of a UE that became
+// reachable, the UE is announced available for maximumResponseTime seconds
+func ReachabilityReport(data *models.MonitoringEventSubscription, state *UeState) *models.MonitoringEventReport {
+	externalId := data.ExternalId
+	reachabilityType := data.ReachabilityType
+	report := &models.MonitoringEventReport{
+		ExternalId:       &externalId,
+		MonitoringType:   models.MonitoringTypeUeReachability,
+		ReachabilityType: &reachabilityType,
+		EventTime:        eventTime(state.TimeStamp),
+	}
+	if data.MaximumResponseTime > 0 {
+		availableUntil := report.EventTime.Add(time.Duration(data.MaximumResponseTime) * time.Second)
+		report.MaxUEAvailabilityTime = &availableUntil
+	}
+	return report
+}
+
+// NewReachabilityCallback returns the event callback of a UE_REACHABILITY
+// subscription, fed with the registration and connectivity state reports of
+// the UE. A report is produced each time the UE becomes reachable.
+func NewReachabilityCallback(data models.MonitoringEventSubscription, ue *models.UeInfo, pagingLatency int32) callbackFun {
+	state := NewUeState(ue)
+	reachable := state.Reachable(data.ReachabilityType, data.MaximumLatency, pagingLatency)
+
+	return func(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
+		if err := state.Update(patch); err != nil {
+			return nil, err
+		}
+		wasReachable := reachable
+		reachable = state.Reachable(data.ReachabilityType, data.MaximumLatency, pagingLatency)
+		if !reachable || wasReachable {
+			return nil, nil
+		}
+		return ReachabilityReport(&data, state), nil
+	}
+}
+
+// LostConnectivity returns the reason of the current loss of connectivity of
+// the UE, nil when the UE is connected or has registered again since
+func LostConnectivity(ue *models.UeInfo) *models.LossOfConnectReason {
+	if ue == nil {
+		return nil
+	}
+	reg := ue.RegistrationInfo
+	if ue.LossOfConnectivity != nil && (reg == nil || ue.LossOfConnectivity.TimeStamp >= reg.TimeStamp) {
+		return ue.LossOfConnectivity
+	}
+	if reg != nil && reg.RmInfo.RmState == models.RmStateDeregistered {
+		return &models.LossOfConnectReason{
+			LossOfConnectReason: models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED,
+			TimeStamp:           reg.TimeStamp,
+		}
+	}
+	return nil
+}
+
+// LossOfConnectivityReport builds the LOSS_OF_CONNECTIVITY report of a UE
+func LossOfConnectivityReport(externalId string, loss *models.LossOfConnectReason) *models.MonitoringEventReport {
+	reason := string(loss.LossOfConnectReason)
+	return &models.MonitoringEventReport{
+		ExternalId:          &externalId,
+		MonitoringType:      models.MonitoringTypeLossOfConnectivity,
+		LossOfConnectReason: &reason,
+		EventTime:           eventTime(loss.TimeStamp),
+	}
+}
+
+// NewLossOfConnectivityCallback returns the event callback of a
+// LOSS_OF_CONNECTIVITY subscription. The AMF loss of connectivity reports and
+// the deregistrations are reported once, until the UE registers or connects
+// again.
+func NewLossOfConnectivityCallback(data models.MonitoringEventSubscription, ue *models.UeInfo) callbackFun {
+	lost := LostConnectivity(ue) != nil
+
+	return func(subscriptionLocation string, userId string, patch *models.UeInfoPatch) (*models.MonitoringEventReport, error) {
+		var loss *models.LossOfConnectReason
+
+		switch models.CoreNetworkEvent(patch.Type) {
+		case models.CORENETWORKEVENT_LOSS_OF_CONNECTIVITY:
+			loss = &models.LossOfConnectReason{}
+			if err := decodePatch(patch, loss); err != nil {
+				return nil, err
+			}
+		case models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT:
+			regInfo := models.RegInfo{}
+			if err := decodePatch(patch, &regInfo); err != nil {
+				return nil, err
+			}
+			if regInfo.RmInfo.RmState == models.RmStateRegistered {
+				lost = false
+				return nil, nil
+			}
+			loss = &models.LossOfConnectReason{
+				LossOfConnectReason: models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED,
+				TimeStamp:           regInfo.TimeStamp,
+			}
+		case models.CORENETWORKEVENT_CONNECTIVITY_STATE_REPORT:
+			connInfo := models.ConnInfo{}
+			if err := decodePatch(patch, &connInfo); err != nil {
+				return nil, err
+			}
+			if connInfo.CmInfo.CmState == models.CmStateConnected {
+				lost = false
+			}
+			return nil, nil
+		default:
+			return nil, nil
+		}
+
+		if lost {
+			return nil, nil
+		}
+		lost = true
+		return LossOfConnectivityReport(data.ExternalId, loss), nil
+	}
+}
+
+func decodePatch(patch *models.UeInfoPatch, v any) error {
+	/* marshall interface into json */
+	jsonData, err := json.Marshal(patch.Data)
+	if err != nil {
+		return fmt.Errorf("malformed core network event data: %s", err.Error())
+	}
+	if err := json.Unmarshal(jsonData, v); err != nil {
+		return fmt.Errorf("malformed core network event data: %s", err.Error())
+	}
+	return nil
+}
+
+func eventTime(timeStamp int64) time.Time {
+	if timeStamp == 0 {
+		return time.Now()
+	}
+	return time.Unix(timeStamp, 0)
+}
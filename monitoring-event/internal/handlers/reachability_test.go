@@ -0,0 +1,168 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package handlers
+
+import (
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+func registrationPatch(rmState models.RmState, timeStamp int64) *models.UeInfoPatch {
+	return &models.UeInfoPatch{
+		Type: string(models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT),
+		Data: map[string]interface{}{"RmInfo": map[string]interface{}{"rmState": rmState, "accessType": "3GPP_ACCESS"}, "TimeStamp": timeStamp},
+	}
+}
+
+func connectivityPatch(cmState models.CmState, timeStamp int64) *models.UeInfoPatch {
+	return &models.UeInfoPatch{
+		Type: string(models.CORENETWORKEVENT_CONNECTIVITY_STATE_REPORT),
+		Data: map[string]interface{}{"CmInfo": map[string]interface{}{"cmState": cmState, "accessType": "3GPP_ACCESS"}, "TimeStamp": timeStamp},
+	}
+}
+
+func TestReachabilityCallback(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           models.MonitoringEventSubscription
+		patches        []*models.UeInfoPatch
+		wantedReports  []bool
+		wantedAvailMax int64
+	}{
+		{
+			name:          "sms on registration",
+			data:          models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeSms},
+			patches:       []*models.UeInfoPatch{registrationPatch(models.RmStateRegistered, 100), connectivityPatch(models.CmStateConnected, 101)},
+			wantedReports: []bool{true, false},
+		},
+		{
+			name:          "data on connection",
+			data:          models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeData},
+			patches:       []*models.UeInfoPatch{registrationPatch(models.RmStateRegistered, 100), connectivityPatch(models.CmStateIdle, 101), connectivityPatch(models.CmStateConnected, 102), connectivityPatch(models.CmStateConnected, 103)},
+			wantedReports: []bool{false, false, true, false},
+		},
+		{
+			name:          "data with latency when idle",
+			data:          models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeData, MaximumLatency: 60},
+			patches:       []*models.UeInfoPatch{registrationPatch(models.RmStateRegistered, 100), connectivityPatch(models.CmStateConnected, 101)},
+			wantedReports: []bool{true, false},
+		},
+		{
+			name:          "data with latency below the paging latency",
+			data:          models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeData, MaximumLatency: 2},
+			patches:       []*models.UeInfoPatch{registrationPatch(models.RmStateRegistered, 100), connectivityPatch(models.CmStateConnected, 101)},
+			wantedReports: []bool{false, true},
+		},
+		{
+			name:           "reachable again after deregistration",
+			data:           models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeSms, MaximumResponseTime: 30},
+			patches:        []*models.UeInfoPatch{registrationPatch(models.RmStateRegistered, 100), registrationPatch(models.RmStateDeregistered, 200), registrationPatch(models.RmStateRegistered, 300)},
+			wantedReports:  []bool{true, false, true},
+			wantedAvailMax: 330,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callback := NewReachabilityCallback(tt.data, nil, 3)
+			var last *models.MonitoringEventReport
+			for i, patch := range tt.patches {
+				report, err := callback("/sub-test", "ext-test", patch)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if (report != nil) != tt.wantedReports[i] {
+					t.Fatalf("patch %d: got report %+v, wanted %v", i, report, tt.wantedReports[i])
+				}
+				if report != nil {
+					last = report
+				}
+			}
+			if last == nil || last.ReachabilityType == nil || *last.ReachabilityType != tt.data.ReachabilityType {
+				t.Errorf("unexpected report %+v", last)
+			}
+			if tt.wantedAvailMax > 0 && (last.MaxUEAvailabilityTime == nil || last.MaxUEAvailabilityTime.Unix() != tt.wantedAvailMax) {
+				t.Errorf("got maxUEAvailabilityTime %v, wanted %d", last.MaxUEAvailabilityTime, tt.wantedAvailMax)
+			}
+		})
+	}
+}
+
+func TestReachabilityCallbackSeededFromStoredState(t *testing.T) {
+	ue := &models.UeInfo{
+		RegistrationInfo: &models.RegInfo{RmInfo: models.RmInfo{RmState: models.RmStateRegistered}, TimeStamp: 100},
+		ConnectivityInfo: &models.ConnInfo{CmInfo: models.CmInfo{CmState: models.CmStateConnected}, TimeStamp: 101},
+	}
+	callback := NewReachabilityCallback(models.MonitoringEventSubscription{ReachabilityType: models.ReachabilityTypeData}, ue, 3)
+
+	if report, _ := callback("/sub-test", "ext-test", connectivityPatch(models.CmStateConnected, 102)); report != nil {
+		t.Errorf("a reachable UE should not be reported again, got %+v", report)
+	}
+	if report, _ := callback("/sub-test", "ext-test", connectivityPatch(models.CmStateIdle, 103)); report != nil {
+		t.Errorf("an unreachable UE should not be reported, got %+v", report)
+	}
+	if report, _ := callback("/sub-test", "ext-test", connectivityPatch(models.CmStateConnected, 104)); report == nil {
+		t.Errorf("the UE should be reported reachable again")
+	}
+}
+
+func TestLossOfConnectivityCallback(t *testing.T) {
+	callback := NewLossOfConnectivityCallback(models.MonitoringEventSubscription{ExternalId: "ext-test"}, nil)
+
+	lossPatch := &models.UeInfoPatch{
+		Type: string(models.CORENETWORKEVENT_LOSS_OF_CONNECTIVITY),
+		Data: map[string]interface{}{"LossOfConnectReason": "MAX_DETECTION_TIME_EXPIRED", "TimeStamp": 100},
+	}
+	report, err := callback("/sub-test", "ext-test", lossPatch)
+	if err != nil || report == nil {
+		t.Fatalf("got report %+v and error %v, wanted a report", report, err)
+	}
+	if report.LossOfConnectReason == nil || *report.LossOfConnectReason != "MAX_DETECTION_TIME_EXPIRED" || *report.ExternalId != "ext-test" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if report, _ := callback("/sub-test", "ext-test", registrationPatch(models.RmStateDeregistered, 101)); report != nil {
+		t.Errorf("the loss of connectivity should be reported once, got %+v", report)
+	}
+	if report, _ := callback("/sub-test", "ext-test", registrationPatch(models.RmStateRegistered, 102)); report != nil {
+		t.Errorf("a registration should not be reported, got %+v", report)
+	}
+	report, _ = callback("/sub-test", "ext-test", registrationPatch(models.RmStateDeregistered, 103))
+	if report == nil || *report.LossOfConnectReason != string(models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED) {
+		t.Errorf("got report %+v, wanted a deregistration", report)
+	}
+}
+
+func TestLostConnectivity(t *testing.T) {
+	loss := &models.LossOfConnectReason{LossOfConnectReason: models.LOSSOFCONNECTIVITYREASONANYOF_PURGED, TimeStamp: 100}
+
+	if LostConnectivity(&models.UeInfo{LossOfConnectivity: loss}) != loss {
+		t.Errorf("the stored loss of connectivity should be returned")
+	}
+	registered := &models.RegInfo{RmInfo: models.RmInfo{RmState: models.RmStateRegistered}, TimeStamp: 200}
+	if got := LostConnectivity(&models.UeInfo{LossOfConnectivity: loss, RegistrationInfo: registered}); got != nil {
+		t.Errorf("a UE registered since should not be lost, got %+v", got)
+	}
+	deregistered := &models.RegInfo{RmInfo: models.RmInfo{RmState: models.RmStateDeregistered}, TimeStamp: 200}
+	if got := LostConnectivity(&models.UeInfo{RegistrationInfo: deregistered}); got == nil || got.LossOfConnectReason != models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED {
+		t.Errorf("got %+v, wanted a deregistration", got)
+	}
+}
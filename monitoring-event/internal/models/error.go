@@ -65,6 +65,15 @@ func (e *RequiredError) Error() string {
 	return fmt.Sprintf("required field '%s' is zero value.", e.Field)
 }
 
+// ProblemError carries the ProblemDetails returned to the client, e.g. to list the invalid parameters of a request
+type ProblemError struct {
+	Problem ProblemDetails
+}
+
+func (e *ProblemError) Error() string {
+	return e.Problem.Detail
+}
+
 // ErrorHandler defines the required method for handling error. You may implement it and inject this into a controller if
 // you would like errors to be handled differently from the DefaultErrorHandler
 type ErrorHandler func(w http.ResponseWriter, r *http.Request, err error, result *ImplResponse)
@@ -86,6 +95,14 @@ func DefaultErrorHandler(w http.ResponseWriter, _ *http.Request, err error, resu
 		return
 	}
 
+	var problemErr *ProblemError
+	if ok := errors.As(err, &problemErr); ok {
+		// Handle errors detailed with a ProblemDetails
+		problemErr.Problem.Status = int32(result.Code)
+		_ = EncodeJSONResponse(problemErr.Problem, &result.Code, w)
+		return
+	}
+
 	// Handle all other errors
 	_ = EncodeJSONResponse(err.Error(), &result.Code, w)
 }
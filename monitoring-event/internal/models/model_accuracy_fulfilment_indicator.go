@@ -28,8 +28,12 @@
 package models
 
 // AccuracyFulfilmentIndicator - Indicates fulfilment of requested accuracy.
-type AccuracyFulfilmentIndicator struct {
-}
+type AccuracyFulfilmentIndicator string
+
+const (
+	AccuracyFulfilmentIndicatorRequestedAccuracyFulfilled    AccuracyFulfilmentIndicator = "REQUESTED_ACCURACY_FULFILLED"
+	AccuracyFulfilmentIndicatorRequestedAccuracyNotFulfilled AccuracyFulfilmentIndicator = "REQUESTED_ACCURACY_NOT_FULFILLED"
+)
 
 // AssertAccuracyFulfilmentIndicatorRequired checks if the required fields are not zero-ed
 func AssertAccuracyFulfilmentIndicatorRequired(obj AccuracyFulfilmentIndicator) error {
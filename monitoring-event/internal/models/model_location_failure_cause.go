@@ -28,8 +28,15 @@
 package models
 
 // LocationFailureCause - Represents the cause of location positioning failure.   Possible values are: - POSITIONING_DENIED: Positioning is denied. - UNSUPPORTED_BY_UE: Positioning is not supported by UE. - NOT_REGISTED_UE: UE is not registered. - UNSPECIFIED: Unspecified. - REQUESTED_AREA_NOT_ALLOWED: The location request is rejected because the location area   requested by the AF for area event reporting is not allowed.
-type LocationFailureCause struct {
-}
+type LocationFailureCause string
+
+const (
+	LocationFailureCausePositioningDenied       LocationFailureCause = "POSITIONING_DENIED"
+	LocationFailureCauseUnsupportedByUe         LocationFailureCause = "UNSUPPORTED_BY_UE"
+	LocationFailureCauseNotRegisteredUe         LocationFailureCause = "NOT_REGISTED_UE"
+	LocationFailureCauseUnspecified             LocationFailureCause = "UNSPECIFIED"
+	LocationFailureCauseRequestedAreaNotAllowed LocationFailureCause = "REQUESTED_AREA_NOT_ALLOWED"
+)
 
 // AssertLocationFailureCauseRequired checks if the required fields are not zero-ed
 func AssertLocationFailureCauseRequired(obj LocationFailureCause) error {
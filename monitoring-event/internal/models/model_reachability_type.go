@@ -28,8 +28,12 @@
 package models
 
 // ReachabilityType - Represents a reachability type.   Possible values are - SMS: The SCS/AS requests to be notified when the UE becomes reachable for sending SMS   to the UE - DATA: The SCS/AS requests to be notified when the UE becomes reachable for sending   downlink data to the UE.
-type ReachabilityType struct {
-}
+type ReachabilityType string
+
+const (
+	ReachabilityTypeSms  ReachabilityType = "SMS"
+	ReachabilityTypeData ReachabilityType = "DATA"
+)
 
 // AssertReachabilityTypeRequired checks if the required fields are not zero-ed
 func AssertReachabilityTypeRequired(obj ReachabilityType) error {
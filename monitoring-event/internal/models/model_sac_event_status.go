@@ -29,29 +29,37 @@ package models
 
 // SacEventStatus - Contains the network slice status information in terms of the current number of UEs registered  with a network slice, the current number of PDU Sessions established on a network slice or both.
 type SacEventStatus struct {
-	ReachedNumUes SacInfo `json:"reachedNumUes,omitempty"`
+	ReachedNumUes *SacInfo `json:"reachedNumUes,omitempty"`
 
-	ReachedNumPduSess SacInfo `json:"reachedNumPduSess,omitempty"`
+	ReachedNumPduSess *SacInfo `json:"reachedNumPduSess,omitempty"`
 }
 
 // AssertSacEventStatusRequired checks if the required fields are not zero-ed
 func AssertSacEventStatusRequired(obj SacEventStatus) error {
-	if err := AssertSacInfoRequired(obj.ReachedNumUes); err != nil {
-		return err
+	if obj.ReachedNumUes != nil {
+		if err := AssertSacInfoRequired(*obj.ReachedNumUes); err != nil {
+			return err
+		}
 	}
-	if err := AssertSacInfoRequired(obj.ReachedNumPduSess); err != nil {
-		return err
+	if obj.ReachedNumPduSess != nil {
+		if err := AssertSacInfoRequired(*obj.ReachedNumPduSess); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 // AssertSacEventStatusConstraints checks if the values respects the defined constraints
 func AssertSacEventStatusConstraints(obj SacEventStatus) error {
-	if err := AssertSacInfoConstraints(obj.ReachedNumUes); err != nil {
-		return err
+	if obj.ReachedNumUes != nil {
+		if err := AssertSacInfoConstraints(*obj.ReachedNumUes); err != nil {
+			return err
+		}
 	}
-	if err := AssertSacInfoConstraints(obj.ReachedNumPduSess); err != nil {
-		return err
+	if obj.ReachedNumPduSess != nil {
+		if err := AssertSacInfoConstraints(*obj.ReachedNumPduSess); err != nil {
+			return err
+		}
 	}
 	return nil
 }
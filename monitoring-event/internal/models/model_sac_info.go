@@ -33,9 +33,9 @@ import (
 
 // SacInfo - Represents threshold(s) to control the triggering of network slice reporting notifications or the information contained in the network slice reporting notification.
 type SacInfo struct {
-	NumericValNumUes int32 `json:"numericValNumUes,omitempty"`
+	NumericValNumUes *int32 `json:"numericValNumUes,omitempty"`
 
-	NumericValNumPduSess int32 `json:"numericValNumPduSess,omitempty"`
+	NumericValNumPduSess *int32 `json:"numericValNumPduSess,omitempty"`
 
 	PercValueNumUes int32 `json:"percValueNumUes,omitempty"`
 
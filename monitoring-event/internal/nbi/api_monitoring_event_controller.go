@@ -81,13 +81,29 @@ func (s *MonitoringEventSubscriptionsAPIService) FetchAllMonitoringEventSubscrip
 func (s *MonitoringEventSubscriptionsAPIService) CreateMonitoringEventSubscription(ctx context.Context, scsAsId string, monitoringEventSubscription *models.MonitoringEventSubscription) (models.ImplResponse, error) {
 	/* Define a report object to pass to PostMonitoringEventSub method, it will be filled with the current status of the UE*/
 	immediateReport := &models.MonitoringEventReport{}
-
-	loc, code, err := s.Service().PostMonitoringEventSubscription(scsAsId, monitoringEventSubscription, immediateReport)
+	var body interface{} = immediateReport
+
+	var loc string
+	var code int
+	var err error
+	if len(monitoringEventSubscription.ExternalGroupId) > 0 && len(monitoringEventSubscription.ExternalId) == 0 && len(monitoringEventSubscription.Supi) == 0 {
+		/* group subscriptions report the current status of each member */
+		immediateReports := &models.MonitoringEventReports{}
+		body = immediateReports
+		loc, code, err = s.Service().PostGroupMonitoringEventSubscription(scsAsId, monitoringEventSubscription, immediateReports)
+	} else {
+		loc, code, err = s.Service().PostMonitoringEventSubscription(scsAsId, monitoringEventSubscription, immediateReport)
+	}
 	if err == nil {
 		log.Printf("CreateMonitoringEventSubscription: created subscription for %s at %s", scsAsId, loc)
-		return models.ResponseWithLocation(code, immediateReport, loc), nil
+		return models.ResponseWithLocation(code, body, loc), nil
 	} else {
 		log.Printf("CreateMonitoringEventSubscription: error creating subscription for %s: %s", scsAsId, err.Error())
+		var problemErr *models.ProblemError
+		if errors.As(err, &problemErr) {
+			/*encoded by the error handler*/
+			return models.Response(code, nil), err
+		}
 		return models.Response(code, models.ProblemDetails{
 			Title:  "Subscription Creation Error",
 			Detail: err.Error(),
@@ -20,13 +20,17 @@
 package nbi
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync"
 
 	"github.com/gorilla/mux"
 	libcapif "gitlab.eurecom.fr/open-exposure/nef/libcapif"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/invoker"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/ratelimit"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/nbi/service"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/pkg/config"
@@ -42,11 +46,17 @@ type NbiServer struct {
 	appCtx
 	router   *mux.Router
 	capifCtx *libcapif.CapifConnector
+	limiter  *ratelimit.Limiter
 	server   *http.Server
 }
 
 func NewNorthbound(app appCtx) (*NbiServer, error) {
 	nbi := &NbiServer{appCtx: app}
+	limiter, err := newRateLimiter(nbi.Cfg().Limits)
+	if err != nil {
+		return nil, err
+	}
+	nbi.limiter = limiter
 
 	MonitoringEventAPIService := NewMonitoringEventSubscriptionsAPIService(nbi)
 	MontioringEventIndividualAPIService := NewIndividualMonitoringEventSubscriptionAPIService(nbi)
@@ -56,6 +66,8 @@ func NewNorthbound(app appCtx) (*NbiServer, error) {
 
 	nbi.router = models.NewRouter(MonitoringEventAPIController, MontioringEventIndividualAPIController)
 
+	/*only the invoker of a token validated by CAPIF is trusted*/
+	nbi.router.Use(invoker.Strip)
 	if len(nbi.Cfg().CapifSvc) > 0 {
 		nbi.capifCtx = libcapif.NewConnector(nbi.Cfg().CapifSvc)
 		nbi.capifCtx.InstantiateConnector(nbi.AppName(), "v1", "HTTP_1_1")
@@ -73,10 +85,54 @@ func NewNorthbound(app appCtx) (*NbiServer, error) {
 		nbi.capifCtx.AddInterface(nbi.Cfg().Nbi.Fqdn, int32(nbi.Cfg().Nbi.Port))
 	}
 
+	/*after the CAPIF middleware, which sets the InvokerId of the request*/
+	nbi.router.Use(invoker.Bind)
+	nbi.router.Use(nbi.rateLimitMiddleware)
+
 	nbi.server = &http.Server{Addr: ":" + strconv.FormatUint(uint64(nbi.Cfg().Nbi.Port), 10), Handler: nbi.router}
 	return nbi, nil
 }
 
+// newRateLimiter builds the token buckets of the configured limits, refusing
+// the per-AF rates as the requests are only told apart by client
+func newRateLimiter(limits config.LimitsConfig) (*ratelimit.Limiter, error) {
+	afIds := make([]string, 0, len(limits.Afs))
+	for afId := range limits.Afs {
+		afIds = append(afIds, afId)
+	}
+	sort.Strings(afIds)
+	for _, afId := range afIds {
+		if af := limits.Afs[afId]; af.Rate > 0 || af.Burst > 0 {
+			return nil, fmt.Errorf("limits of af %s: the request rates are per client, set them in limits.clients", afId)
+		}
+	}
+
+	overrides := make(map[string]ratelimit.Limit)
+	for client := range limits.Clients {
+		limit := limits.ClientOf(client)
+		overrides[client] = ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return ratelimit.NewLimiter(ratelimit.Limit{Rate: limits.Rate, Burst: limits.Burst}, overrides), nil
+}
+
+// rateLimitMiddleware rejects the requests of the client above its rate with a 429
+// telling when to retry
+func (n *NbiServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := n.limiter.Allow(ratelimit.ClientOf(r, "")); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+			status := http.StatusTooManyRequests
+			_ = models.EncodeJSONResponse(models.ProblemDetails{
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "request rate of the client exceeded",
+			}, &status, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (n *NbiServer) startListening(wg *sync.WaitGroup) {
 	defer func() {
 		_ = recover()
@@ -0,0 +1,196 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/handlers"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// PostGroupMonitoringEventSubscription monitors the members of an external
+// group under one subscription, the immediate reports of the members being
+// returned in immediateReports
+func (s *Service) PostGroupMonitoringEventSubscription(afId string, data *models.MonitoringEventSubscription, immediateReports *models.MonitoringEventReports) (string, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		af = s.Ctx().AddAf(afId)
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	if err := s.quotaProblem(afId, len(af.GetAfSubscriptions())); err != nil {
+		return "", http.StatusTooManyRequests, err
+	}
+	if len(data.ExternalGroupId) == 0 {
+		return "", http.StatusBadRequest, fmt.Errorf("no externalGroupId provided")
+	}
+	if err := s.validateMonitoringEventSubscription(data); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	members, status, err := s.resolveGroupMembers(afId, data)
+	if err != nil {
+		return "", status, err
+	}
+	if len(members) == 0 {
+		return "", http.StatusNotFound, fmt.Errorf("group %s has no member", data.ExternalGroupId)
+	}
+
+	immediateReports.MonitoringEventReports = []models.MonitoringEventReport{}
+	for supi, member := range members {
+		userInfo, err := s.Connector().QueryUEInfo(supi)
+		if err != nil {
+			log.Printf("group %s member %s: no current state: %s", data.ExternalGroupId, supi, err)
+			continue
+		}
+		report, err := s.prepareImmediateReport(memberSubscriptionData(data, member), userInfo)
+		if err != nil {
+			return "", http.StatusInternalServerError, fmt.Errorf("failed to prepare immediate report: %w", err)
+		}
+		member.Stamp(&report)
+		immediateReports.MonitoringEventReports = append(immediateReports.MonitoringEventReports, report)
+	}
+
+	if data.MaximumNumberOfReports == 1 {
+		/* if only immediate reports are requested, then return and do not create the subscription context*/
+		return "", http.StatusOK, nil
+	}
+
+	sub := af.NewAfSubscription("", data)
+	if sub == nil {
+		return "", http.StatusBadRequest, fmt.Errorf("failed to validate monitoring event subscription")
+	}
+	sub.SetMembers(members)
+	if data.MaximumNumberOfReports > 1 {
+		/*the immediate reports are the first one*/
+		sub.SetRemainingReports(data.MaximumNumberOfReports - 1)
+	}
+	status, err = s.startNotificationHandler(afId, sub)
+	if err != nil {
+		_ = af.DeleteAfscription(data.Self)
+		return "", status, err
+	}
+	s.scheduleExpiry(afId, sub)
+	if err := af.SaveAfSubscription(sub); err != nil {
+		log.Printf("could not store subscription %s: %s", data.Self, err)
+	}
+
+	return sub.GetLocation(), http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// resolveGroupMembers expands the external groups of the subscription into the
+// SUPIs of their members, adding the addedExternalIds/addedMsisdns and removing
+// the excludedExternalIds/excludedMsisdns
+func (s *Service) resolveGroupMembers(afId string, data *models.MonitoringEventSubscription) (map[string]handlers.GroupMember, int, error) {
+	members := make(map[string]handlers.GroupMember)
+
+	for _, groupId := range append([]string{data.ExternalGroupId}, data.AddExtGroupId...) {
+		supis, err := s.Connector().GetGroupMembers(groupId)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("could not resolve externalGroupId %s", groupId)
+		}
+		for _, supi := range supis {
+			/*known by the group only, reported by an externalId issued to the AF,
+			 * never by its SUPI*/
+			externalId, err := s.Connector().ExternalIdOf(afId, supi)
+			if err != nil {
+				log.Printf("could not get the externalId of a member of %s: %s", groupId, err)
+				return nil, http.StatusInternalServerError, fmt.Errorf("could not resolve externalGroupId %s", groupId)
+			}
+			members[supi] = handlers.GroupMember{ExternalId: externalId}
+		}
+	}
+
+	for _, externalId := range data.AddedExternalIds {
+		supi, err := s.Connector().LookupExternalId(afId, externalId)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to lookup externalId %s: %w", externalId, err)
+		}
+		members[supi] = handlers.GroupMember{ExternalId: externalId}
+	}
+	for _, msisdn := range data.AddedMsisdns {
+		supi, err := s.Connector().LookupMsisdn(msisdn)
+		if err != nil {
+			return nil, http.StatusNotFound, fmt.Errorf("failed to lookup msisdn %s: %w", msisdn, err)
+		}
+		members[supi] = handlers.GroupMember{Msisdn: msisdn}
+	}
+
+	/* unknown excluded UEs are not members anyway */
+	for _, externalId := range data.ExcludedExternalIds {
+		if supi, err := s.Connector().LookupExternalId(afId, externalId); err == nil {
+			delete(members, supi)
+		}
+	}
+	for _, msisdn := range data.ExcludedMsisdns {
+		if supi, err := s.Connector().LookupMsisdn(msisdn); err == nil {
+			delete(members, supi)
+		}
+	}
+	return members, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// startGroupNotificationHandlers starts one notification handler per member,
+// their reports are notified to the AF by the group reporter
+func (s *Service) startGroupNotificationHandlers(afId string, sub *contexts.AfSubscriptionCtx) (int, error) {
+	data := sub.GetSubscriptionData()
+
+	reporter := handlers.NewGroupReporter(sub.GetLocation(), sub.GetNotificationUri(), data.GroupReportGuardTime)
+	if sub.GetRemainingReports() > 0 {
+		subId := data.Self
+		reporter.SetReportLimit(sub.GetRemainingReports(), func(remainingReports int32) {
+			s.countReport(afId, subId, remainingReports)
+		})
+	}
+
+	memberHandlers := make(map[string]*handlers.NotificationHandler)
+	for supi, member := range sub.GetMembers() {
+		notifHandler, status, err := s.newNotificationHandler(sub.GetLocation(), sub.GetNotificationUri(), supi, *memberSubscriptionData(&data, member))
+		if err != nil {
+			for _, started := range memberHandlers {
+				started.Stop()
+			}
+			return status, err
+		}
+		notifHandler.SetGroupReporter(reporter, member)
+		notifHandler.Start()
+		memberHandlers[supi] = notifHandler
+	}
+	sub.SetGroupNotificationHandlers(reporter, memberHandlers)
+	return http.StatusOK, nil
+}
+
+// memberSubscriptionData returns the subscription data as seen by one member
+func memberSubscriptionData(data *models.MonitoringEventSubscription, member handlers.GroupMember) *models.MonitoringEventSubscription {
+	memberData := *data
+	memberData.ExternalId = member.ExternalId
+	memberData.Msisdn = member.Msisdn
+	return &memberData
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/handlers"
+)
+
+// lifecycleManager schedules the expiry of the subscriptions, keyed by their location
+type lifecycleManager struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newLifecycleManager() *lifecycleManager {
+	return &lifecycleManager{timers: make(map[string]*time.Timer)}
+}
+
+// schedule calls expire at the given time, replacing any previous expiry of the key
+func (l *lifecycleManager) schedule(key string, at time.Time, expire func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		l.mu.Lock()
+		if l.timers[key] != timer {
+			/*rescheduled or cancelled meanwhile*/
+			l.mu.Unlock()
+			return
+		}
+		delete(l.timers, key)
+		l.mu.Unlock()
+
+		log.Printf("subscription %s expired", key)
+		expire()
+	})
+	l.timers[key] = timer
+}
+
+// cancel drops the scheduled expiry of the key, if any
+func (l *lifecycleManager) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+		delete(l.timers, key)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// scheduleExpiry arms the end of the subscription at its monitorExpireTime
+func (s *Service) scheduleExpiry(afId string, sub *contexts.AfSubscriptionCtx) {
+	data := sub.GetSubscriptionData()
+	if data.MonitorExpireTime.IsZero() {
+		return
+	}
+	subId := data.Self
+	s.lifecycle.schedule(sub.GetLocation(), data.MonitorExpireTime, func() {
+		s.endMonitoringEventSubscription(afId, subId, true)
+	})
+}
+
+// ------------------------------------------------------------------------------
+// countReport records the reports left to the subscription, which ends once
+// its maximumNumberOfReports is reached.
+func (s *Service) countReport(afId string, subId string, remainingReports int32) {
+	if remainingReports == 0 {
+		/*the last report already carried the cancellation indication*/
+		s.endMonitoringEventSubscription(afId, subId, false)
+		return
+	}
+
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil {
+		return
+	}
+	sub.SetRemainingReports(remainingReports)
+	if err := af.SaveAfSubscription(sub); err != nil {
+		log.Printf("could not store subscription %s: %s", subId, err)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// endMonitoringEventSubscription stops the notifications of an expired or
+// exhausted subscription and removes it, the AF is told with a notification
+// carrying the cancellation indication.
+func (s *Service) endMonitoringEventSubscription(afId string, subId string, notifyAf bool) {
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil {
+		return
+	}
+	sub.StopNotifications()
+	s.lifecycle.cancel(sub.GetLocation())
+	_ = af.DeleteAfscription(subId)
+	log.Printf("subscription %s ended", sub.GetLocation())
+
+	if notifyAf {
+		loc, notificationUri := sub.GetLocation(), sub.GetNotificationUri()
+		go func() {
+			if err := handlers.NotifyCancellation(loc, notificationUri); err != nil {
+				log.Printf("%s", err)
+			}
+		}()
+	}
+}
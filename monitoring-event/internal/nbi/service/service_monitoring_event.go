@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"time"
 
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/area"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/connector"
 	contexts "gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/handlers"
@@ -36,14 +37,16 @@ type app interface {
 	Cfg() *config.AppConfig
 	Connector() *connector.Connector
 	Ctx() *contexts.MonitoringEventCtx
+	Resolver() area.Resolver
 }
 
 type Service struct {
 	app
+	lifecycle *lifecycleManager
 }
 
 func NewMonitoringEventService(app app) *Service {
-	svc := &Service{app: app}
+	svc := &Service{app: app, lifecycle: newLifecycleManager()}
 	return svc
 }
 
@@ -58,6 +61,15 @@ func (s *Service) PostMonitoringEventSubscription(afId string, data *models.Moni
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
 
+	if err := s.quotaProblem(afId, len(af.GetAfSubscriptions())); err != nil {
+		return "", http.StatusTooManyRequests, err
+	}
+
+	if handlers.IsUeCountType(data.MonitoringType) {
+		/* counts over all the UEs, no UE is given */
+		return s.postUeCountSubscription(afId, af, data, immediateReport)
+	}
+
 	/* elaborate subscription here */
 	if /* len(data.Msisdn) > 0 ||*/ len(data.ExternalId) > 0 || len(data.Supi) > 0 {
 
@@ -76,6 +88,10 @@ func (s *Service) PostMonitoringEventSubscription(afId string, data *models.Moni
 			log.Printf("LookupExternalId returned SUPI: %s", supi)
 		}
 
+		if err := s.validateMonitoringEventSubscription(data); err != nil {
+			return "", http.StatusBadRequest, err
+		}
+
 		/* get user info */
 		log.Printf("Calling QueryUEInfo with SUPI: %s", supi)
 		userInfo, err := s.Connector().QueryUEInfo(supi)
@@ -83,7 +99,7 @@ func (s *Service) PostMonitoringEventSubscription(afId string, data *models.Moni
 			return "", http.StatusInternalServerError, fmt.Errorf("failed to get current user state: %w", err)
 		}
 
-		*immediateReport, err = prepareImmediateReport(data.ExternalId, data.MonitoringType, userInfo)
+		*immediateReport, err = s.prepareImmediateReport(data, userInfo)
 		if err != nil {
 			return "", http.StatusInternalServerError, fmt.Errorf("failed to prepare immediate report: %w", err)
 		}
@@ -97,51 +113,168 @@ func (s *Service) PostMonitoringEventSubscription(afId string, data *models.Moni
 		if sub == nil {
 			return "", http.StatusBadRequest, fmt.Errorf("failed to validate monitoring event subscription")
 		}
-		loc := sub.GetLocation()
-		eventType := sub.GetEventType()
-
-		cnEvenType := mapNefTriggerToCoreNetworkEventTypes(eventType)
-		if cnEvenType == "NOT_SUPPORTED" {
-			return "", http.StatusNotImplemented, fmt.Errorf("monitoring event type %s is not supported", eventType)
+		if data.MaximumNumberOfReports > 1 {
+			/*the immediate report is the first one*/
+			sub.SetRemainingReports(data.MaximumNumberOfReports - 1)
 		}
-
-		/*subscribe to user info */
-		subscription, err := s.Connector().SubscribeUserEvent(supi, cnEvenType)
+		status, err := s.startNotificationHandler(afId, sub)
 		if err != nil {
-			return "", http.StatusInternalServerError, fmt.Errorf("failed to subscribe to user info: %w", err)
+			_ = af.DeleteAfscription(data.Self)
+			return "", status, err
 		}
-
-		/* create notification handler */
-		notifHandler := handlers.NewNotificationHandler(loc, data.ExternalId, sub.GetNotificationUri(), subscription)
-
-		/* assign event callback to the notification handler */
-		switch eventType {
-		case models.MonitoringTypeLocationReporting:
-			notifHandler.SetEventCallback(handlers.HandleLocationReport)
-		case models.MonitoringTypeUeReachability:
-			notifHandler.SetEventCallback(handlers.HandleRegistrationReport)
-		case models.MonitoringTypeLossOfConnectivity:
-			notifHandler.SetEventCallback(handlers.HandleConnectivityReport)
-		case models.MonitoringTypeDownlinkDataDeliveryStatus:
-			notifHandler.SetEventCallback(handlers.HandleDDDSReport)
-		case models.MonitoringTypePdnConnectivityStatus:
-			notifHandler.SetEventCallback(handlers.HandlePdnStatusReport)
-		default:
-			return "", http.StatusNotImplemented, fmt.Errorf("monitoring event type %s is not supported", eventType)
+		s.scheduleExpiry(afId, sub)
+		if err := af.SaveAfSubscription(sub); err != nil {
+			log.Printf("could not store subscription %s: %s", data.Self, err)
 		}
-		notifHandler.Start()
-		sub.SetNotificationHandler(notifHandler)
 
-		return loc, http.StatusOK, nil
+		return sub.GetLocation(), http.StatusOK, nil
 
-	} else if len(data.ExternalGroupId) > 0 {
-		return "", http.StatusNotImplemented, fmt.Errorf("multiple or grouped UEs are not supported yet")
 	} else {
 		return "", http.StatusBadRequest, fmt.Errorf("no identifiers provided")
 	}
 
 }
 
+// ------------------------------------------------------------------------------
+// quotaProblem rejects a new subscription of the AF holding its maximum of
+// active subscriptions.
+func (s *Service) quotaProblem(afId string, subscriptions int) error {
+	maxSubs := s.Cfg().Limits.Of(afId).MaxSubscriptions
+	if maxSubs == 0 || subscriptions < maxSubs {
+		return nil
+	}
+	return &models.ProblemError{Problem: models.ProblemDetails{
+		Title:  "Too Many Requests",
+		Status: http.StatusTooManyRequests,
+		Detail: fmt.Sprintf("maximum of %d active subscriptions reached", maxSubs),
+		Cause:  "QUOTA_EXCEEDED",
+	}}
+}
+
+// ------------------------------------------------------------------------------
+// validateMonitoringEventSubscription checks the parameters common to the
+// single UE and group subscriptions
+func (s *Service) validateMonitoringEventSubscription(data *models.MonitoringEventSubscription) error {
+	if !data.MonitorExpireTime.IsZero() && data.MonitorExpireTime.Before(time.Now()) {
+		return fmt.Errorf("monitorExpireTime is in the past")
+	}
+	if data.MonitoringType == models.MonitoringTypeUeReachability &&
+		data.ReachabilityType != models.ReachabilityTypeSms && data.ReachabilityType != models.ReachabilityTypeData {
+		return fmt.Errorf("reachabilityType must be SMS or DATA")
+	}
+	/*an idle UE cannot be reached for data in less than the paging latency*/
+	if pagingLatency := s.Cfg().GetPagingLatency(); data.MonitoringType == models.MonitoringTypeUeReachability &&
+		data.ReachabilityType == models.ReachabilityTypeData && data.MaximumLatency > 0 && data.MaximumLatency < pagingLatency {
+		return fmt.Errorf("maximumLatency must be at least %d seconds", pagingLatency)
+	}
+	if (data.MonitoringType == models.MonitoringTypeAreaOfInterest || data.MonitoringType == models.MonitoringTypeNumberOfUesInAnArea) &&
+		area.New(&data.LocationArea, &data.LocationArea5G).IsEmpty() {
+		return fmt.Errorf("locationArea must give tracking areas, cells or geographic areas")
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// startNotificationHandler subscribes to the core network events of the
+// subscription UE and relays them to the AF
+func (s *Service) startNotificationHandler(afId string, sub *contexts.AfSubscriptionCtx) (int, error) {
+	if sub.IsGroup() {
+		return s.startGroupNotificationHandlers(afId, sub)
+	}
+	if handlers.IsUeCountType(sub.GetEventType()) {
+		return s.startUeCountHandler(afId, sub)
+	}
+	data := sub.GetSubscriptionData()
+
+	notifHandler, status, err := s.newNotificationHandler(sub.GetLocation(), sub.GetNotificationUri(), sub.GetSupi(), data)
+	if err != nil {
+		return status, err
+	}
+	if sub.GetRemainingReports() > 0 {
+		subId := data.Self
+		notifHandler.SetReportLimit(sub.GetRemainingReports(), func(remainingReports int32) {
+			s.countReport(afId, subId, remainingReports)
+		})
+	}
+	notifHandler.Start()
+	sub.SetNotificationHandler(notifHandler)
+	return http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// newNotificationHandler subscribes to the core network events of a UE and
+// prepares the handler turning them into reports, left to be started
+func (s *Service) newNotificationHandler(loc string, notificationUri string, supi string, data models.MonitoringEventSubscription) (*handlers.NotificationHandler, int, error) {
+	eventType := data.MonitoringType
+
+	cnEventTypes := mapNefTriggerToCoreNetworkEventTypes(eventType)
+	if len(cnEventTypes) == 0 {
+		return nil, http.StatusNotImplemented, fmt.Errorf("monitoring event type %s is not supported", eventType)
+	}
+
+	/* the reachability and presence callbacks derive the UE state from the stored reports */
+	var userInfo *models.UeInfo
+	if eventType == models.MonitoringTypeUeReachability || eventType == models.MonitoringTypeLossOfConnectivity ||
+		eventType == models.MonitoringTypeAreaOfInterest {
+		var err error
+		userInfo, err = s.Connector().QueryUEInfo(supi)
+		if err != nil {
+			log.Printf("no stored state for %s, assuming it unknown: %s", supi, err)
+		}
+	}
+
+	/*subscribe to user info */
+	subscription, err := s.Connector().SubscribeUserEvent(supi, cnEventTypes...)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("failed to subscribe to user info: %w", err)
+	}
+
+	/* create notification handler */
+	notifHandler := handlers.NewNotificationHandler(loc, data.ExternalId, notificationUri, subscription)
+
+	/* assign event callback to the notification handler */
+	switch eventType {
+	case models.MonitoringTypeLocationReporting:
+		notifHandler.SetEventCallback(handlers.NewLocationCallback(func(loc *models.Location) *models.GeographicArea {
+			return s.locate(loc, data.SupportedGADShapes)
+		}))
+	case models.MonitoringTypeUeReachability:
+		notifHandler.SetEventCallback(handlers.NewReachabilityCallback(data, userInfo, s.Cfg().GetPagingLatency()))
+	case models.MonitoringTypeLossOfConnectivity:
+		notifHandler.SetEventCallback(handlers.NewLossOfConnectivityCallback(data, userInfo))
+	case models.MonitoringTypeAreaOfInterest:
+		notifHandler.SetEventCallback(handlers.NewPresenceCallback(data, userInfo, s.uePosition))
+	case models.MonitoringTypeDownlinkDataDeliveryStatus:
+		notifHandler.SetEventCallback(handlers.HandleDDDSReport)
+	case models.MonitoringTypePdnConnectivityStatus:
+		notifHandler.SetEventCallback(handlers.HandlePdnStatusReport)
+	default:
+		_ = subscription.Close()
+		return nil, http.StatusNotImplemented, fmt.Errorf("monitoring event type %s is not supported", eventType)
+	}
+	return notifHandler, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// RestoreMonitoringEventSubscriptions reloads the stored subscriptions, starts
+// again their notification handlers and arms their expiry
+func (s *Service) RestoreMonitoringEventSubscriptions() error {
+	if err := s.Ctx().Restore(); err != nil {
+		return err
+	}
+	for afId, af := range s.Ctx().AppFunc {
+		for _, sub := range af.GetAfSubscriptions() {
+			if _, err := s.startNotificationHandler(afId, sub); err != nil {
+				log.Printf("could not restore notifications of %s: %s", sub.GetLocation(), err)
+				continue
+			}
+			s.scheduleExpiry(afId, sub)
+			log.Printf("restored subscription %s", sub.GetLocation())
+		}
+	}
+	return nil
+}
+
 // ------------------------------------------------------------------------------
 func (s *Service) UpdateMonitoringEventSubscription(afId string, subId string, data *models.MonitoringEventSubscription) error {
 
@@ -158,19 +291,17 @@ func (s *Service) DeleteMonitoringEventSubscription(afId string, subId string) (
 
 		sub := af.GetAfSubscription(subId)
 		if sub != nil {
-			notifHandler := sub.GetNotificationHandler()
-			if notifHandler != nil {
-				// stop the notification handler
-				ok := notifHandler.Stop()
-				if !ok {
+			// stop the notification handlers
+			s.lifecycle.cancel(sub.GetLocation())
+			ok := sub.StopNotifications()
+			if !ok {
+				return http.StatusInternalServerError, fmt.Errorf("could not stop the notification handler")
+			} else {
+				err := af.DeleteAfscription(subId)
+				if err != nil {
 					return http.StatusInternalServerError, fmt.Errorf("could not stop the notification handler")
-				} else {
-					err := af.DeleteAfscription(subId)
-					if err != nil {
-						return http.StatusInternalServerError, fmt.Errorf("could not stop the notification handler")
-					}
-					return http.StatusNoContent, nil
 				}
+				return http.StatusNoContent, nil
 			}
 		}
 	}
@@ -223,58 +354,44 @@ func (s *Service) GetAllMonitoringEventSubscriptions(afId string) ([]*models.Mon
 }
 
 // ------------------------------------------------------------------------------
-func mapNefTriggerToCoreNetworkEventTypes(trigger models.MonitoringType) string {
+// mapNefTriggerToCoreNetworkEventTypes returns the core network event channels
+// feeding a monitoring type, none when the type is not supported
+func mapNefTriggerToCoreNetworkEventTypes(trigger models.MonitoringType) []string {
 
 	switch trigger {
 	case models.MonitoringTypeUeReachability:
-		return string(models.CORENETWORKEVENT_REACHABILITY_REPORT)
+		return []string{
+			string(models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT),
+			string(models.CORENETWORKEVENT_CONNECTIVITY_STATE_REPORT),
+		}
 	case models.MonitoringTypeLocationReporting:
-		return string(models.CORENETWORKEVENT_LOCATION_REPORT)
+		return []string{string(models.CORENETWORKEVENT_LOCATION_REPORT)}
 	case models.MonitoringTypeLossOfConnectivity:
-		return string(models.CORENETWORKEVENT_LOSS_OF_CONNECTIVITY)
+		return []string{
+			string(models.CORENETWORKEVENT_LOSS_OF_CONNECTIVITY),
+			string(models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT),
+			string(models.CORENETWORKEVENT_CONNECTIVITY_STATE_REPORT),
+		}
+	case models.MonitoringTypeAreaOfInterest:
+		return []string{
+			string(models.CORENETWORKEVENT_LOCATION_REPORT),
+			string(models.CORENETWORKEVENT_REGISTRATION_STATE_REPORT),
+		}
 	case models.MonitoringTypeDownlinkDataDeliveryStatus:
-		return string(models.CORENETWORKEVENT_DDDS)
+		return []string{string(models.CORENETWORKEVENT_DDDS)}
 	case models.MonitoringTypePdnConnectivityStatus:
-		return string(models.CORENETWORKEVENT_PDN_CONNECTIVITY_STATUS)
-	}
-	return "NOT_SUPPORTED"
-
-}
-
-// generateMockGeographicArea creates a mock geographic area (polygon) based on cell ID
-// This is needed because CoreSim only provides cell-based location (NCGI), not GPS coordinates
-func generateMockGeographicArea(cellId string) *models.GeographicArea {
-	// Mock base coordinates - different locations for different cell IDs
-	// Using coordinates around Paris, France as default
-	baseLat := 48.8566
-	baseLon := 2.3522
-
-	// Offset based on cell ID to create different locations
-	// Parse last digit of cell ID for simple variation
-	if len(cellId) > 0 {
-		lastChar := cellId[len(cellId)-1]
-		offset := float64(lastChar%10) * 0.01
-		baseLat += offset
-		baseLon += offset
-	}
-
-	// Create a small polygon (approximately 200m x 200m)
-	// ~0.001 degrees is roughly 100 meters
-	pointList := []models.GeographicalCoordinates{
-		{Lat: baseLat + 0.001, Lon: baseLon + 0.001},
-		{Lat: baseLat + 0.001, Lon: baseLon - 0.001},
-		{Lat: baseLat - 0.001, Lon: baseLon - 0.001},
-		{Lat: baseLat - 0.001, Lon: baseLon + 0.001},
+		return []string{string(models.CORENETWORKEVENT_PDN_CONNECTIVITY_STATUS)}
 	}
+	return nil
 
-	return &models.GeographicArea{
-		Shape:     models.SupportedGadShapesPOLYGON,
-		PointList: pointList,
-	}
 }
 
-func prepareImmediateReport(externalId string, eventType models.MonitoringType, ue *models.UeInfo) (models.MonitoringEventReport, error) {
+// ------------------------------------------------------------------------------
+// prepareImmediateReport builds the report of the current state of the UE
+func (s *Service) prepareImmediateReport(data *models.MonitoringEventSubscription, ue *models.UeInfo) (models.MonitoringEventReport, error) {
 	immediateReport := models.MonitoringEventReport{}
+	externalId := data.ExternalId
+	eventType := data.MonitoringType
 
 	immediateReport.ExternalId = &externalId
 	immediateReport.MonitoringType = eventType
@@ -283,30 +400,28 @@ func prepareImmediateReport(externalId string, eventType models.MonitoringType,
 	switch eventType {
 	case models.MonitoringTypeLocationReporting:
 		if ue.Location != nil {
-			// Generate mock geographic area if not provided by CoreSim
-			geoArea := ue.Location.GeographicArea
-			if geoArea == nil {
-				cellId := ue.Location.UserLocation.NrLocation.Ncgi.NrCellId
-				geoArea = generateMockGeographicArea(cellId)
-				log.Printf("Generated mock geographic area for cell %s", cellId)
+			immediateReport.LocationInfo = handlers.LocationInfo(ue.Location, s.locate(ue.Location, data.SupportedGADShapes))
+			/*update nrLocation ageOfLocation*/
+			if nrLoc := ue.Location.UserLocation.NrLocation; nrLoc != nil {
+				nrLoc.AgeOfLocationInformation = int32(time.Now().Unix() - ue.Location.TimeStamp)
+				nrLoc.UeLocationTimestamp = time.Unix(ue.Location.TimeStamp, 0)
 			}
+		}
 
-			immediateReport.LocationInfo = &models.LocationInfo{
-				UserLocation:   &ue.Location.UserLocation,
-				GeographicArea: geoArea,
-				CellId:         ue.Location.UserLocation.NrLocation.Ncgi.NrCellId,
-			}
-			/*update nrLocation ageOfLocation*/
-			immediateReport.LocationInfo.UserLocation.NrLocation.AgeOfLocationInformation = int32(time.Now().Unix() - ue.Location.TimeStamp)
-			immediateReport.LocationInfo.UserLocation.NrLocation.UeLocationTimestamp = time.Unix(ue.Location.TimeStamp, 0)
+	case models.MonitoringTypeUeReachability:
+		state := handlers.NewUeState(ue)
+		if state.Reachable(data.ReachabilityType, data.MaximumLatency, s.Cfg().GetPagingLatency()) {
+			immediateReport = *handlers.ReachabilityReport(data, state)
 		}
 
 	case models.MonitoringTypeLossOfConnectivity:
-		if ue.LossOfConnectivity != nil {
-			reason := string(ue.LossOfConnectivity.LossOfConnectReason)
-			immediateReport.LossOfConnectReason = &reason
+		if loss := handlers.LostConnectivity(ue); loss != nil {
+			immediateReport = *handlers.LossOfConnectivityReport(externalId, loss)
 		}
 
+	case models.MonitoringTypeAreaOfInterest:
+		immediateReport = *handlers.PresenceReport(externalId, handlers.UePresence(data, ue, s.uePosition))
+
 	case models.MonitoringTypeDownlinkDataDeliveryStatus:
 		var oldestDdds *models.Ddds
 		oldestDdds = nil
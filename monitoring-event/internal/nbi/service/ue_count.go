@@ -0,0 +1,140 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/area"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/handlers"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// postUeCountSubscription reports the number of UEs in an area, of registered
+// UEs or of established PDU sessions, at once and then every repPeriod. Without
+// repPeriod only the immediate report is given.
+func (s *Service) postUeCountSubscription(afId string, af *contexts.AppFunctionCtx, data *models.MonitoringEventSubscription, immediateReport *models.MonitoringEventReport) (string, int, error) {
+
+	if err := s.validateMonitoringEventSubscription(data); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	if data.RepPeriod < 0 {
+		return "", http.StatusBadRequest, fmt.Errorf("repPeriod must be positive")
+	}
+
+	report, err := s.ueCountReport(data)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("failed to prepare immediate report: %w", err)
+	}
+	*immediateReport = *report
+
+	if data.MaximumNumberOfReports == 1 || data.RepPeriod == 0 {
+		/* if only immediate report is requested, then return and do not create the subscription context*/
+		return "", http.StatusOK, nil
+	}
+
+	sub := af.NewAfSubscription("", data)
+	if sub == nil {
+		return "", http.StatusBadRequest, fmt.Errorf("failed to validate monitoring event subscription")
+	}
+	if data.MaximumNumberOfReports > 1 {
+		/*the immediate report is the first one*/
+		sub.SetRemainingReports(data.MaximumNumberOfReports - 1)
+	}
+	status, err := s.startNotificationHandler(afId, sub)
+	if err != nil {
+		_ = af.DeleteAfscription(data.Self)
+		return "", status, err
+	}
+	s.scheduleExpiry(afId, sub)
+	if err := af.SaveAfSubscription(sub); err != nil {
+		log.Printf("could not store subscription %s: %s", data.Self, err)
+	}
+
+	return sub.GetLocation(), http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// startUeCountHandler counts the UEs or PDU sessions every repPeriod
+func (s *Service) startUeCountHandler(afId string, sub *contexts.AfSubscriptionCtx) (int, error) {
+	data := sub.GetSubscriptionData()
+	if data.RepPeriod <= 0 {
+		return http.StatusBadRequest, fmt.Errorf("repPeriod must be positive")
+	}
+
+	notifHandler := handlers.NewNotificationHandler(sub.GetLocation(), "", sub.GetNotificationUri(), nil)
+	notifHandler.SetPeriodicReport(time.Duration(data.RepPeriod)*time.Second, func() (*models.MonitoringEventReport, error) {
+		return s.ueCountReport(&data)
+	})
+	if sub.GetRemainingReports() > 0 {
+		subId := data.Self
+		notifHandler.SetReportLimit(sub.GetRemainingReports(), func(remainingReports int32) {
+			s.countReport(afId, subId, remainingReports)
+		})
+	}
+	notifHandler.Start()
+	sub.SetNotificationHandler(notifHandler)
+	return http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// ueCountReport counts over the UEs currently stored by the core network service
+func (s *Service) ueCountReport(data *models.MonitoringEventSubscription) (*models.MonitoringEventReport, error) {
+	ues, err := s.Connector().QueryUEsInfo()
+	if err != nil {
+		return nil, err
+	}
+	locationArea := area.New(&data.LocationArea, &data.LocationArea5G)
+	return handlers.UeCountReport(data, ues, func(ue *models.UeInfo) bool {
+		in, _ := locationArea.Contains(&ue.Location.UserLocation, s.uePosition(ue.Location))
+		return in
+	})
+}
+
+// ------------------------------------------------------------------------------
+// uePosition returns the geographic position of the UE location, nil when
+// it is unknown
+func (s *Service) uePosition(loc *models.Location) *models.GeographicArea {
+	return s.locate(loc, nil)
+}
+
+// ------------------------------------------------------------------------------
+// locate returns the geographic area of the UE location in one of the shapes
+// supported by the AF, the one given by the core network or else the coverage
+// of the serving cell. It is nil when the cell is unknown.
+func (s *Service) locate(loc *models.Location, shapes []models.SupportedGadShapes) *models.GeographicArea {
+	if loc == nil {
+		return nil
+	}
+	if loc.GeographicArea != nil && (len(shapes) == 0 || slices.Contains(shapes, loc.GeographicArea.Shape)) {
+		return loc.GeographicArea
+	}
+	geographicArea, ok := s.Resolver().Resolve(&loc.UserLocation, shapes)
+	if !ok {
+		return nil
+	}
+	return geographicArea
+}
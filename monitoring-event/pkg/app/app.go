@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -28,6 +29,7 @@ import (
 	"syscall"
 
 	"github.com/google/uuid"
+	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/area"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/connector"
 	contexts "gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/monitoring-event/internal/nbi"
@@ -46,6 +48,7 @@ type AppCtx struct {
 	server    *nbi.NbiServer
 	connector *connector.Connector
 	service   *service.Service
+	resolver  area.Resolver
 
 	//contexts
 	nfCtx *contexts.MonitoringEventCtx
@@ -59,15 +62,52 @@ func InitApplication(name string, configPath string) (*AppCtx, error) {
 		appId:   uuid.New().String(),
 	}
 
-	appInstance.server, _ = nbi.NewNorthbound(appInstance)
+	server, err := nbi.NewNorthbound(appInstance)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the northbound: %w", err)
+	}
+	appInstance.server = server
 	appInstance.service = service.NewMonitoringEventService(appInstance)
 	appInstance.connector = connector.NewConnector(appInstance)
 
-	appInstance.nfCtx = contexts.NewMonitoringEventCtx(appInstance)
+	resolver, err := newCellResolver(appInstance.config)
+	if err != nil {
+		return nil, fmt.Errorf("could not load cell catalogue: %w", err)
+	}
+	appInstance.resolver = resolver
+
+	appInstance.nfCtx = contexts.NewMonitoringEventCtx(appInstance, newSubscriptionStore(appInstance.config))
+	if err := appInstance.service.RestoreMonitoringEventSubscriptions(); err != nil {
+		return nil, fmt.Errorf("could not restore subscriptions: %w", err)
+	}
 
 	return appInstance, nil
 }
 
+// newSubscriptionStore selects where the subscriptions are persisted
+func newSubscriptionStore(cfg *config.AppConfig) contexts.SubscriptionStore {
+	switch cfg.SubsStore {
+	case "redis":
+		return contexts.NewRedisStore(cfg.Sbi.RedisSvc, "monitoring-event")
+	default:
+		return contexts.NewMemoryStore()
+	}
+}
+
+// newCellResolver selects how the serving cells of the UEs are located
+func newCellResolver(cfg *config.AppConfig) (area.Resolver, error) {
+	if len(cfg.CellCatalogue) == 0 {
+		log.Printf("no cell catalogue, UE positions are unknown")
+		return area.NewCatalogue(), nil
+	}
+	catalogue, err := area.LoadCatalogue(cfg.CellCatalogue)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("loaded %d cells from %s", catalogue.Len(), cfg.CellCatalogue)
+	return catalogue, nil
+}
+
 func (app *AppCtx) Cfg() *config.AppConfig {
 	return app.config
 }
@@ -84,6 +124,10 @@ func (app *AppCtx) Connector() *connector.Connector {
 	return app.connector
 }
 
+func (app *AppCtx) Resolver() area.Resolver {
+	return app.resolver
+}
+
 func (app *AppCtx) Ctx() *contexts.MonitoringEventCtx {
 	return app.nfCtx
 }
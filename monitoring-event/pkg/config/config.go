@@ -28,15 +28,77 @@ import (
 
 type AppConfig struct {
 	/* Basic configuration parameters */
-	Sbi           SbiConfig `yaml:"sbi"`
-	Nbi           NbiConfig `yaml:"nbi"`
-	CapifSvc      string    `yaml:"capifSvc"`
-	SupportedFeat string    `yaml:"supportedFeatures"`
+	Sbi           SbiConfig    `yaml:"sbi"`
+	Nbi           NbiConfig    `yaml:"nbi"`
+	CapifSvc      string       `yaml:"capifSvc"`
+	SupportedFeat string       `yaml:"supportedFeatures"`
+	SubsStore     string       `yaml:"subscriptionStore"` /*memory (default) or redis, stored in sbi redisSvc*/
+	Limits        LimitsConfig `yaml:"limits"`            /*per client request rate and per AF subscription quota*/
+	CellCatalogue string       `yaml:"cellCatalogue"`     /*CSV or GeoJSON file locating the cells, none leaving the UE positions unknown*/
+	PagingLatency int32        `yaml:"pagingLatency"`     /*seconds to reach an idle UE by paging, DRX cycle included, defaults to 3*/
 
 	/* Custom configuration parameters */
 
 }
 
+// defaultPagingLatency covers the longest default paging cycle of 2.56 seconds
+const defaultPagingLatency = 3
+
+// GetPagingLatency returns the seconds needed to reach an idle UE
+func (cfg *AppConfig) GetPagingLatency() int32 {
+	if cfg.PagingLatency > 0 {
+		return cfg.PagingLatency
+	}
+	return defaultPagingLatency
+}
+
+// LimitsConfig bounds the request rate of the clients, identified by their
+// CAPIF API invoker or else by their address, and the active subscriptions of
+// the AFs
+type LimitsConfig struct {
+	Rate             float64                       `yaml:"rate"`             /*requests per second of a client, 0 for unlimited*/
+	Burst            int                           `yaml:"burst"`            /*requests accepted at once, defaults to one second worth*/
+	MaxSubscriptions int                           `yaml:"maxSubscriptions"` /*active subscriptions of an AF, 0 for unlimited*/
+	Clients          map[string]ClientLimitsConfig `yaml:"clients"`          /*per client rate overrides, unset values inheriting the above*/
+	Afs              map[string]AfLimitsConfig     `yaml:"afs"`              /*per AF quota overrides*/
+}
+
+type ClientLimitsConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+type AfLimitsConfig struct {
+	MaxSubscriptions int `yaml:"maxSubscriptions"`
+
+	/*the requests are not told apart by AF, a rate set here is refused*/
+	Rate  float64 `yaml:"rate,omitempty"`
+	Burst int     `yaml:"burst,omitempty"`
+}
+
+// Of returns the subscription quota of the AF
+func (l LimitsConfig) Of(afId string) AfLimitsConfig {
+	limits := AfLimitsConfig{MaxSubscriptions: l.MaxSubscriptions}
+	if af, ok := l.Afs[afId]; ok && af.MaxSubscriptions > 0 {
+		limits.MaxSubscriptions = af.MaxSubscriptions
+	}
+	return limits
+}
+
+// ClientOf returns the request rate of the client
+func (l LimitsConfig) ClientOf(client string) ClientLimitsConfig {
+	limits := ClientLimitsConfig{Rate: l.Rate, Burst: l.Burst}
+	if c, ok := l.Clients[client]; ok {
+		if c.Rate > 0 {
+			limits.Rate = c.Rate
+		}
+		if c.Burst > 0 {
+			limits.Burst = c.Burst
+		}
+	}
+	return limits
+}
+
 type NbiConfig struct {
 	HttpVersion uint16 `yaml:"httpVersion"`
 	UseTLS      bool   `yaml:"useTLS"`
@@ -110,7 +110,7 @@ type Configuration struct {
 func NewConfiguration(url string, httpVersion int) *Configuration {
 	cfg := &Configuration{
 		DefaultHeader: make(map[string]string),
-		UserAgent:     "OpenAPI-Generator/1.0.0/go",
+		UserAgent:     "OpenAPI-Generator/1.1.0/go",
 		Debug:         false,
 		Servers: ServerConfigurations{
 			{
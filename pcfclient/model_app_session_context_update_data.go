@@ -37,11 +37,13 @@ var _ MappedNullable = &AppSessionContextUpdateData{}
 // AppSessionContextUpdateData Identifies the modifications to the \"ascReqData\" property of an Individual Application Session Context which may include the modifications to the sub-resource Events Subscription.
 type AppSessionContextUpdateData struct {
 	// Contains an AF application identifier.
-	AfAppId   *string                        `json:"afAppId,omitempty"`
+	AfAppId *string `json:"afAppId,omitempty"`
+	// Application provided charging identifier allowing correlation of charging information.
+	AfChargId NullableString                 `json:"afChargId,omitempty"`
 	AfRoutReq NullableAfRoutingRequirementRm `json:"afRoutReq,omitempty"`
 	AfSfcReq  NullableAfSfcRequirement       `json:"afSfcReq,omitempty"`
 	// Contains an identity of an application service provider.
-	AspId *string `json:"aspId,omitempty"`
+	AspId NullableString `json:"aspId,omitempty"`
 	// string identifying a BDT Reference ID as defined in clause 5.3.3 of 3GPP TS 29.154.
 	BdtRefId *string                      `json:"bdtRefId,omitempty"`
 	EvSubsc  NullableEventsSubscReqDataRm `json:"evSubsc,omitempty"`
@@ -125,6 +127,49 @@ func (o *AppSessionContextUpdateData) SetAfAppId(v string) {
 	o.AfAppId = &v
 }
 
+// GetAfChargId returns the AfChargId field value if set, zero value otherwise (both if not set or set to explicit null).
+func (o *AppSessionContextUpdateData) GetAfChargId() string {
+	if o == nil || IsNil(o.AfChargId.Get()) {
+		var ret string
+		return ret
+	}
+	return *o.AfChargId.Get()
+}
+
+// GetAfChargIdOk returns a tuple with the AfChargId field value if set, nil otherwise
+// and a boolean to check if the value has been set.
+// NOTE: If the value is an explicit nil, `nil, true` will be returned
+func (o *AppSessionContextUpdateData) GetAfChargIdOk() (*string, bool) {
+	if o == nil {
+		return nil, false
+	}
+	return o.AfChargId.Get(), o.AfChargId.IsSet()
+}
+
+// HasAfChargId returns a boolean if a field has been set.
+func (o *AppSessionContextUpdateData) HasAfChargId() bool {
+	if o != nil && o.AfChargId.IsSet() {
+		return true
+	}
+
+	return false
+}
+
+// SetAfChargId gets a reference to the given NullableString and assigns it to the AfChargId field.
+func (o *AppSessionContextUpdateData) SetAfChargId(v string) {
+	o.AfChargId.Set(&v)
+}
+
+// SetAfChargIdNil sets the value for AfChargId to be an explicit nil
+func (o *AppSessionContextUpdateData) SetAfChargIdNil() {
+	o.AfChargId.Set(nil)
+}
+
+// UnsetAfChargId ensures that no value is present for AfChargId, not even an explicit nil
+func (o *AppSessionContextUpdateData) UnsetAfChargId() {
+	o.AfChargId.Unset()
+}
+
 // GetAfRoutReq returns the AfRoutReq field value if set, zero value otherwise (both if not set or set to explicit null).
 func (o *AppSessionContextUpdateData) GetAfRoutReq() AfRoutingRequirementRm {
 	if o == nil || IsNil(o.AfRoutReq.Get()) {
@@ -211,36 +256,47 @@ func (o *AppSessionContextUpdateData) UnsetAfSfcReq() {
 	o.AfSfcReq.Unset()
 }
 
-// GetAspId returns the AspId field value if set, zero value otherwise.
+// GetAspId returns the AspId field value if set, zero value otherwise (both if not set or set to explicit null).
 func (o *AppSessionContextUpdateData) GetAspId() string {
-	if o == nil || IsNil(o.AspId) {
+	if o == nil || IsNil(o.AspId.Get()) {
 		var ret string
 		return ret
 	}
-	return *o.AspId
+	return *o.AspId.Get()
 }
 
 // GetAspIdOk returns a tuple with the AspId field value if set, nil otherwise
 // and a boolean to check if the value has been set.
+// NOTE: If the value is an explicit nil, `nil, true` will be returned
 func (o *AppSessionContextUpdateData) GetAspIdOk() (*string, bool) {
-	if o == nil || IsNil(o.AspId) {
+	if o == nil {
 		return nil, false
 	}
-	return o.AspId, true
+	return o.AspId.Get(), o.AspId.IsSet()
 }
 
 // HasAspId returns a boolean if a field has been set.
 func (o *AppSessionContextUpdateData) HasAspId() bool {
-	if o != nil && !IsNil(o.AspId) {
+	if o != nil && o.AspId.IsSet() {
 		return true
 	}
 
 	return false
 }
 
-// SetAspId gets a reference to the given string and assigns it to the AspId field.
+// SetAspId gets a reference to the given NullableString and assigns it to the AspId field.
 func (o *AppSessionContextUpdateData) SetAspId(v string) {
-	o.AspId = &v
+	o.AspId.Set(&v)
+}
+
+// SetAspIdNil sets the value for AspId to be an explicit nil
+func (o *AppSessionContextUpdateData) SetAspIdNil() {
+	o.AspId.Set(nil)
+}
+
+// UnsetAspId ensures that no value is present for AspId, not even an explicit nil
+func (o *AppSessionContextUpdateData) UnsetAspId() {
+	o.AspId.Unset()
 }
 
 // GetBdtRefId returns the BdtRefId field value if set, zero value otherwise.
@@ -972,14 +1028,17 @@ func (o AppSessionContextUpdateData) ToMap() (map[string]interface{}, error) {
 	if !IsNil(o.AfAppId) {
 		toSerialize["afAppId"] = o.AfAppId
 	}
+	if o.AfChargId.IsSet() {
+		toSerialize["afChargId"] = o.AfChargId.Get()
+	}
 	if o.AfRoutReq.IsSet() {
 		toSerialize["afRoutReq"] = o.AfRoutReq.Get()
 	}
 	if o.AfSfcReq.IsSet() {
 		toSerialize["afSfcReq"] = o.AfSfcReq.Get()
 	}
-	if !IsNil(o.AspId) {
-		toSerialize["aspId"] = o.AspId
+	if o.AspId.IsSet() {
+		toSerialize["aspId"] = o.AspId.Get()
 	}
 	if !IsNil(o.BdtRefId) {
 		toSerialize["bdtRefId"] = o.BdtRefId
@@ -29,43 +29,46 @@ package pcfclient
 
 import (
 	"encoding/json"
+	"fmt"
 )
 
-// checks if the Ipv6Addr type satisfies the MappedNullable interface at compile time
-var _ MappedNullable = &Ipv6Addr{}
-
 // Ipv6Addr String identifying an IPv6 address formatted according to clause 4 of RFC5952. The mixed IPv4 IPv6 notation according to clause 5 of RFC5952 shall not be used.
 type Ipv6Addr struct {
+	String *string
 }
 
-// NewIpv6Addr instantiates a new Ipv6Addr object
-// This constructor will assign default values to properties that have it defined,
-// and makes sure properties required by API are set, but the set of arguments
-// will change when the set of required properties is changed
-func NewIpv6Addr() *Ipv6Addr {
-	this := Ipv6Addr{}
+// NewIpv6Addr instantiates a new Ipv6Addr object holding the given address
+func NewIpv6Addr(addr string) *Ipv6Addr {
+	this := Ipv6Addr{String: &addr}
 	return &this
 }
 
-// NewIpv6AddrWithDefaults instantiates a new Ipv6Addr object
-// This constructor will only assign default values to properties that have it defined,
-// but it doesn't guarantee that properties required by API are set
-func NewIpv6AddrWithDefaults() *Ipv6Addr {
-	this := Ipv6Addr{}
-	return &this
+// Unmarshal JSON data into any of the pointers in the struct
+func (dst *Ipv6Addr) UnmarshalJSON(data []byte) error {
+	var err error
+	// try to unmarshal JSON data into String
+	err = json.Unmarshal(data, &dst.String)
+	if err == nil {
+		jsonString, _ := json.Marshal(dst.String)
+		if string(jsonString) == "{}" { // empty struct
+			dst.String = nil
+		} else {
+			return nil // data stored in dst.String, return on the first match
+		}
+	} else {
+		dst.String = nil
+	}
+
+	return fmt.Errorf("data failed to match schemas in anyOf(Ipv6Addr)")
 }
 
-func (o Ipv6Addr) MarshalJSON() ([]byte, error) {
-	toSerialize, err := o.ToMap()
-	if err != nil {
-		return []byte{}, err
+// Marshal data from the first non-nil pointers in the struct to JSON
+func (src Ipv6Addr) MarshalJSON() ([]byte, error) {
+	if src.String != nil {
+		return json.Marshal(&src.String)
 	}
-	return json.Marshal(toSerialize)
-}
 
-func (o Ipv6Addr) ToMap() (map[string]interface{}, error) {
-	toSerialize := map[string]interface{}{}
-	return toSerialize, nil
+	return nil, nil // no data in anyOf schemas
 }
 
 type NullableIpv6Addr struct {
@@ -83,7 +83,7 @@ type MediaComponentRm struct {
 	PreemptCap     NullablePreemptionCapability    `json:"preemptCap,omitempty"`
 	PreemptVuln    NullablePreemptionVulnerability `json:"preemptVuln,omitempty"`
 	PrioSharingInd *PrioritySharingIndicator       `json:"prioSharingInd,omitempty"`
-	ResPrio        *ReservPriority                 `json:"resPrio,omitempty"`
+	ResPrio        NullableReservPriority          `json:"resPrio,omitempty"`
 	// This data type is defined in the same way as the 'BitRate' data type, but with the OpenAPI 'nullable: true' property.
 	RrBw NullableString `json:"rrBw,omitempty" validate:"regexp=^\\\\d+(\\\\.\\\\d+)? (bps|Kbps|Mbps|Gbps|Tbps)$"`
 	// This data type is defined in the same way as the 'BitRate' data type, but with the OpenAPI 'nullable: true' property.
@@ -1252,36 +1252,47 @@ func (o *MediaComponentRm) SetPrioSharingInd(v PrioritySharingIndicator) {
 	o.PrioSharingInd = &v
 }
 
-// GetResPrio returns the ResPrio field value if set, zero value otherwise.
+// GetResPrio returns the ResPrio field value if set, zero value otherwise (both if not set or set to explicit null).
 func (o *MediaComponentRm) GetResPrio() ReservPriority {
-	if o == nil || IsNil(o.ResPrio) {
+	if o == nil || IsNil(o.ResPrio.Get()) {
 		var ret ReservPriority
 		return ret
 	}
-	return *o.ResPrio
+	return *o.ResPrio.Get()
 }
 
 // GetResPrioOk returns a tuple with the ResPrio field value if set, nil otherwise
 // and a boolean to check if the value has been set.
+// NOTE: If the value is an explicit nil, `nil, true` will be returned
 func (o *MediaComponentRm) GetResPrioOk() (*ReservPriority, bool) {
-	if o == nil || IsNil(o.ResPrio) {
+	if o == nil {
 		return nil, false
 	}
-	return o.ResPrio, true
+	return o.ResPrio.Get(), o.ResPrio.IsSet()
 }
 
 // HasResPrio returns a boolean if a field has been set.
 func (o *MediaComponentRm) HasResPrio() bool {
-	if o != nil && !IsNil(o.ResPrio) {
+	if o != nil && o.ResPrio.IsSet() {
 		return true
 	}
 
 	return false
 }
 
-// SetResPrio gets a reference to the given ReservPriority and assigns it to the ResPrio field.
+// SetResPrio gets a reference to the given NullableReservPriority and assigns it to the ResPrio field.
 func (o *MediaComponentRm) SetResPrio(v ReservPriority) {
-	o.ResPrio = &v
+	o.ResPrio.Set(&v)
+}
+
+// SetResPrioNil sets the value for ResPrio to be an explicit nil
+func (o *MediaComponentRm) SetResPrioNil() {
+	o.ResPrio.Set(nil)
+}
+
+// UnsetResPrio ensures that no value is present for ResPrio, not even an explicit nil
+func (o *MediaComponentRm) UnsetResPrio() {
+	o.ResPrio.Unset()
 }
 
 // GetRrBw returns the RrBw field value if set, zero value otherwise (both if not set or set to explicit null).
@@ -2022,8 +2033,8 @@ func (o MediaComponentRm) ToMap() (map[string]interface{}, error) {
 	if !IsNil(o.PrioSharingInd) {
 		toSerialize["prioSharingInd"] = o.PrioSharingInd
 	}
-	if !IsNil(o.ResPrio) {
-		toSerialize["resPrio"] = o.ResPrio
+	if o.ResPrio.IsSet() {
+		toSerialize["resPrio"] = o.ResPrio.Get()
 	}
 	if o.RrBw.IsSet() {
 		toSerialize["rrBw"] = o.RrBw.Get()
@@ -47,7 +47,7 @@ func main() {
 
 	app, err := traffInflApp.InitApplication("traffic-influence", getConfigPath())
 	if err != nil {
-		log.Printf("cannot initialize application")
+		log.Printf("cannot initialize application: %s", err)
 		return
 	}
 
@@ -11,6 +11,8 @@ require (
 )
 
 require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/klauspost/compress v1.13.6 // indirect
 	github.com/montanaflynn/stats v0.7.1 // indirect
@@ -25,10 +27,14 @@ require (
 
 require (
 	github.com/google/uuid v1.6.0
+	github.com/redis/go-redis/v9 v9.11.0
 	gitlab.eurecom.fr/open-exposure/nef/libcapif v1.2.0
+	gitlab.eurecom.fr/open-exposure/nef/libnbi v1.0.0
 	gitlab.eurecom.fr/open-exposure/nef/nrfclient v1.0.1
-	gitlab.eurecom.fr/open-exposure/nef/pcfclient v1.0.1
+	gitlab.eurecom.fr/open-exposure/nef/pcfclient v1.1.0
 	go.mongodb.org/mongo-driver v1.17.1
 	golang.org/x/exp v0.0.0-20241108190413-2d47ceb2692f
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+replace gitlab.eurecom.fr/open-exposure/nef/libnbi => ../libnbi
@@ -0,0 +1,169 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// ------------------------------------------------------------------------------
+// ProvisionInfluenceData stores the influence data and notifies the consumers
+// subscribed to its changes.
+func (c *Connector) ProvisionInfluenceData(influenceId string, data *TrafficInfluData) error {
+	previous, err := c.appData.GetInfluenceData(influenceId)
+	if err != nil {
+		return err
+	}
+	if err := c.appData.PutInfluenceData(influenceId, data); err != nil {
+		log.Printf("could not store influence data %s: %v", influenceId, err)
+		return err
+	}
+	log.Printf("provisioned influence data at %s", data.ResUri)
+	c.notifyInfluenceDataChange(previous, data)
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// RemoveInfluenceData deletes the influence data and notifies the consumers
+// subscribed to its changes.
+func (c *Connector) RemoveInfluenceData(influenceId string) error {
+	data, err := c.appData.GetInfluenceData(influenceId)
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return nil
+	}
+	if err := c.appData.DeleteInfluenceData(influenceId); err != nil {
+		log.Printf("could not delete influence data %s: %v", influenceId, err)
+		return err
+	}
+	log.Printf("removed influence data at %s", data.ResUri)
+	c.notifyInfluenceDataChange(data, nil)
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) QueryInfluenceData(filter *InfluenceDataFilter) ([]*TrafficInfluData, error) {
+	return c.appData.QueryInfluenceData(filter)
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) SubscribeInfluenceData(subId string, sub *InfluenceDataSub) error {
+	return c.appData.PutInfluenceDataSub(subId, sub)
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) UnsubscribeInfluenceData(subId string) (bool, error) {
+	return c.appData.DeleteInfluenceDataSub(subId)
+}
+
+// ------------------------------------------------------------------------------
+// notifyInfluenceDataChange sends the new influence data to each subscription
+// matching them, and a removal notification to the subscriptions that only
+// matched the previous influence data. Either of them is nil on creation and
+// removal, expired subscriptions are dropped.
+func (c *Connector) notifyInfluenceDataChange(previous *TrafficInfluData, data *TrafficInfluData) {
+	subs, err := c.appData.InfluenceDataSubs()
+	if err != nil {
+		log.Printf("could not load influence data subscriptions: %v", err)
+		return
+	}
+	now := time.Now()
+	for subId, sub := range subs {
+		if sub.Expiry != nil && sub.Expiry.Before(now) {
+			if _, err := c.appData.DeleteInfluenceDataSub(subId); err != nil {
+				log.Printf("could not remove expired influence data subscription %s: %v", subId, err)
+			}
+			continue
+		}
+		var notif TrafficInfluDataNotif
+		if data != nil && sub.Filter().Matches(data) {
+			notif = TrafficInfluDataNotif{ResUri: data.ResUri, TrafficInfluData: data}
+		} else if previous != nil && sub.Filter().Matches(previous) {
+			notif = TrafficInfluDataNotif{ResUri: previous.ResUri}
+		} else {
+			continue
+		}
+		notificationUri := sub.NotificationUri
+		go func() {
+			if err := c.SendNotification(notificationUri, []TrafficInfluDataNotif{notif}); err != nil {
+				log.Printf("%s", err)
+			}
+		}()
+	}
+}
+
+// ------------------------------------------------------------------------------
+// SendNotification posts a JSON notification to a consumer callback.
+func (c *Connector) SendNotification(notificationUri string, v any) error {
+	return c.postNotification(notificationUri, v, nil)
+}
+
+// ------------------------------------------------------------------------------
+// postNotification posts a JSON notification to a consumer callback and
+// decodes the body of a 200 response in out, when provided.
+func (c *Connector) postNotification(notificationUri string, v any, out any) error {
+	log.Printf("sending notification to %s", notificationUri)
+	bData, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification data: %w", err)
+	}
+
+	r, err := http.NewRequest("POST", notificationUri, bytes.NewBuffer(bData))
+	if err != nil {
+		return fmt.Errorf("failed to build notification request: %w", err)
+	}
+	r.Header.Add("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(r)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	defer func(body io.ReadCloser) {
+		if err := body.Close(); err != nil {
+			log.Printf("could not close response body correctly")
+		}
+	}(resp.Body)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to send notification, status code: %d", resp.StatusCode)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read notification response: %w", err)
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("failed to decode notification response: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,361 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// AnyUeGroupId is the interGroupId of the influence data applying to any UE
+const AnyUeGroupId = "AnyUE"
+
+// TrafficInfluData is the subset of the Nudr_DataRepository TrafficInfluData
+// (TS 29.519) provisioned by the NEF for any UE or a group of UEs
+type TrafficInfluData struct {
+	AfAppId           string                        `json:"afAppId,omitempty"`
+	AppReloInd        bool                          `json:"appReloInd,omitempty"`
+	Dnn               string                        `json:"dnn,omitempty"`
+	Snssai            *models.Snssai                `json:"snssai,omitempty"`
+	InterGroupId      string                        `json:"interGroupId,omitempty"`
+	Supi              string                        `json:"supi,omitempty"`
+	TrafficFilters    []models.FlowInfo             `json:"trafficFilters,omitempty"`
+	EthTrafficFilters []models.EthFlowDescription   `json:"ethTrafficFilters,omitempty"`
+	TrafficRoutes     []models.RouteToLocation      `json:"trafficRoutes,omitempty"`
+	TraffCorreInd     bool                          `json:"traffCorreInd,omitempty"`
+	TempValidities    []models.TemporalValidity     `json:"tempValidities,omitempty"`
+	UpPathChgNotifUri string                        `json:"upPathChgNotifUri,omitempty"`
+	SubscribedEvents  []models.SubscribedEvent      `json:"subscribedEvents,omitempty"`
+	DnaiChgType       models.DnaiChangeType         `json:"dnaiChgType,omitempty"`
+	AfAckInd          bool                          `json:"afAckInd,omitempty"`
+	AddrPreserInd     bool                          `json:"addrPreserInd,omitempty"`
+	SimConnInd        bool                          `json:"simConnInd,omitempty"`
+	SimConnTerm       int32                         `json:"simConnTerm,omitempty"`
+	MaxAllowedUpLat   int32                         `json:"maxAllowedUpLat,omitempty"`
+	EasIpReplaceInfos []models.EasIpReplacementInfo `json:"easIpReplaceInfos,omitempty"`
+	EasRedisInd       bool                          `json:"easRedisInd,omitempty"`
+	SupportedFeatures string                        `json:"supportedFeatures,omitempty"`
+	ResUri            string                        `json:"resUri,omitempty"`
+}
+
+// InfluenceDataSub is the Nudr TrafficInfluSub: a consumer, typically the
+// PCF, asking to be notified of the changes of the matching influence data
+type InfluenceDataSub struct {
+	Dnns              []string        `json:"dnns,omitempty"`
+	Snssais           []models.Snssai `json:"snssais,omitempty"`
+	InternalGroupIds  []string        `json:"internalGroupIds,omitempty"`
+	Supis             []string        `json:"supis,omitempty"`
+	NotificationUri   string          `json:"notificationUri"`
+	Expiry            *time.Time      `json:"expiry,omitempty"`
+	SupportedFeatures string          `json:"supportedFeatures,omitempty"`
+}
+
+// TrafficInfluDataNotif reports a change of an influence data, the data is
+// absent when it was removed
+type TrafficInfluDataNotif struct {
+	ResUri           string            `json:"resUri"`
+	TrafficInfluData *TrafficInfluData `json:"trafficInfluData,omitempty"`
+}
+
+// InfluenceDataFilter selects the influence data by DNN, slice and target UEs,
+// an empty criterion matches everything
+type InfluenceDataFilter struct {
+	Dnns             []string
+	Snssais          []models.Snssai
+	InternalGroupIds []string
+	Supis            []string
+}
+
+func (f *InfluenceDataFilter) Matches(data *TrafficInfluData) bool {
+	if len(f.Dnns) > 0 && !slices.Contains(f.Dnns, data.Dnn) {
+		return false
+	}
+	if len(f.Snssais) > 0 && (data.Snssai == nil || !slices.Contains(f.Snssais, *data.Snssai)) {
+		return false
+	}
+	if len(f.InternalGroupIds) == 0 && len(f.Supis) == 0 {
+		return true
+	}
+	/*data for any UE applies to all the UEs and groups*/
+	return data.InterGroupId == AnyUeGroupId ||
+		(len(data.InterGroupId) > 0 && slices.Contains(f.InternalGroupIds, data.InterGroupId)) ||
+		(len(data.Supi) > 0 && slices.Contains(f.Supis, data.Supi))
+}
+
+func (sub *InfluenceDataSub) Filter() *InfluenceDataFilter {
+	return &InfluenceDataFilter{
+		Dnns:             sub.Dnns,
+		Snssais:          sub.Snssais,
+		InternalGroupIds: sub.InternalGroupIds,
+		Supis:            sub.Supis,
+	}
+}
+
+// ApplicationDataStore keeps the influence data provisioned by the NEF and
+// the subscriptions to their changes, standing in for the UDR application data
+type ApplicationDataStore interface {
+	GetInfluenceData(influenceId string) (*TrafficInfluData, error)
+	PutInfluenceData(influenceId string, data *TrafficInfluData) error
+	DeleteInfluenceData(influenceId string) error
+	QueryInfluenceData(filter *InfluenceDataFilter) ([]*TrafficInfluData, error)
+
+	PutInfluenceDataSub(subId string, sub *InfluenceDataSub) error
+	DeleteInfluenceDataSub(subId string) (bool, error)
+	InfluenceDataSubs() (map[string]*InfluenceDataSub, error)
+}
+
+// ------------------------------------------------------------------------------
+// MemoryAppDataStore keeps the application data for the lifetime of the process
+type MemoryAppDataStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	subs map[string][]byte
+}
+
+func NewMemoryAppDataStore() *MemoryAppDataStore {
+	return &MemoryAppDataStore{
+		data: make(map[string][]byte),
+		subs: make(map[string][]byte),
+	}
+}
+
+func (m *MemoryAppDataStore) GetInfluenceData(influenceId string) (*TrafficInfluData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bData, ok := m.data[influenceId]
+	if !ok {
+		return nil, nil
+	}
+	return unmarshalInfluenceData(bData)
+}
+
+func (m *MemoryAppDataStore) PutInfluenceData(influenceId string, data *TrafficInfluData) error {
+	/*records are stored serialized so that later changes to the data are not seen*/
+	bData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal influence data: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[influenceId] = bData
+	return nil
+}
+
+func (m *MemoryAppDataStore) DeleteInfluenceData(influenceId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, influenceId)
+	return nil
+}
+
+func (m *MemoryAppDataStore) QueryInfluenceData(filter *InfluenceDataFilter) ([]*TrafficInfluData, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []*TrafficInfluData{}
+	for _, bData := range m.data {
+		data, err := unmarshalInfluenceData(bData)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(data) {
+			result = append(result, data)
+		}
+	}
+	return result, nil
+}
+
+func (m *MemoryAppDataStore) PutInfluenceDataSub(subId string, sub *InfluenceDataSub) error {
+	bData, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal influence data subscription: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subs[subId] = bData
+	return nil
+}
+
+func (m *MemoryAppDataStore) DeleteInfluenceDataSub(subId string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.subs[subId]
+	delete(m.subs, subId)
+	return ok, nil
+}
+
+func (m *MemoryAppDataStore) InfluenceDataSubs() (map[string]*InfluenceDataSub, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	subs := make(map[string]*InfluenceDataSub)
+	for subId, bData := range m.subs {
+		sub := &InfluenceDataSub{}
+		if err := json.Unmarshal(bData, sub); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal influence data subscription: %w", err)
+		}
+		subs[subId] = sub
+	}
+	return subs, nil
+}
+
+// ------------------------------------------------------------------------------
+// RedisAppDataStore keeps each influence data as a JSON string under
+// <prefix>:influence-data:<influenceId> and each subscription to their
+// changes under <prefix>:influence-data-sub:<subId>
+type RedisAppDataStore struct {
+	redisClient *redis.Client
+	ctx         context.Context
+	prefix      string
+}
+
+func NewRedisAppDataStore(addr string, prefix string) *RedisAppDataStore {
+	return &RedisAppDataStore{
+		ctx:    context.Background(),
+		prefix: prefix,
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (r *RedisAppDataStore) dataKey(influenceId string) string {
+	return fmt.Sprintf("%s:influence-data:%s", r.prefix, influenceId)
+}
+
+func (r *RedisAppDataStore) subKey(subId string) string {
+	return fmt.Sprintf("%s:influence-data-sub:%s", r.prefix, subId)
+}
+
+func (r *RedisAppDataStore) GetInfluenceData(influenceId string) (*TrafficInfluData, error) {
+	bData, err := r.redisClient.Get(r.ctx, r.dataKey(influenceId)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load influence data: %w", err)
+	}
+	return unmarshalInfluenceData(bData)
+}
+
+func (r *RedisAppDataStore) PutInfluenceData(influenceId string, data *TrafficInfluData) error {
+	bData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal influence data: %w", err)
+	}
+	if err := r.redisClient.Set(r.ctx, r.dataKey(influenceId), bData, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store influence data: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisAppDataStore) DeleteInfluenceData(influenceId string) error {
+	if err := r.redisClient.Del(r.ctx, r.dataKey(influenceId)).Err(); err != nil {
+		return fmt.Errorf("failed to delete influence data: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisAppDataStore) QueryInfluenceData(filter *InfluenceDataFilter) ([]*TrafficInfluData, error) {
+	result := []*TrafficInfluData{}
+	err := r.scan(r.dataKey("*"), func(_ string, bData []byte) error {
+		data, err := unmarshalInfluenceData(bData)
+		if err != nil {
+			return err
+		}
+		if filter.Matches(data) {
+			result = append(result, data)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *RedisAppDataStore) PutInfluenceDataSub(subId string, sub *InfluenceDataSub) error {
+	bData, err := json.Marshal(sub)
+	if err != nil {
+		return fmt.Errorf("failed to marshal influence data subscription: %w", err)
+	}
+	if err := r.redisClient.Set(r.ctx, r.subKey(subId), bData, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store influence data subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisAppDataStore) DeleteInfluenceDataSub(subId string) (bool, error) {
+	n, err := r.redisClient.Del(r.ctx, r.subKey(subId)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete influence data subscription: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *RedisAppDataStore) InfluenceDataSubs() (map[string]*InfluenceDataSub, error) {
+	subs := make(map[string]*InfluenceDataSub)
+	keyPrefix := r.subKey("")
+	err := r.scan(keyPrefix+"*", func(key string, bData []byte) error {
+		sub := &InfluenceDataSub{}
+		if err := json.Unmarshal(bData, sub); err != nil {
+			return fmt.Errorf("failed to unmarshal influence data subscription %s: %w", key, err)
+		}
+		subs[key[len(keyPrefix):]] = sub
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// scan calls fn with the key and the value of each key matching the pattern
+func (r *RedisAppDataStore) scan(pattern string, fn func(key string, bData []byte) error) error {
+	iter := r.redisClient.Scan(r.ctx, 0, pattern, 0).Iterator()
+	for iter.Next(r.ctx) {
+		bData, err := r.redisClient.Get(r.ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			/*deleted since the scan*/
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to load influence data: %w", err)
+		}
+		if err := fn(iter.Val(), bData); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to scan influence data: %w", err)
+	}
+	return nil
+}
+
+func unmarshalInfluenceData(bData []byte) (*TrafficInfluData, error) {
+	data := &TrafficInfluData{}
+	if err := json.Unmarshal(bData, data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal influence data: %w", err)
+	}
+	return data, nil
+}
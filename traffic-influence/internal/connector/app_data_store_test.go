@@ -0,0 +1,125 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+func TestQueryInfluenceData(t *testing.T) {
+	store := NewMemoryAppDataStore()
+	mec := models.Snssai{Sst: 1, Sd: "000001"}
+	_ = store.PutInfluenceData("any", &TrafficInfluData{Dnn: "internet", Snssai: &mec, InterGroupId: AnyUeGroupId})
+	_ = store.PutInfluenceData("group", &TrafficInfluData{Dnn: "internet", InterGroupId: "group1"})
+	_ = store.PutInfluenceData("ims", &TrafficInfluData{Dnn: "ims", InterGroupId: "group1"})
+
+	tests := []struct {
+		name   string
+		filter InfluenceDataFilter
+		want   int
+	}{
+		{"no filter", InfluenceDataFilter{}, 3},
+		{"dnn", InfluenceDataFilter{Dnns: []string{"internet"}}, 2},
+		{"slice", InfluenceDataFilter{Snssais: []models.Snssai{mec}}, 1},
+		{"group", InfluenceDataFilter{InternalGroupIds: []string{"group1"}}, 3},
+		{"other group gets the any UE data", InfluenceDataFilter{InternalGroupIds: []string{"group2"}}, 1},
+		{"supi and dnn", InfluenceDataFilter{Dnns: []string{"ims"}, Supis: []string{"imsi-001010000000001"}}, 0},
+	}
+	for _, tt := range tests {
+		got, err := store.QueryInfluenceData(&tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: got %d influence data, wanted %d", tt.name, len(got), tt.want)
+		}
+	}
+}
+
+func TestNotifyInfluenceDataChange(t *testing.T) {
+	notifs := make(chan []TrafficInfluDataNotif, 2)
+	pcf := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []TrafficInfluDataNotif
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		notifs <- body
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer pcf.Close()
+
+	c := NewConnector(&testApp{cfg: &config.AppConfig{}})
+	_ = c.SubscribeInfluenceData("internet", &InfluenceDataSub{Dnns: []string{"internet"}, NotificationUri: pcf.URL})
+	_ = c.SubscribeInfluenceData("ims", &InfluenceDataSub{Dnns: []string{"ims"}, NotificationUri: pcf.URL})
+
+	data := &TrafficInfluData{Dnn: "internet", InterGroupId: AnyUeGroupId, ResUri: "http://nef/influenceData/1"}
+	if err := c.ProvisionInfluenceData("1", data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notif := <-notifs
+	if len(notif) != 1 || notif[0].ResUri != data.ResUri || notif[0].TrafficInfluData == nil {
+		t.Errorf("got notification %+v, wanted the provisioned data", notif)
+	}
+
+	/*moved to another DNN, the internet subscription is told of the removal*/
+	moved := &TrafficInfluData{Dnn: "ims", InterGroupId: AnyUeGroupId, ResUri: data.ResUri}
+	if err := c.ProvisionInfluenceData("1", moved); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	removed, updated := 0, 0
+	for range 2 {
+		notif = <-notifs
+		if len(notif) != 1 || notif[0].ResUri != data.ResUri {
+			t.Fatalf("got notification %+v, wanted one for %s", notif, data.ResUri)
+		}
+		if notif[0].TrafficInfluData == nil {
+			removed++
+		} else if notif[0].TrafficInfluData.Dnn == "ims" {
+			updated++
+		}
+	}
+	if removed != 1 || updated != 1 {
+		t.Errorf("got %d removal and %d update notifications, wanted one of each", removed, updated)
+	}
+	_ = c.ProvisionInfluenceData("1", data)
+	for range 2 {
+		<-notifs
+	}
+
+	if err := c.RemoveInfluenceData("1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notif = <-notifs
+	if len(notif) != 1 || notif[0].ResUri != data.ResUri || notif[0].TrafficInfluData != nil {
+		t.Errorf("got notification %+v, wanted the removal of %s", notif, data.ResUri)
+	}
+	if got, _ := c.QueryInfluenceData(&InfluenceDataFilter{}); len(got) != 0 {
+		t.Errorf("got %d influence data after removal, wanted 0", len(got))
+	}
+	select {
+	case notif := <-notifs:
+		t.Errorf("unexpected notification %+v to the ims subscription", notif)
+	default:
+	}
+}
@@ -19,7 +19,13 @@
 
 package connector
 
-import "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/identity"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/pcfselect"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
 
 type app interface {
 	Cfg() *config.AppConfig
@@ -27,9 +33,38 @@ type app interface {
 
 type Connector struct {
 	app
+	appData    ApplicationDataStore
+	groupStore GroupStore
+	pcf        *pcfselect.Selector
+	identity   *identity.Client
 }
 
 func NewConnector(app app) *Connector {
-	svc := &Connector{app: app}
+	svc := &Connector{
+		app:      app,
+		pcf:      pcfselect.NewSelector(pcfSelectConfig(app.Cfg()), pcfselect.NewMemoryBindingStore()),
+		identity: identity.NewClient(app.Cfg().Sbi.IdentitySvc, app.Cfg().Sbi.ProfileSvc),
+		appData:  NewMemoryAppDataStore(),
+	}
+	if app.Cfg().SubsStore == "redis" {
+		svc.pcf = pcfselect.NewSelector(pcfSelectConfig(app.Cfg()),
+			pcfselect.NewRedisBindingStore(app.Cfg().Sbi.RedisSvc, "traffic-influence"))
+		svc.appData = NewRedisAppDataStore(app.Cfg().Sbi.RedisSvc, "traffic-influence")
+	}
+	if len(app.Cfg().Sbi.RedisSvc) > 0 {
+		svc.groupStore = NewRedisGroupStore(app.Cfg().Sbi.RedisSvc)
+	}
 	return svc
 }
+
+// pcfSelectConfig returns where the PCFs of the app sessions are found
+func pcfSelectConfig(cfg *config.AppConfig) pcfselect.Config {
+	return pcfselect.Config{
+		UseNrf:      cfg.Sbi.UseNrf,
+		NrfSvc:      cfg.Sbi.NrfSvc,
+		PcfSvc:      cfg.Sbi.PcfSvc,
+		BsfSvc:      cfg.Sbi.BsfSvc,
+		Httpversion: cfg.Sbi.Httpversion,
+		CacheTtl:    time.Duration(cfg.Sbi.NrfCacheTtl) * time.Second,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// GroupStore translates an external group identifier into the internal group
+// identifier the influence data of the group are provisioned for
+type GroupStore interface {
+	GetInternalGroupId(extGroupId string) (string, error)
+}
+
+// RedisGroupStore keeps the internal group identifier of each external group
+// under group-id:<extGroupId>, beside the group:<extGroupId> member sets.
+type RedisGroupStore struct {
+	redisClient *redis.Client
+	ctx         context.Context
+}
+
+func NewRedisGroupStore(addr string) *RedisGroupStore {
+	return &RedisGroupStore{
+		ctx: context.Background(),
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (r *RedisGroupStore) GetInternalGroupId(extGroupId string) (string, error) {
+	key := fmt.Sprintf("group-id:%s", extGroupId)
+	interGroupId, err := r.redisClient.Get(r.ctx, key).Result()
+	if err == redis.Nil {
+		return "", nil
+	} else if err != nil {
+		log.Printf("GetInternalGroupId: Redis query failed for key=%s, error=%v", key, err)
+		return "", fmt.Errorf("failed to get internal group id: %w", err)
+	}
+	return interGroupId, nil
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) GetInternalGroupId(extGroupId string) (string, error) {
+	if c.groupStore == nil {
+		return "", fmt.Errorf("no group store configured")
+	}
+	return c.groupStore.GetInternalGroupId(extGroupId)
+}
+
+// ------------------------------------------------------------------------------
+func (c *Connector) HasGroupStore() bool {
+	return c.groupStore != nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+type testApp struct {
+	cfg *config.AppConfig
+}
+
+func (a *testApp) Cfg() *config.AppConfig {
+	return a.cfg
+}
+
+func TestGetUeProfile(t *testing.T) {
+	profile := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/ue-profile/v1/profiles/imsi-001010000000001" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"Imsi": "001010000000001", "PduSessions": {
+			"1": {"Id": 1, "Ipv4": "12.1.1.2", "Dnn": "ims", "Snssai": {"sst": 1}},
+			"2": {"Id": 2, "Ipv4": "12.1.1.3", "Dnn": "internet", "Snssai": {"sst": 1, "sd": "000001"}}
+		}}`))
+	}))
+	defer profile.Close()
+
+	c := NewConnector(&testApp{cfg: &config.AppConfig{Sbi: config.SbiConfig{ProfileSvc: profile.URL}}})
+
+	ueProfile, err := c.GetUeProfile("imsi-001010000000001")
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	session := ueProfile.GetSession("internet", models.Snssai{})
+	if session == nil || session.Ipv4 != "12.1.1.3" {
+		t.Errorf("got session %v, wanted the internet one", session)
+	}
+	if session := ueProfile.GetSession("internet", models.Snssai{Sst: 1, Sd: "000002"}); session != nil {
+		t.Errorf("got session %v on another slice, wanted none", session)
+	}
+
+	if _, err := c.GetUeProfile("imsi-001010000000002"); err == nil {
+		t.Errorf("expected an error for an unknown supi")
+	}
+}
@@ -25,16 +25,18 @@ import (
 	"log"
 	"strings"
 
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/pcfselect"
+	nrf_client "gitlab.eurecom.fr/open-exposure/nef/nrfclient"
 	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 )
 
 // ------------------------------------------------------------------------------
 func (c *Connector) CreatePolicyAuthzSubscription(pa_ctx pcfclient.AppSessionContext) (string, error) {
-	//1.Discover PCF
-	url := c.DiscoverPCFEndpoint()
-	if url == "" {
-		log.Printf("Could not find any availble PCF Instance")
-		return "", fmt.Errorf("no PCF available, ignoring")
+	//1.Select the PCF serving the session
+	url, err := c.pcf.Select(pcfSelectionOf(pa_ctx.GetAscReqData()))
+	if err != nil {
+		log.Printf("Could not find any availble PCF Instance: %v", err)
+		return "", fmt.Errorf("no PCF available: %w", err)
 	}
 	//2. Setup API Client and perform registration
 	configuration := pcfclient.NewConfiguration(url, c.Cfg().Sbi.Httpversion)
@@ -50,51 +52,44 @@ func (c *Connector) CreatePolicyAuthzSubscription(pa_ctx pcfclient.AppSessionCon
 	loc := r.Header.Get("Location")
 	appSessId := strings.Split(loc, "/app-sessions/")[1]
 	log.Printf("activated policy authorization subscription at %s", appSessId)
+	c.pcf.Bind(appSessId, url)
 
 	return appSessId, nil
 }
 
 // ------------------------------------------------------------------------------
-func (c *Connector) UpdatePolicyAuthzSubscription(AppSessId string, pa_ctx pcfclient.AppSessionContext) (string, error) {
-	//1.Discover PCF
-	url := c.DiscoverPCFEndpoint()
-	if url == "" {
-		log.Printf("Could not find any availble PCF Instance")
-		return "", fmt.Errorf("no PCF available, ignoring")
+// ModifyPolicyAuthzSubscription updates the app session in place, the PCF
+// keeps enforcing the previous policy when the modification is rejected
+func (c *Connector) ModifyPolicyAuthzSubscription(AppSessId string, patch pcfclient.AppSessionContextUpdateDataPatch) error {
+	//1.Find the PCF owning the session
+	url, err := c.pcf.EndpointOf(AppSessId)
+	if err != nil {
+		log.Printf("Could not find any availble PCF Instance: %v", err)
+		return fmt.Errorf("no PCF available: %w", err)
 	}
 
-	//2. Setup API Client
+	//2. Setup API Client and modify the app session
 	configuration := pcfclient.NewConfiguration(url, c.Cfg().Sbi.Httpversion)
 	pcfPolicyAuthClient := pcfclient.NewAPIClient(configuration)
-
-	//3. Delete current PCF subscription
-	_, r, err := pcfPolicyAuthClient.IndividualApplicationSessionContextDocumentAPI.DeleteAppSession(
-		context.Background(), AppSessId).Execute()
-
-	//4. Create new one if no errors
-	if err == nil {
-		_, r, err = pcfPolicyAuthClient.ApplicationSessionsCollectionAPI.PostAppSessions(
-			context.Background()).AppSessionContext(pa_ctx).Execute()
-		if err == nil {
-			loc := r.Header.Get("Location")
-			appSessId := strings.Split(loc, "/app-sessions/")[1]
-			log.Printf("updated policy authorization subscription, new id is %s", appSessId)
-			return appSessId, nil
-		}
+	_, r, err := pcfPolicyAuthClient.IndividualApplicationSessionContextDocumentAPI.ModAppSession(
+		context.Background(), AppSessId).AppSessionContextUpdateDataPatch(patch).Execute()
+	if err != nil {
+		log.Printf("cannot modify policy authorization subscription %s", AppSessId)
+		log.Printf("Full HTTP response: %v\n", r)
+		return err
 	}
+	log.Printf("modified policy authorization subscription at %s", AppSessId)
 
-	log.Printf("could not update policy authorization subscription \n")
-	log.Printf("Full HTTP response: %v\n", r)
-	return "", err
+	return nil
 }
 
 // ------------------------------------------------------------------------------
 func (c *Connector) RemovePolicyAuthzSubscription(AppSessId string) error {
-	//1.Discover PCF
-	url := c.DiscoverPCFEndpoint()
-	if url == "" {
-		log.Printf("Could not find any availble PCF Instance")
-		return fmt.Errorf("no PCF available, ignoring")
+	//1.Find the PCF owning the session
+	url, err := c.pcf.EndpointOf(AppSessId)
+	if err != nil {
+		log.Printf("Could not find any availble PCF Instance: %v", err)
+		return fmt.Errorf("no PCF available: %w", err)
 	}
 
 	//2. Setup API Client and perform registration
@@ -110,6 +105,24 @@ func (c *Connector) RemovePolicyAuthzSubscription(AppSessId string) error {
 		log.Printf("Full HTTP response: %v\n", r)
 		return err
 	}
+	c.pcf.Unbind(AppSessId)
 
 	return nil
 }
+
+// ------------------------------------------------------------------------------
+// pcfSelectionOf extracts the PCF selection parameters of an app session request
+func pcfSelectionOf(req pcfclient.AppSessionContextReqData) pcfselect.Selection {
+	sel := pcfselect.Selection{
+		Dnn:    req.GetDnn(),
+		UeIpv4: req.GetUeIpv4(),
+		Supi:   req.GetSupi(),
+	}
+	if req.SliceInfo != nil {
+		sel.Snssai = &nrf_client.Snssai{Sst: req.SliceInfo.Sst, Sd: req.SliceInfo.Sd}
+	}
+	if req.UeIpv6 != nil && req.UeIpv6.String != nil {
+		sel.UeIpv6Prefix = *req.UeIpv6.String
+	}
+	return sel
+}
@@ -0,0 +1,78 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// UpPathChEvent is the Nsmf_EventExposure event of a UP path change
+const UpPathChEvent = "UP_PATH_CH"
+
+// SmfEventNotification is the subset of the Nsmf_EventExposure
+// EventNotification (TS 29.508) reporting a UP path change
+type SmfEventNotification struct {
+	Event              string                  `json:"event"`
+	TimeStamp          *time.Time              `json:"timeStamp,omitempty"`
+	Supi               string                  `json:"supi,omitempty"`
+	Gpsi               string                  `json:"gpsi,omitempty"`
+	SourceDnai         string                  `json:"sourceDnai,omitempty"`
+	TargetDnai         string                  `json:"targetDnai,omitempty"`
+	DnaiChgType        models.DnaiChangeType   `json:"dnaiChgType,omitempty"`
+	SourceUeIpv4Addr   string                  `json:"sourceUeIpv4Addr,omitempty"`
+	SourceUeIpv6Prefix models.Ipv6Prefix       `json:"sourceUeIpv6Prefix,omitempty"`
+	TargetUeIpv4Addr   string                  `json:"targetUeIpv4Addr,omitempty"`
+	TargetUeIpv6Prefix models.Ipv6Prefix       `json:"targetUeIpv6Prefix,omitempty"`
+	SourceTraRouting   *models.RouteToLocation `json:"sourceTraRouting,omitempty"`
+	TargetTraRouting   *models.RouteToLocation `json:"targetTraRouting,omitempty"`
+	UeMac              string                  `json:"ueMac,omitempty"`
+	EasRediscoverInd   bool                    `json:"easRediscoverInd,omitempty"`
+}
+
+// NsmfEventExposureNotification is the notification the SMF sends to the
+// notification URI of the UP path change subscription
+type NsmfEventExposureNotification struct {
+	NotifId     string                 `json:"notifId"`
+	EventNotifs []SmfEventNotification `json:"eventNotifs"`
+}
+
+// AckOfNotify carries the AF acknowledgement back to the SMF, in the response
+// to its notification
+type AckOfNotify struct {
+	NotifId   string              `json:"notifId"`
+	AckResult models.AfResultInfo `json:"ackResult"`
+	Gpsi      string              `json:"gpsi,omitempty"`
+}
+
+// ------------------------------------------------------------------------------
+// NotifyUpPathChange posts the traffic influence event notification to the AF
+// and returns the acknowledgement it answered with, if any.
+func (c *Connector) NotifyUpPathChange(notificationDestination string, notif *models.EventNotification) (*models.AfAckInfo, error) {
+	ack := &models.AfAckInfo{}
+	if err := c.postNotification(notificationDestination, notif, ack); err != nil {
+		return nil, err
+	}
+	if len(ack.AckResult.AfStatus) == 0 {
+		return nil, nil
+	}
+	return ack, nil
+}
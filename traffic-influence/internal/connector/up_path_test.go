@@ -0,0 +1,70 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package connector
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+func TestNotifyUpPathChange(t *testing.T) {
+	received := make(chan models.EventNotification, 2)
+	af := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notif := models.EventNotification{}
+		_ = json.NewDecoder(r.Body).Decode(&notif)
+		received <- notif
+		if len(notif.AfAckUri) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(models.AfAckInfo{
+			AfTransId: notif.AfTransId,
+			AckResult: models.AfResultInfo{AfStatus: "SUCCESS"},
+		})
+	}))
+	defer af.Close()
+
+	c := NewConnector(&testApp{cfg: &config.AppConfig{}})
+
+	ack, err := c.NotifyUpPathChange(af.URL, &models.EventNotification{SourceDnai: "MEC1", TargetDnai: "MEC2"})
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if ack != nil {
+		t.Errorf("expected no acknowledgement on 204, got %v", ack)
+	}
+	if notif := <-received; notif.TargetDnai != "MEC2" {
+		t.Errorf("got targetDnai %s, wanted MEC2", notif.TargetDnai)
+	}
+
+	ack, err = c.NotifyUpPathChange(af.URL, &models.EventNotification{AfTransId: "tr1", AfAckUri: "http://nef/ack"})
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	<-received
+	if ack == nil || ack.AckResult.AfStatus != "SUCCESS" || ack.AfTransId != "tr1" {
+		t.Errorf("expected a SUCCESS acknowledgement for tr1, got %v", ack)
+	}
+}
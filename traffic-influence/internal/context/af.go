@@ -21,6 +21,7 @@ package contexts
 
 import (
 	"fmt"
+	"log"
 	"sync"
 
 	"github.com/google/uuid"
@@ -34,6 +35,7 @@ type AppFunctionCtx struct {
 	Mu      sync.RWMutex
 	lastId  uint64
 	TransId uint64
+	store   SubscriptionStore
 }
 
 func NewAf(afId string) *AppFunctionCtx {
@@ -58,14 +60,29 @@ func (afCtx *AppFunctionCtx) GetAfSubscription(subId string) *TraffInflSubscript
 	}
 }
 
+// GetIdempotentSubscription returns the subscription created with the given
+// idempotency key, nil if none
+func (afCtx *AppFunctionCtx) GetIdempotentSubscription(key string) *TraffInflSubscriptionCtx {
+	if len(key) == 0 {
+		return nil
+	}
+	for _, sub := range afCtx.subs {
+		if sub.IdempotencyKey == key {
+			return sub
+		}
+	}
+	return nil
+}
+
 func (afCtx *AppFunctionCtx) NewAfSubscription(data *models.TrafficInfluSub) (string, *TraffInflSubscriptionCtx) {
 
 	subId := afCtx.newSubscriptionId()
 	loc := createTraffInflLocation(afCtx.afId, subId)
 	sub := &TraffInflSubscriptionCtx{
-		subId: subId,
-		Data:  data,
-		loc:   loc,
+		subId:      subId,
+		Data:       data,
+		loc:        loc,
+		NotifToken: uuid.NewString(),
 	}
 	sub.Data.Self = subId
 	afCtx.subs[subId] = sub
@@ -76,12 +93,50 @@ func (afCtx *AppFunctionCtx) DeleteAfscription(subId string) error {
 	_, ok := afCtx.subs[subId]
 	if ok {
 		delete(afCtx.subs, subId)
+		if afCtx.store != nil {
+			if err := afCtx.store.Delete(afCtx.afId, subId); err != nil {
+				log.Printf("could not delete stored subscription %s: %s", subId, err)
+			}
+		}
 		return nil
 	} else {
 		return fmt.Errorf("could not find traffic influence subscription")
 	}
 }
 
+// SaveAfSubscription persists the current state of the subscription, to be
+// called once the subscription and its app session are set up or modified.
+func (afCtx *AppFunctionCtx) SaveAfSubscription(sub *TraffInflSubscriptionCtx) error {
+	if afCtx.store == nil {
+		return nil
+	}
+	return afCtx.store.Save(&SubscriptionRecord{
+		AfId:           afCtx.afId,
+		SubId:          sub.subId,
+		AppSessId:      sub.AppSessId,
+		TrInflId:       sub.TrInflId,
+		NotifToken:     sub.NotifToken,
+		IdempotencyKey: sub.IdempotencyKey,
+		RequestHash:    sub.RequestHash,
+		Data:           sub.Data,
+	})
+}
+
+func (afCtx *AppFunctionCtx) restoreAfSubscription(record *SubscriptionRecord) *TraffInflSubscriptionCtx {
+	sub := &TraffInflSubscriptionCtx{
+		subId:          record.SubId,
+		Data:           record.Data,
+		loc:            createTraffInflLocation(afCtx.afId, record.SubId),
+		AppSessId:      record.AppSessId,
+		TrInflId:       record.TrInflId,
+		NotifToken:     record.NotifToken,
+		IdempotencyKey: record.IdempotencyKey,
+		RequestHash:    record.RequestHash,
+	}
+	afCtx.subs[record.SubId] = sub
+	return sub
+}
+
 func (afCtx *AppFunctionCtx) newSubscriptionId() string {
 	return uuid.NewString()
 }
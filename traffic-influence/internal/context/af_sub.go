@@ -29,4 +29,13 @@ type TraffInflSubscriptionCtx struct {
 	loc       string
 	AppSessId string //for pcf
 	TrInflId  string //for udr
+
+	NotifToken string //random part of the UP path notification uri, so that it cannot be guessed from the subscription id
+
+	IdempotencyKey string //afTransId or Idempotency-Key of the creation request, if any
+	RequestHash    string //digest of the creation request body, to detect conflicting retries
+}
+
+func (sub *TraffInflSubscriptionCtx) Location() string {
+	return sub.loc
 }
@@ -0,0 +1,158 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package contexts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// SubscriptionRecord is the persisted state of a subscription, enough to
+// rebuild its context and find back its PCF app session after a restart.
+type SubscriptionRecord struct {
+	AfId           string                  `json:"afId"`
+	SubId          string                  `json:"subId"`
+	AppSessId      string                  `json:"appSessId,omitempty"`
+	TrInflId       string                  `json:"trInflId,omitempty"`
+	NotifToken     string                  `json:"notifToken,omitempty"`
+	IdempotencyKey string                  `json:"idempotencyKey,omitempty"`
+	RequestHash    string                  `json:"requestHash,omitempty"`
+	Data           *models.TrafficInfluSub `json:"data"`
+}
+
+// SubscriptionStore persists the subscriptions of the AFs
+type SubscriptionStore interface {
+	Save(record *SubscriptionRecord) error
+	Delete(afId string, subId string) error
+	LoadAll() ([]*SubscriptionRecord, error)
+}
+
+// ------------------------------------------------------------------------------
+// MemoryStore keeps the subscriptions in memory, they do not survive a restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string][]byte)}
+}
+
+func (m *MemoryStore) Save(record *SubscriptionRecord) error {
+	/*records are stored serialized so that later changes to the context are not seen*/
+	bData, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[record.AfId+"/"+record.SubId] = bData
+	return nil
+}
+
+func (m *MemoryStore) Delete(afId string, subId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, afId+"/"+subId)
+	return nil
+}
+
+func (m *MemoryStore) LoadAll() ([]*SubscriptionRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := []*SubscriptionRecord{}
+	for _, bData := range m.records {
+		record := &SubscriptionRecord{}
+		if err := json.Unmarshal(bData, record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal subscription: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// ------------------------------------------------------------------------------
+// RedisStore keeps each subscription as a JSON string under
+// <prefix>:subscription:<afId>:<subId>
+type RedisStore struct {
+	redisClient *redis.Client
+	ctx         context.Context
+	prefix      string
+}
+
+func NewRedisStore(addr string, prefix string) *RedisStore {
+	return &RedisStore{
+		ctx:    context.Background(),
+		prefix: prefix,
+		redisClient: redis.NewClient(&redis.Options{
+			Addr: addr,
+		}),
+	}
+}
+
+func (r *RedisStore) key(afId string, subId string) string {
+	return fmt.Sprintf("%s:subscription:%s:%s", r.prefix, afId, subId)
+}
+
+func (r *RedisStore) Save(record *SubscriptionRecord) error {
+	bData, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscription: %w", err)
+	}
+	if err := r.redisClient.Set(r.ctx, r.key(record.AfId, record.SubId), bData, 0).Err(); err != nil {
+		return fmt.Errorf("failed to store subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisStore) Delete(afId string, subId string) error {
+	if err := r.redisClient.Del(r.ctx, r.key(afId, subId)).Err(); err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisStore) LoadAll() ([]*SubscriptionRecord, error) {
+	records := []*SubscriptionRecord{}
+	iter := r.redisClient.Scan(r.ctx, 0, r.prefix+":subscription:*", 0).Iterator()
+	for iter.Next(r.ctx) {
+		bData, err := r.redisClient.Get(r.ctx, iter.Val()).Bytes()
+		if err == redis.Nil {
+			/*deleted since the scan*/
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to load subscription: %w", err)
+		}
+		record := &SubscriptionRecord{}
+		if err := json.Unmarshal(bData, record); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal subscription %s: %w", iter.Val(), err)
+		}
+		records = append(records, record)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan subscriptions: %w", err)
+	}
+	return records, nil
+}
@@ -25,11 +25,13 @@ type app interface {
 type TraffInflAppCtx struct {
 	app
 	AppFunc map[string]*AppFunctionCtx
+	store   SubscriptionStore
 }
 
-func NewTraffInflCtx(app app) *TraffInflAppCtx {
+func NewTraffInflCtx(app app, store SubscriptionStore) *TraffInflAppCtx {
 	ctx := &TraffInflAppCtx{app: app,
 		AppFunc: make(map[string]*AppFunctionCtx),
+		store:   store,
 	}
 	return ctx
 }
@@ -49,7 +51,27 @@ func (c *TraffInflAppCtx) AddAf(id string) *AppFunctionCtx {
 		return nil
 	} else {
 		newCtx := NewAf(id)
+		newCtx.store = c.store
 		c.AppFunc[id] = newCtx
 		return newCtx
 	}
 }
+
+// Restore reloads the subscriptions persisted in the store
+func (c *TraffInflAppCtx) Restore() error {
+	if c.store == nil {
+		return nil
+	}
+	records, err := c.store.LoadAll()
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		af := c.GetAf(record.AfId)
+		if af == nil {
+			af = c.AddAf(record.AfId)
+		}
+		af.restoreAfSubscription(record)
+	}
+	return nil
+}
@@ -28,6 +28,8 @@
 package models
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 )
 
@@ -91,6 +93,32 @@ type TrafficInfluSubPatch struct {
 	EventReq ReportingInformation `json:"eventReq,omitempty"`
 
 	TfcCorreInfo *TrafficCorrelationInfo `json:"tfcCorreInfo,omitempty"`
+
+	// attributes present in the decoded patch, null ones included
+	present map[string]json.RawMessage
+}
+
+// UnmarshalJSON decodes the patch and records its attributes, so that the
+// attributes set to null, false or zero can be told from the absent ones when
+// merging it (RFC 7396)
+func (obj *TrafficInfluSubPatch) UnmarshalJSON(data []byte) error {
+	type patch TrafficInfluSubPatch
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode((*patch)(obj)); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &obj.present)
+}
+
+// Provided tells whether the attribute is present in the patch, set to null
+// included. A patch not decoded from JSON provides the attributes it sets.
+func (obj *TrafficInfluSubPatch) Provided(attr string, set bool) bool {
+	if obj.present == nil {
+		return set
+	}
+	_, ok := obj.present[attr]
+	return ok
 }
 
 // AssertTrafficInfluSubPatchRequired checks if the required fields are not zero-ed
@@ -33,6 +33,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
 
 	"github.com/gorilla/mux"
@@ -356,7 +357,7 @@ func WithMaximum[T Number](expected T) Constraint[T] {
 // 	return values, nil
 // }
 
-// parseQuery parses query parameters and returns an error if any malformed value pairs are encountered.
-// func parseQuery(rawQuery string) (url.Values, error) {
-// 	return url.ParseQuery(rawQuery)
-// }
+// ParseQuery parses query parameters and returns an error if any malformed value pairs are encountered.
+func ParseQuery(rawQuery string) (url.Values, error) {
+	return url.ParseQuery(rawQuery)
+}
@@ -31,6 +31,7 @@ import (
 	"context"
 	"net/http"
 
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
 	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
 )
 
@@ -69,5 +70,34 @@ type IndividualTrafficInfluenceSubscriptionAPIServicer interface {
 // and updated with the logic required for the API.
 type TrafficInfluenceSubscriptionAPIServicer interface {
 	ReadAllSubscriptions(context.Context, string) (models.ImplResponse, error)
-	CreateNewSubscription(context.Context, string, models.TrafficInfluSub) (models.ImplResponse, error)
+	CreateNewSubscription(context.Context, string, string, models.TrafficInfluSub) (models.ImplResponse, error)
+}
+
+// ApplicationDataAPIRouter defines the required methods for binding the Nudr application data requests of the PCF
+// The ApplicationDataAPIRouter implementation should parse the influence data query or subscription
+// and pass it to an ApplicationDataAPIServicer.
+type ApplicationDataAPIRouter interface {
+	ReadInfluenceData(http.ResponseWriter, *http.Request)
+	CreateIndividualInfluenceDataSubscription(http.ResponseWriter, *http.Request)
+	DeleteIndividualInfluenceDataSubscription(http.ResponseWriter, *http.Request)
+}
+
+// ApplicationDataAPIServicer defines the api actions on the influence data provisioned by the NEF
+type ApplicationDataAPIServicer interface {
+	ReadInfluenceData(context.Context, []string, []models.Snssai, []string, []string) (models.ImplResponse, error)
+	CreateIndividualInfluenceDataSubscription(context.Context, connector.InfluenceDataSub) (models.ImplResponse, error)
+	DeleteIndividualInfluenceDataSubscription(context.Context, string) (models.ImplResponse, error)
+}
+
+// UpPathNotificationsAPIRouter defines the required methods for binding the UP path change notifications of the SMF
+// and the acknowledgements of the AF
+type UpPathNotificationsAPIRouter interface {
+	NotifyUpPathChange(http.ResponseWriter, *http.Request)
+	AcknowledgeUpPathChange(http.ResponseWriter, *http.Request)
+}
+
+// UpPathNotificationsAPIServicer defines the api actions relaying the UP path changes to the AF
+type UpPathNotificationsAPIServicer interface {
+	NotifyUpPathChange(context.Context, string, string, string, connector.NsmfEventExposureNotification) (models.ImplResponse, error)
+	AcknowledgeUpPathChange(context.Context, string, string, string, string, models.AfAckInfo) (models.ImplResponse, error)
 }
@@ -32,7 +32,9 @@ import (
 	"net/http"
 	"strings"
 
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
 	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/nbi/service"
 
 	"github.com/gorilla/mux"
 )
@@ -124,7 +126,8 @@ func (c *TrafficInfluenceSubscriptionAPIController) CreateNewSubscription(w http
 		c.errorHandler(w, r, err, nil)
 		return
 	}
-	result, err := c.service.CreateNewSubscription(r.Context(), afIdParam, trafficInfluSubParam)
+	idempotencyKeyParam := r.Header.Get("Idempotency-Key")
+	result, err := c.service.CreateNewSubscription(r.Context(), afIdParam, idempotencyKeyParam, trafficInfluSubParam)
 	// If an error occurred, encode the error with the status code
 	if err != nil {
 		c.errorHandler(w, r, err, &result)
@@ -312,3 +315,208 @@ func (c *IndividualTrafficInfluenceSubscriptionAPIController) PartialUpdateAnSub
 	// If no error, encode the body and the result code
 	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
 }
+
+// ApplicationDataAPIController binds the Nudr application data requests to an api service and writes the service results to the http response
+type ApplicationDataAPIController struct {
+	service      ApplicationDataAPIServicer
+	errorHandler models.ErrorHandler
+}
+
+// NewApplicationDataAPIController creates a default api controller
+func NewApplicationDataAPIController(s ApplicationDataAPIServicer) *ApplicationDataAPIController {
+	return &ApplicationDataAPIController{
+		service:      s,
+		errorHandler: models.DefaultErrorHandler,
+	}
+}
+
+// Routes returns all the api routes for the ApplicationDataAPIController
+func (c *ApplicationDataAPIController) Routes() models.Routes {
+	return models.Routes{
+		"ReadInfluenceData": models.Route{
+			Method:      strings.ToUpper("Get"),
+			Pattern:     service.AppDataPath,
+			HandlerFunc: c.ReadInfluenceData,
+		},
+		"CreateIndividualInfluenceDataSubscription": models.Route{
+			Method:      strings.ToUpper("Post"),
+			Pattern:     service.AppDataPath + "/subs-to-notify",
+			HandlerFunc: c.CreateIndividualInfluenceDataSubscription,
+		},
+		"DeleteIndividualInfluenceDataSubscription": models.Route{
+			Method:      strings.ToUpper("Delete"),
+			Pattern:     service.AppDataPath + "/subs-to-notify/{subscriptionId}",
+			HandlerFunc: c.DeleteIndividualInfluenceDataSubscription,
+		},
+	}
+}
+
+// ReadInfluenceData - Retrieves the influence data matching the dnns, snssais, internal group ids and supis.
+func (c *ApplicationDataAPIController) ReadInfluenceData(w http.ResponseWriter, r *http.Request) {
+	query, err := models.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	var dnnsParam []string
+	if query.Has("dnns") {
+		dnnsParam = strings.Split(query.Get("dnns"), ",")
+	}
+	var snssaisParam []models.Snssai
+	if query.Has("snssais") {
+		if err := json.Unmarshal([]byte(query.Get("snssais")), &snssaisParam); err != nil {
+			c.errorHandler(w, r, &models.ParsingError{Param: "snssais", Err: err}, nil)
+			return
+		}
+	}
+	var internalGroupIdsParam []string
+	if query.Has("internal-Group-Ids") {
+		internalGroupIdsParam = strings.Split(query.Get("internal-Group-Ids"), ",")
+	}
+	var supisParam []string
+	if query.Has("supis") {
+		supisParam = strings.Split(query.Get("supis"), ",")
+	}
+	result, err := c.service.ReadInfluenceData(r.Context(), dnnsParam, snssaisParam, internalGroupIdsParam, supisParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// CreateIndividualInfluenceDataSubscription - Subscribes to the changes of the influence data.
+func (c *ApplicationDataAPIController) CreateIndividualInfluenceDataSubscription(w http.ResponseWriter, r *http.Request) {
+	influenceDataSubParam := connector.InfluenceDataSub{}
+	d := json.NewDecoder(r.Body)
+	if err := d.Decode(&influenceDataSubParam); err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.CreateIndividualInfluenceDataSubscription(r.Context(), influenceDataSubParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	w.Header().Add("Location", result.Location)
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// DeleteIndividualInfluenceDataSubscription - Removes a subscription to the changes of the influence data.
+func (c *ApplicationDataAPIController) DeleteIndividualInfluenceDataSubscription(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	subscriptionIdParam := params["subscriptionId"]
+	result, err := c.service.DeleteIndividualInfluenceDataSubscription(r.Context(), subscriptionIdParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// UpPathNotificationsAPIController binds the UP path change notifications to an api service and writes the service results to the http response
+type UpPathNotificationsAPIController struct {
+	service      UpPathNotificationsAPIServicer
+	errorHandler models.ErrorHandler
+}
+
+// NewUpPathNotificationsAPIController creates a default api controller
+func NewUpPathNotificationsAPIController(s UpPathNotificationsAPIServicer) *UpPathNotificationsAPIController {
+	return &UpPathNotificationsAPIController{
+		service:      s,
+		errorHandler: models.DefaultErrorHandler,
+	}
+}
+
+// Routes returns all the api routes for the UpPathNotificationsAPIController
+func (c *UpPathNotificationsAPIController) Routes() models.Routes {
+	return models.Routes{
+		"NotifyUpPathChange": models.Route{
+			Method:      strings.ToUpper("Post"),
+			Pattern:     service.UpPathNotificationsPath + "/{afId}/subscriptions/{subscriptionId}/{notifToken}",
+			HandlerFunc: c.NotifyUpPathChange,
+		},
+	}
+}
+
+// AfAckAPIController binds the AF acknowledgements of the UP path changes to an api service and writes the service results to the http response
+type AfAckAPIController struct {
+	service      UpPathNotificationsAPIServicer
+	errorHandler models.ErrorHandler
+}
+
+// NewAfAckAPIController creates a default api controller
+func NewAfAckAPIController(s UpPathNotificationsAPIServicer) *AfAckAPIController {
+	return &AfAckAPIController{
+		service:      s,
+		errorHandler: models.DefaultErrorHandler,
+	}
+}
+
+// Routes returns all the api routes for the AfAckAPIController
+func (c *AfAckAPIController) Routes() models.Routes {
+	return models.Routes{
+		"AcknowledgeUpPathChange": models.Route{
+			Method:      strings.ToUpper("Post"),
+			Pattern:     "/3gpp-traffic-influence/v1/{afId}/subscriptions/{subscriptionId}/ack",
+			HandlerFunc: c.AcknowledgeUpPathChange,
+		},
+	}
+}
+
+// NotifyUpPathChange - Receives the UP path change events of the SMF for a subscription.
+func (c *UpPathNotificationsAPIController) NotifyUpPathChange(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	afIdParam := params["afId"]
+	subscriptionIdParam := params["subscriptionId"]
+	notifTokenParam := params["notifToken"]
+	notificationParam := connector.NsmfEventExposureNotification{}
+	d := json.NewDecoder(r.Body)
+	if err := d.Decode(&notificationParam); err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	result, err := c.service.NotifyUpPathChange(r.Context(), afIdParam, subscriptionIdParam, notifTokenParam, notificationParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
+
+// AcknowledgeUpPathChange - Receives the AF acknowledgement of a UP path change notification.
+func (c *AfAckAPIController) AcknowledgeUpPathChange(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	query := r.URL.Query()
+	afIdParam := params["afId"]
+	subscriptionIdParam := params["subscriptionId"]
+	notifIdParam := query.Get("notifId")
+	ueParam := query.Get("ue")
+	afAckInfoParam := models.AfAckInfo{}
+	d := json.NewDecoder(r.Body)
+	d.DisallowUnknownFields()
+	if err := d.Decode(&afAckInfoParam); err != nil {
+		c.errorHandler(w, r, &models.ParsingError{Err: err}, nil)
+		return
+	}
+	if err := models.AssertAfAckInfoRequired(afAckInfoParam); err != nil {
+		c.errorHandler(w, r, err, nil)
+		return
+	}
+	result, err := c.service.AcknowledgeUpPathChange(r.Context(), afIdParam, subscriptionIdParam, notifIdParam, ueParam, afAckInfoParam)
+	// If an error occurred, encode the error with the status code
+	if err != nil {
+		c.errorHandler(w, r, err, &result)
+		return
+	}
+	// If no error, encode the body and the result code
+	_ = models.EncodeJSONResponse(result.Body, &result.Code, w)
+}
@@ -30,8 +30,10 @@ package nbi
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
 	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/nbi/service"
 )
@@ -51,6 +53,14 @@ type IndividualTrafficInfluenceSubscriptionAPIService struct {
 	serverCtx
 }
 
+type ApplicationDataAPIService struct {
+	serverCtx
+}
+
+type UpPathNotificationsAPIService struct {
+	serverCtx
+}
+
 // NewTrafficInfluenceSubscriptionAPIService creates a default api service
 func NewTrafficInfluenceSubscriptionAPIService(srv serverCtx) *TrafficInfluenceSubscriptionAPIService {
 	return &TrafficInfluenceSubscriptionAPIService{serverCtx: srv}
@@ -61,6 +71,16 @@ func NewIndividualTrafficInfluenceSubscriptionAPIService(srv serverCtx) *Individ
 	return &IndividualTrafficInfluenceSubscriptionAPIService{serverCtx: srv}
 }
 
+// NewApplicationDataAPIService creates a default api service
+func NewApplicationDataAPIService(srv serverCtx) *ApplicationDataAPIService {
+	return &ApplicationDataAPIService{serverCtx: srv}
+}
+
+// NewUpPathNotificationsAPIService creates a default api service
+func NewUpPathNotificationsAPIService(srv serverCtx) *UpPathNotificationsAPIService {
+	return &UpPathNotificationsAPIService{serverCtx: srv}
+}
+
 // ReadAllSubscriptions - read all of the active subscriptions for the AF
 func (s *TrafficInfluenceSubscriptionAPIService) ReadAllSubscriptions(ctx context.Context, afId string) (models.ImplResponse, error) {
 	subs, status, err := s.Service().GetAllTrafficInfluenceSub(afId)
@@ -74,10 +94,10 @@ func (s *IndividualTrafficInfluenceSubscriptionAPIService) ReadAnSubscription(ct
 }
 
 // TODO CreateNewSubscription - Creates a new subscription resource
-func (s *TrafficInfluenceSubscriptionAPIService) CreateNewSubscription(ctx context.Context, afId string, trafficInfluSub models.TrafficInfluSub) (models.ImplResponse, error) {
-	loc, status, err := s.Service().CreateTrafficInfluenceSub(afId, &trafficInfluSub)
+func (s *TrafficInfluenceSubscriptionAPIService) CreateNewSubscription(ctx context.Context, afId string, idempotencyKey string, trafficInfluSub models.TrafficInfluSub) (models.ImplResponse, error) {
+	loc, status, err := s.Service().CreateTrafficInfluenceSub(afId, idempotencyKey, &trafficInfluSub)
 	if err != nil {
-		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+		return models.Response(status, nil, ""), fmt.Errorf("Error: %w", err)
 	}
 	return models.Response(status, trafficInfluSub, loc), nil
 }
@@ -91,16 +111,73 @@ func (s *IndividualTrafficInfluenceSubscriptionAPIService) DeleteAnSubscription(
 	return models.Response(http.StatusNotFound, nil, ""), err
 }
 
-// TODO PartialUpdateAnSubscription - Partially updates/replaces an existing subscription resource
+// PartialUpdateAnSubscription - Partially updates/replaces an existing subscription resource
 func (s *IndividualTrafficInfluenceSubscriptionAPIService) PartialUpdateAnSubscription(ctx context.Context, afId string, subscriptionId string, trafficInfluSubPatch models.TrafficInfluSubPatch) (models.ImplResponse, error) {
-	return models.Response(http.StatusNotImplemented, nil, ""), errors.New("PartialUpdateAnSubscription method not implemented")
+	sub, code, err := s.Service().ModifyTrafficInfluenceSub(afId, subscriptionId, &trafficInfluSubPatch)
+	if err == nil {
+		return models.Response(code, sub, ""), nil
+	}
+	return models.Response(code, nil, ""), fmt.Errorf("Error: %w", err)
 }
 
 // FullyUpdateAnSubscription - Fully updates/replaces an existing subscription resource
 func (s *IndividualTrafficInfluenceSubscriptionAPIService) FullyUpdateAnSubscription(ctx context.Context, afId string, subscriptionId string, trafficInfluSub models.TrafficInfluSub) (models.ImplResponse, error) {
-	code, err := s.Service().UpdateTrafficInfluenceSub(afId, subscriptionId, &trafficInfluSub)
+	sub, code, err := s.Service().UpdateTrafficInfluenceSub(afId, subscriptionId, &trafficInfluSub)
 	if err == nil {
-		return models.Response(code, nil, ""), nil
+		return models.Response(code, sub, ""), nil
+	}
+	return models.Response(code, nil, ""), fmt.Errorf("Error: %w", err)
+}
+
+// ReadInfluenceData - Retrieves the influence data provisioned for any UE or groups of UEs
+func (s *ApplicationDataAPIService) ReadInfluenceData(ctx context.Context, dnns []string, snssais []models.Snssai, internalGroupIds []string, supis []string) (models.ImplResponse, error) {
+	influData, status, err := s.Service().QueryInfluenceData(&connector.InfluenceDataFilter{
+		Dnns:             dnns,
+		Snssais:          snssais,
+		InternalGroupIds: internalGroupIds,
+		Supis:            supis,
+	})
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, influData, ""), nil
+}
+
+// CreateIndividualInfluenceDataSubscription - Subscribes to the changes of the influence data
+func (s *ApplicationDataAPIService) CreateIndividualInfluenceDataSubscription(ctx context.Context, influenceDataSub connector.InfluenceDataSub) (models.ImplResponse, error) {
+	loc, status, err := s.Service().CreateInfluenceDataSub(&influenceDataSub)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, influenceDataSub, loc), nil
+}
+
+// DeleteIndividualInfluenceDataSubscription - Removes a subscription to the changes of the influence data
+func (s *ApplicationDataAPIService) DeleteIndividualInfluenceDataSubscription(ctx context.Context, subscriptionId string) (models.ImplResponse, error) {
+	status, err := s.Service().DeleteInfluenceDataSub(subscriptionId)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	return models.Response(status, nil, ""), nil
+}
+
+// NotifyUpPathChange - Relays the UP path change events of the SMF to the AF
+func (s *UpPathNotificationsAPIService) NotifyUpPathChange(ctx context.Context, afId string, subscriptionId string, notifToken string, notification connector.NsmfEventExposureNotification) (models.ImplResponse, error) {
+	acks, status, err := s.Service().UpPathChangeNotification(afId, subscriptionId, notifToken, &notification)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
+	}
+	if len(acks) == 0 {
+		return models.Response(status, nil, ""), nil
+	}
+	return models.Response(status, acks, ""), nil
+}
+
+// AcknowledgeUpPathChange - Delivers the AF acknowledgement to the pending UP path change notification
+func (s *UpPathNotificationsAPIService) AcknowledgeUpPathChange(ctx context.Context, afId string, subscriptionId string, notifId string, ue string, afAckInfo models.AfAckInfo) (models.ImplResponse, error) {
+	status, err := s.Service().AcknowledgeUpPathChange(afId, subscriptionId, notifId, ue, &afAckInfo)
+	if err != nil {
+		return models.Response(status, nil, ""), errors.New("Error: " + err.Error())
 	}
-	return models.Response(code, nil, ""), err
+	return models.Response(status, nil, ""), nil
 }
@@ -20,6 +20,7 @@
 package nbi
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -27,6 +28,9 @@ import (
 
 	"github.com/gorilla/mux"
 	libcapif "gitlab.eurecom.fr/open-exposure/nef/libcapif"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/invoker"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/ratelimit"
 	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/nbi/service"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
@@ -35,25 +39,46 @@ import (
 type appCtx interface {
 	Cfg() *config.AppConfig
 	Service() *service.Service
+	Policies() *afpolicy.Registry
 }
 
 type NbiServer struct {
 	appCtx
-	router   *mux.Router
-	capifCtx *libcapif.CapifConnector
-	server   *http.Server
+	router    *mux.Router
+	sbiRouter *mux.Router
+	capifCtx  *libcapif.CapifConnector
+	limiter   *ratelimit.Limiter
+	server    *http.Server
+	sbiServer *http.Server /*application data and SMF callbacks, kept off the nbi port exposed to the AFs*/
 }
 
 func NewNorthbound(app appCtx) (*NbiServer, error) {
 	nbi := &NbiServer{appCtx: app}
+	limiter, err := newRateLimiter(nbi.Cfg().Limits, len(nbi.Cfg().CapifSvc) > 0, nbi.Policies())
+	if err != nil {
+		return nil, err
+	}
+	nbi.limiter = limiter
 	IndividualTrafficInfluenceSubscriptionAPIService := NewIndividualTrafficInfluenceSubscriptionAPIService(nbi)
 	IndividualTrafficInfluenceSubscriptionAPIController := NewIndividualTrafficInfluenceSubscriptionAPIController(IndividualTrafficInfluenceSubscriptionAPIService)
 
 	TrafficInfluenceSubscriptionAPIService := NewTrafficInfluenceSubscriptionAPIService(nbi)
 	TrafficInfluenceSubscriptionAPIController := NewTrafficInfluenceSubscriptionAPIController(TrafficInfluenceSubscriptionAPIService)
 
-	nbi.router = models.NewRouter(IndividualTrafficInfluenceSubscriptionAPIController, TrafficInfluenceSubscriptionAPIController)
+	/*the AF acknowledges the UP path changes under its subscription*/
+	UpPathNotificationsAPIService := NewUpPathNotificationsAPIService(nbi)
+	AfAckAPIController := NewAfAckAPIController(UpPathNotificationsAPIService)
+
+	nbi.router = models.NewRouter(IndividualTrafficInfluenceSubscriptionAPIController, TrafficInfluenceSubscriptionAPIController, AfAckAPIController)
 
+	/*Application data consumed by the PCF and UP path notifications of the SMF are served on their own sbi listener*/
+	ApplicationDataAPIService := NewApplicationDataAPIService(nbi)
+	ApplicationDataAPIController := NewApplicationDataAPIController(ApplicationDataAPIService)
+	UpPathNotificationsAPIController := NewUpPathNotificationsAPIController(UpPathNotificationsAPIService)
+	nbi.sbiRouter = models.NewRouter(ApplicationDataAPIController, UpPathNotificationsAPIController)
+
+	/*only the invoker of a token validated by CAPIF is trusted*/
+	nbi.router.Use(invoker.Strip)
 	if len(nbi.Cfg().CapifSvc) > 0 {
 		nbi.capifCtx = libcapif.NewConnector(nbi.Cfg().CapifSvc)
 		nbi.capifCtx.InstantiateConnector("traffic-influence", "v1", "HTTP_1_1")
@@ -67,13 +92,82 @@ func NewNorthbound(app appCtx) (*NbiServer, error) {
 		for rName, route := range TrafficInfluenceSubscriptionAPIController.Routes() {
 			nbi.capifCtx.AddEndpoint(route.Pattern, "SUBSCRIBE_NOTIFY", []string{route.Method}, rName)
 		}
+		for rName, route := range AfAckAPIController.Routes() {
+			nbi.capifCtx.AddEndpoint(route.Pattern, "SUBSCRIBE_NOTIFY", []string{route.Method}, rName)
+		}
 		nbi.capifCtx.AddInterface(nbi.Cfg().Nbi.Fqdn, int32(nbi.Cfg().Nbi.Port))
+	} else if nbi.Policies().BindsInvokers() {
+		log.Printf("capif svc not defined, the AFs bound to API invokers are rejected")
 	}
+	/*after the CAPIF middleware, which sets the InvokerId of the request*/
+	nbi.router.Use(invoker.Bind)
+	nbi.router.Use(nbi.rateLimitMiddleware)
+	nbi.router.Use(nbi.afPolicyMiddleware)
 
 	nbi.server = &http.Server{Addr: ":" + strconv.FormatUint(uint64(nbi.Cfg().Nbi.Port), 10), Handler: nbi.router}
+	nbi.sbiServer = &http.Server{Addr: ":" + strconv.FormatUint(uint64(nbi.Cfg().Sbi.Port), 10), Handler: nbi.sbiRouter}
 	return nbi, nil
 }
 
+// afPolicyMiddleware rejects the requests of the AFs without a policy, or
+// coming from an API invoker the AF is not bound to
+func (n *NbiServer) afPolicyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := n.Policies().Authorize(mux.Vars(r)["afId"], invoker.Of(r))
+		var violation *afpolicy.Violation
+		if errors.As(err, &violation) {
+			models.DefaultErrorHandler(w, r, &models.ProblemError{Problem: models.ProblemDetails{
+				Title:         "Forbidden",
+				Detail:        "request not allowed by the AF policy",
+				InvalidParams: []models.InvalidParam{{Param: violation.Param, Reason: violation.Reason}},
+			}}, &models.ImplResponse{Code: http.StatusForbidden})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newRateLimiter builds the token buckets of the configured limits, refusing
+// the per-AF rates of the AFs not bound to a CAPIF invoker, which would never
+// apply
+func newRateLimiter(limits config.LimitsConfig, capif bool, policies *afpolicy.Registry) (*ratelimit.Limiter, error) {
+	overrides := make(map[string]ratelimit.Limit)
+	for afId, af := range limits.Afs {
+		if af.Rate <= 0 && af.Burst <= 0 {
+			continue
+		}
+		limit := limits.Of(afId)
+		overrides[afId] = ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	if err := ratelimit.CheckOverrides(overrides, capif, policies); err != nil {
+		return nil, err
+	}
+	return ratelimit.NewLimiter(ratelimit.Limit{Rate: limits.Rate, Burst: limits.Burst}, overrides), nil
+}
+
+// rateLimitMiddleware rejects the requests of the AF above its rate with a 429
+// telling when to retry
+func (n *NbiServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		/*the afId of the url only keys the bucket once bound to the invoker*/
+		afId := mux.Vars(r)["afId"]
+		if !n.Policies().Binds(afId, invoker.Of(r)) {
+			afId = ""
+		}
+		if ok, wait := n.limiter.Allow(ratelimit.ClientOf(r, afId)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+			status := http.StatusTooManyRequests
+			_ = models.EncodeJSONResponse(models.ProblemDetails{
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "request rate of the AF exceeded",
+			}, &status, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (n *NbiServer) startListening(wg *sync.WaitGroup) {
 	defer func() {
 		_ = recover()
@@ -95,6 +189,20 @@ func (n *NbiServer) startListening(wg *sync.WaitGroup) {
 
 }
 
+func (n *NbiServer) startSbiListening(wg *sync.WaitGroup) {
+	defer func() {
+		_ = recover()
+		wg.Done()
+	}()
+
+	log.Printf("sbi http server started")
+	err := n.sbiServer.ListenAndServe()
+
+	if err != nil && err != http.ErrServerClosed {
+		log.Default().Printf("could not start sbi http server")
+	}
+}
+
 func (n *NbiServer) Stop() {
 	if n.server != nil {
 		err := n.server.Close()
@@ -102,6 +210,11 @@ func (n *NbiServer) Stop() {
 			log.Default().Printf("could not stop nbi server")
 		}
 	}
+	if n.sbiServer != nil {
+		if err := n.sbiServer.Close(); err != nil {
+			log.Default().Printf("could not stop sbi server")
+		}
+	}
 
 	if n.capifCtx != nil {
 		_ = n.capifCtx.DeleteService()
@@ -110,7 +223,8 @@ func (n *NbiServer) Stop() {
 }
 
 func (n *NbiServer) Run(wg *sync.WaitGroup) {
-	wg.Add(1)
+	wg.Add(2)
 	go n.startListening(wg)
+	go n.startSbiListening(wg)
 
 }
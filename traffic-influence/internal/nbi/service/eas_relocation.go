@@ -0,0 +1,194 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"reflect"
+
+	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// validateEasRelocation checks the EAS relocation and IP replacement
+// attributes, each offending attribute is reported as InvalidParam.
+func validateEasRelocation(data *models.TrafficInfluSub) error {
+	invalidParams := []models.InvalidParam{}
+	for i, easIpReplaceInfo := range data.EasIpReplaceInfos {
+		if reason := validateEasServerAddress(easIpReplaceInfo.Source); len(reason) > 0 {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/easIpReplaceInfos/%d/source", i),
+				Reason: reason,
+			})
+		}
+		if reason := validateEasServerAddress(easIpReplaceInfo.Target); len(reason) > 0 {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/easIpReplaceInfos/%d/target", i),
+				Reason: reason,
+			})
+		}
+	}
+	if data.SimConnTerm < 0 {
+		invalidParams = append(invalidParams, models.InvalidParam{
+			Param:  "/simConnTerm",
+			Reason: "simConnTerm must not be negative",
+		})
+	}
+	if data.SimConnTerm > 0 && !data.SimConnInd {
+		invalidParams = append(invalidParams, models.InvalidParam{
+			Param:  "/simConnTerm",
+			Reason: "simConnTerm requires simConnInd",
+		})
+	}
+	if data.MaxAllowedUpLat < 0 {
+		invalidParams = append(invalidParams, models.InvalidParam{
+			Param:  "/maxAllowedUpLat",
+			Reason: "maxAllowedUpLat must not be negative",
+		})
+	}
+
+	if len(invalidParams) > 0 {
+		return &models.ProblemError{Problem: models.ProblemDetails{
+			Title:         "Invalid EAS relocation",
+			Detail:        "invalid EAS relocation information",
+			InvalidParams: invalidParams,
+		}}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// validateEasServerAddress returns why the EAS address is invalid, empty if
+// it is valid: a single IPv4 or IPv6 address and a port.
+func validateEasServerAddress(easAddr models.EasServerAddress) string {
+	if easAddr.Ip == nil {
+		return "ip not provided"
+	}
+	if len(easAddr.Ip.Ipv6Prefix) > 0 {
+		return "an EAS address cannot be an IPv6 prefix"
+	}
+	switch {
+	case len(easAddr.Ip.Ipv4Addr) > 0 && len(easAddr.Ip.Ipv6Addr) > 0:
+		return "only one of ipv4Addr and ipv6Addr can be provided"
+	case len(easAddr.Ip.Ipv4Addr) > 0:
+		if ip := net.ParseIP(easAddr.Ip.Ipv4Addr); ip == nil || ip.To4() == nil {
+			return fmt.Sprintf("invalid ipv4Addr %s", easAddr.Ip.Ipv4Addr)
+		}
+	case len(easAddr.Ip.Ipv6Addr) > 0:
+		if ip := net.ParseIP(string(easAddr.Ip.Ipv6Addr)); ip == nil || ip.To4() != nil {
+			return fmt.Sprintf("invalid ipv6Addr %s", easAddr.Ip.Ipv6Addr)
+		}
+	default:
+		return "ip not provided"
+	}
+	if easAddr.Port < 0 || easAddr.Port > 65535 {
+		return fmt.Sprintf("invalid port %d", easAddr.Port)
+	}
+	return ""
+}
+
+// ------------------------------------------------------------------------------
+// easRelocation2RoutingRequirement sets the EAS relocation attributes of the
+// routing requirement.
+func easRelocation2RoutingRequirement(data *models.TrafficInfluSub, routReq *pcfclient.AfRoutingRequirement) {
+	if data.AddrPreserInd {
+		routReq.SetAddrPreserInd(true)
+	}
+	if data.SimConnInd {
+		routReq.SetSimConnInd(true)
+		if data.SimConnTerm > 0 {
+			routReq.SetSimConnTerm(data.SimConnTerm)
+		}
+	}
+	if data.MaxAllowedUpLat > 0 {
+		routReq.SetMaxAllowedUpLat(data.MaxAllowedUpLat)
+	}
+	if len(data.EasIpReplaceInfos) > 0 {
+		routReq.SetEasIpReplaceInfos(easIpReplaceInfos2Pcf(data.EasIpReplaceInfos))
+	}
+	if data.EasRedisInd {
+		routReq.SetEasRedisInd(true)
+	}
+}
+
+// ------------------------------------------------------------------------------
+// easRelocation2RoutingRequirementRm sets the changed EAS relocation
+// attributes of the routing requirement update, and tells whether any did.
+func easRelocation2RoutingRequirementRm(prev *models.TrafficInfluSub, data *models.TrafficInfluSub, routReq *pcfclient.AfRoutingRequirementRm) bool {
+	changed := false
+	if prev.AddrPreserInd != data.AddrPreserInd {
+		routReq.SetAddrPreserInd(data.AddrPreserInd)
+		changed = true
+	}
+	if prev.SimConnInd != data.SimConnInd || prev.SimConnTerm != data.SimConnTerm {
+		routReq.SetSimConnInd(data.SimConnInd)
+		if data.SimConnInd && data.SimConnTerm > 0 {
+			routReq.SetSimConnTerm(data.SimConnTerm)
+		} else {
+			routReq.SetSimConnTermNil()
+		}
+		changed = true
+	}
+	if prev.MaxAllowedUpLat != data.MaxAllowedUpLat {
+		if data.MaxAllowedUpLat > 0 {
+			routReq.SetMaxAllowedUpLat(data.MaxAllowedUpLat)
+		} else {
+			routReq.SetMaxAllowedUpLatNil()
+		}
+		changed = true
+	}
+	if !reflect.DeepEqual(prev.EasIpReplaceInfos, data.EasIpReplaceInfos) {
+		routReq.SetEasIpReplaceInfos(easIpReplaceInfos2Pcf(data.EasIpReplaceInfos))
+		changed = true
+	}
+	if prev.EasRedisInd != data.EasRedisInd {
+		routReq.SetEasRedisInd(data.EasRedisInd)
+		changed = true
+	}
+	return changed
+}
+
+// ------------------------------------------------------------------------------
+func easIpReplaceInfos2Pcf(easIpReplaceInfos []models.EasIpReplacementInfo) []pcfclient.EasIpReplacementInfo {
+	pcfInfos := []pcfclient.EasIpReplacementInfo{}
+	for _, easIpReplaceInfo := range easIpReplaceInfos {
+		pcfInfos = append(pcfInfos, pcfclient.EasIpReplacementInfo{
+			Source: easServerAddress2Pcf(easIpReplaceInfo.Source),
+			Target: easServerAddress2Pcf(easIpReplaceInfo.Target),
+		})
+	}
+	return pcfInfos
+}
+
+// ------------------------------------------------------------------------------
+func easServerAddress2Pcf(easAddr models.EasServerAddress) pcfclient.EasServerAddress {
+	ipAddr := pcfclient.NewIpAddr()
+	if easAddr.Ip != nil {
+		if len(easAddr.Ip.Ipv4Addr) > 0 {
+			ipAddr.Ipv4Addr = pcfclient.PtrString(easAddr.Ip.Ipv4Addr)
+		}
+		if len(easAddr.Ip.Ipv6Addr) > 0 {
+			ipAddr.Ipv6Addr = pcfclient.NewIpv6Addr(string(easAddr.Ip.Ipv6Addr))
+		}
+	}
+	return *pcfclient.NewEasServerAddress(*pcfclient.NewNullableIpAddr(ipAddr), easAddr.Port)
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+func TestValidateEasRelocation(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		EasIpReplaceInfos: []models.EasIpReplacementInfo{{
+			Source: models.EasServerAddress{Ip: &models.IpAddr{Ipv4Addr: "10.0.0.1"}, Port: 8080},
+			Target: models.EasServerAddress{Ip: &models.IpAddr{Ipv6Addr: "2001:db8::1"}, Port: 8080},
+		}},
+		SimConnInd:      true,
+		SimConnTerm:     30,
+		MaxAllowedUpLat: 10,
+	}
+	if err := validateEasRelocation(data); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	data.EasIpReplaceInfos[0].Target = models.EasServerAddress{Ip: &models.IpAddr{Ipv6Addr: "10.0.1.1"}, Port: 8080}
+	if err := validateEasRelocation(data); err == nil {
+		t.Errorf("expected an IPv4 address given as ipv6Addr to be rejected")
+	}
+
+	data.EasIpReplaceInfos[0].Target = models.EasServerAddress{Ip: &models.IpAddr{Ipv4Addr: "10.0.0.300"}, Port: 8080}
+	data.SimConnInd = false
+	err := validateEasRelocation(data)
+	var problemErr *models.ProblemError
+	if !errors.As(err, &problemErr) {
+		t.Fatalf("expected a ProblemError, got %v", err)
+	}
+	if len(problemErr.Problem.InvalidParams) != 2 {
+		t.Fatalf("expected 2 invalid params, got %v", problemErr.Problem.InvalidParams)
+	}
+	if param := problemErr.Problem.InvalidParams[0].Param; param != "/easIpReplaceInfos/0/target" {
+		t.Errorf("got invalid param %s, wanted /easIpReplaceInfos/0/target", param)
+	}
+	if param := problemErr.Problem.InvalidParams[1].Param; param != "/simConnTerm" {
+		t.Errorf("got invalid param %s, wanted /simConnTerm", param)
+	}
+}
+
+func TestEasRelocation2RoutingRequirement(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		AddrPreserInd: true,
+		EasRedisInd:   true,
+		EasIpReplaceInfos: []models.EasIpReplacementInfo{{
+			Source: models.EasServerAddress{Ip: &models.IpAddr{Ipv4Addr: "10.0.0.1"}, Port: 8080},
+			Target: models.EasServerAddress{Ip: &models.IpAddr{Ipv6Addr: "2001:db8::1"}, Port: 8080},
+		}},
+		MaxAllowedUpLat: 10,
+	}
+
+	routReq := pcfclient.AfRoutingRequirement{}
+	easRelocation2RoutingRequirement(data, &routReq)
+	if !routReq.GetAddrPreserInd() || !routReq.GetEasRedisInd() || routReq.GetMaxAllowedUpLat() != 10 {
+		t.Errorf("got routing requirement %+v, wanted the EAS relocation attributes", routReq)
+	}
+	if infos := routReq.GetEasIpReplaceInfos(); len(infos) != 1 || infos[0].Source.Ip.Get().GetIpv4Addr() != "10.0.0.1" {
+		t.Errorf("got easIpReplaceInfos %v, wanted source 10.0.0.1", infos)
+	} else if ipv6Addr := infos[0].Target.Ip.Get().GetIpv6Addr(); ipv6Addr.String == nil || *ipv6Addr.String != "2001:db8::1" {
+		t.Errorf("got target %v, wanted 2001:db8::1", infos[0].Target.Ip.Get())
+	}
+	if routReq.HasSimConnInd() {
+		t.Errorf("expected no simConnInd when not requested")
+	}
+
+	updated := *data
+	updated.MaxAllowedUpLat = 0
+	routReqRm := pcfclient.AfRoutingRequirementRm{}
+	if !easRelocation2RoutingRequirementRm(data, &updated, &routReqRm) {
+		t.Fatalf("expected the maxAllowedUpLat removal in the delta")
+	}
+	if !routReqRm.MaxAllowedUpLat.IsSet() || routReqRm.MaxAllowedUpLat.Get() != nil {
+		t.Errorf("expected maxAllowedUpLat set to null in the delta")
+	}
+	if routReqRm.EasIpReplaceInfos != nil || routReqRm.AddrPreserInd.IsSet() {
+		t.Errorf("unchanged attributes must not be part of the delta")
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// validateTrafficFilters checks the IP and Ethernet traffic filters, each
+// offending filter is reported as InvalidParam.
+func validateTrafficFilters(data *models.TrafficInfluSub) error {
+	invalidParams := []models.InvalidParam{}
+	flowIds := map[int32]bool{}
+
+	for i, flow := range data.TrafficFilters {
+		if len(flow.FlowDescriptions) == 0 {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/trafficFilters/%d/flowDescriptions", i),
+				Reason: "flowDescriptions not provided",
+			})
+		}
+		if flowIds[flow.FlowId] {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/trafficFilters/%d/flowId", i),
+				Reason: fmt.Sprintf("duplicated flowId %d", flow.FlowId),
+			})
+		}
+		flowIds[flow.FlowId] = true
+		for j, flowD := range flow.FlowDescriptions {
+			if err := validateIpFilterRule(flowD); err != nil {
+				invalidParams = append(invalidParams, models.InvalidParam{
+					Param:  fmt.Sprintf("/trafficFilters/%d/flowDescriptions/%d", i, j),
+					Reason: err.Error(),
+				})
+			}
+		}
+	}
+	for i, ethFlow := range data.EthTrafficFilters {
+		if len(ethFlow.EthType) == 0 {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/ethTrafficFilters/%d/ethType", i),
+				Reason: "ethType not provided",
+			})
+		}
+	}
+
+	if len(invalidParams) > 0 {
+		return &models.ProblemError{Problem: models.ProblemDetails{
+			Title:         "Invalid traffic filters",
+			Detail:        "invalid flow descriptor",
+			InvalidParams: invalidParams,
+		}}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// validateIpFilterRule checks a flow description against the IPFilterRule
+// restrictions of TS 29.214: "permit out <proto> from <src> [ports] to <dst>
+// [ports]", without options.
+func validateIpFilterRule(rule string) error {
+	fields := strings.Fields(rule)
+	if len(fields) < 7 {
+		return fmt.Errorf("expected permit out <proto> from <address> [ports] to <address> [ports]")
+	}
+	if fields[0] != "permit" {
+		return fmt.Errorf("action %s not supported, only permit is allowed", fields[0])
+	}
+	if fields[1] != "out" {
+		return fmt.Errorf("direction %s not supported, only out is allowed", fields[1])
+	}
+	if fields[2] != "ip" {
+		proto, err := strconv.Atoi(fields[2])
+		if err != nil || proto < 0 || proto > 255 {
+			return fmt.Errorf("invalid protocol %s", fields[2])
+		}
+	}
+	if fields[3] != "from" {
+		return fmt.Errorf("expected from, got %s", fields[3])
+	}
+
+	rest, err := validateIpFilterEndpoint(fields[4:])
+	if err != nil {
+		return err
+	}
+	if len(rest) == 0 || rest[0] != "to" {
+		return fmt.Errorf("expected to after the source")
+	}
+	rest, err = validateIpFilterEndpoint(rest[1:])
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("options %s not allowed", strings.Join(rest, " "))
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// validateIpFilterEndpoint checks an address and its optional ports, and
+// returns the remaining fields of the rule.
+func validateIpFilterEndpoint(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("address not provided")
+	}
+	addr := fields[0]
+	if addr != "any" && addr != "assigned" {
+		if _, _, err := net.ParseCIDR(addr); err != nil && net.ParseIP(addr) == nil {
+			return nil, fmt.Errorf("invalid address %s", addr)
+		}
+	}
+	if len(fields) == 1 || fields[1] == "to" {
+		return fields[1:], nil
+	}
+	for _, ports := range strings.Split(fields[1], ",") {
+		bounds := strings.SplitN(ports, "-", 2)
+		for _, port := range bounds {
+			p, err := strconv.Atoi(port)
+			if err != nil || p < 0 || p > 65535 {
+				return nil, fmt.Errorf("invalid port %s", ports)
+			}
+		}
+		if len(bounds) == 2 {
+			low, _ := strconv.Atoi(bounds[0])
+			high, _ := strconv.Atoi(bounds[1])
+			if low > high {
+				return nil, fmt.Errorf("invalid port range %s", ports)
+			}
+		}
+	}
+	return fields[2:], nil
+}
+
+// ------------------------------------------------------------------------------
+// trafficFilters2MediaComponent maps each traffic filter to a media
+// subcomponent of the app session.
+func trafficFilters2MediaComponent(data *models.TrafficInfluSub) pcfclient.MediaComponent {
+	medComponent := pcfclient.MediaComponent{}
+	medComponent.SetMedCompN(1)
+	medComponent.SetAfAppId(data.AfAppId)
+	medComponent.SetFStatus("ENABLED")
+
+	medSubComponents := make(map[string]pcfclient.MediaSubComponent)
+	for _, flow := range data.TrafficFilters {
+		medSubComponent := pcfclient.MediaSubComponent{}
+		medSubComponent.FNum = flow.FlowId
+		medSubComponent.FlowUsage = pcfclient.PtrString("NO_INFO")
+		medSubComponent.FDescs = append(medSubComponent.FDescs, flow.FlowDescriptions...)
+		if len(flow.TosTC) > 0 {
+			medSubComponent.SetTosTrCl(flow.TosTC)
+		}
+
+		medSubComponents[strconv.Itoa(int(flow.FlowId))] = medSubComponent
+	}
+	for i, ethFlow := range data.EthTrafficFilters {
+		fNum := ethFlowNumber(data, i)
+		medSubComponent := pcfclient.MediaSubComponent{}
+		medSubComponent.FNum = fNum
+		medSubComponent.FlowUsage = pcfclient.PtrString("NO_INFO")
+		medSubComponent.EthfDescs = []pcfclient.EthFlowDescription{ethFlowDescription2Pcf(ethFlow)}
+
+		medSubComponents[strconv.Itoa(int(fNum))] = medSubComponent
+	}
+	medComponent.MedSubComps = &medSubComponents
+	return medComponent
+}
+
+// ------------------------------------------------------------------------------
+// trafficFilters2MediaComponentRm maps the traffic filters to the media
+// subcomponents of the app session update, the filters no longer requested
+// are removed.
+func trafficFilters2MediaComponentRm(prev *models.TrafficInfluSub, data *models.TrafficInfluSub) pcfclient.MediaComponentRm {
+	medComponent := pcfclient.MediaComponentRm{}
+	medComponent.SetMedCompN(1)
+	medComponent.SetAfAppId(data.AfAppId)
+
+	medSubComponents := make(map[string]pcfclient.MediaSubComponentRm)
+	for _, flow := range data.TrafficFilters {
+		medSubComponent := pcfclient.MediaSubComponentRm{}
+		medSubComponent.SetFNum(flow.FlowId)
+		medSubComponent.SetFlowUsage(pcfclient.FlowUsage{String: pcfclient.PtrString("NO_INFO")})
+		medSubComponent.SetFDescs(flow.FlowDescriptions)
+		if len(flow.TosTC) > 0 {
+			medSubComponent.SetTosTrCl(flow.TosTC)
+		}
+
+		medSubComponents[strconv.Itoa(int(flow.FlowId))] = medSubComponent
+	}
+	for i, ethFlow := range data.EthTrafficFilters {
+		fNum := ethFlowNumber(data, i)
+		medSubComponent := pcfclient.MediaSubComponentRm{}
+		medSubComponent.SetFNum(fNum)
+		medSubComponent.SetFlowUsage(pcfclient.FlowUsage{String: pcfclient.PtrString("NO_INFO")})
+		medSubComponent.SetEthfDescs([]pcfclient.EthFlowDescription{ethFlowDescription2Pcf(ethFlow)})
+
+		medSubComponents[strconv.Itoa(int(fNum))] = medSubComponent
+	}
+	/*flows no longer requested are explicitly removed from the app session*/
+	prevFNums := []int32{}
+	for _, flow := range prev.TrafficFilters {
+		prevFNums = append(prevFNums, flow.FlowId)
+	}
+	for i := range prev.EthTrafficFilters {
+		prevFNums = append(prevFNums, ethFlowNumber(prev, i))
+	}
+	for _, fNum := range prevFNums {
+		key := strconv.Itoa(int(fNum))
+		if _, ok := medSubComponents[key]; !ok {
+			medSubComponent := pcfclient.MediaSubComponentRm{}
+			medSubComponent.SetFNum(fNum)
+			medSubComponent.SetFStatus(pcfclient.FlowStatus{String: pcfclient.PtrString("REMOVED")})
+			medSubComponents[key] = medSubComponent
+		}
+	}
+	medComponent.SetMedSubComps(medSubComponents)
+	return medComponent
+}
+
+// ------------------------------------------------------------------------------
+// ethFlowNumber numbers the Ethernet filters after the highest IP flowId
+func ethFlowNumber(data *models.TrafficInfluSub, i int) int32 {
+	var maxFlowId int32
+	for _, flow := range data.TrafficFilters {
+		if flow.FlowId > maxFlowId {
+			maxFlowId = flow.FlowId
+		}
+	}
+	return maxFlowId + int32(i) + 1
+}
+
+// ------------------------------------------------------------------------------
+func ethFlowDescription2Pcf(ethFlow models.EthFlowDescription) pcfclient.EthFlowDescription {
+	desc := pcfclient.EthFlowDescription{
+		EthType:  ethFlow.EthType,
+		VlanTags: ethFlow.VlanTags,
+	}
+	if len(ethFlow.DestMacAddr) > 0 {
+		desc.SetDestMacAddr(ethFlow.DestMacAddr)
+	}
+	if len(ethFlow.SourceMacAddr) > 0 {
+		desc.SetSourceMacAddr(ethFlow.SourceMacAddr)
+	}
+	if len(ethFlow.SrcMacAddrEnd) > 0 {
+		desc.SetSrcMacAddrEnd(ethFlow.SrcMacAddrEnd)
+	}
+	if len(ethFlow.DestMacAddrEnd) > 0 {
+		desc.SetDestMacAddrEnd(ethFlow.DestMacAddrEnd)
+	}
+	if len(ethFlow.FDesc) > 0 {
+		desc.SetFDesc(ethFlow.FDesc)
+	}
+	if len(ethFlow.FDir) > 0 {
+		desc.SetFDir(pcfclient.FlowDirection{String: pcfclient.PtrString(string(ethFlow.FDir))})
+	}
+	return desc
+}
+
+// ------------------------------------------------------------------------------
+// trafficCorreInfo2Pcf maps the traffic correlation requested by the AF, nil
+// when none is requested.
+func trafficCorreInfo2Pcf(info *models.TrafficCorrelationInfo) *pcfclient.TrafficCorrelationInfo {
+	if info == nil {
+		return nil
+	}
+	tfcInfo := pcfclient.NewTrafficCorrelationInfo()
+	if len(info.CorrType) > 0 {
+		tfcInfo.SetCorrType(pcfclient.CorrelationType{String: pcfclient.PtrString(string(info.CorrType))})
+	}
+	if len(info.TfcCorrId) > 0 {
+		tfcInfo.SetTfcCorrId(info.TfcCorrId)
+	}
+	if info.ComEasIpv4Addr != nil {
+		tfcInfo.SetComEasIpv4Addr(*info.ComEasIpv4Addr)
+	}
+	if info.NotifUri != nil {
+		tfcInfo.SetNotifUri(*info.NotifUri)
+	}
+	if info.NotifCorrId != nil {
+		tfcInfo.SetNotifCorrId(*info.NotifCorrId)
+	}
+	return tfcInfo
+}
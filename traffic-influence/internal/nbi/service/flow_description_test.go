@@ -0,0 +1,120 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+func TestValidateIpFilterRule(t *testing.T) {
+	tests := []struct {
+		rule  string
+		valid bool
+	}{
+		{"permit out ip from any to 12.1.1.1", true},
+		{"permit out 17 from 10.0.0.0/24 5000-5010,6000 to assigned", true},
+		{"permit out 6 from 2001:db8::/32 443 to any 1024-65535", true},
+		{"permit ip 0.0.0.0 0.0.0.0", false},
+		{"deny out ip from any to any", false},
+		{"permit in ip from any to any", false},
+		{"permit out tcp from any to any", false},
+		{"permit out ip from 10.0.0.300 to any", false},
+		{"permit out ip from any 70000 to any", false},
+		{"permit out ip from any 6000-5000 to any", false},
+		{"permit out ip from any to any established", false},
+	}
+	for _, test := range tests {
+		err := validateIpFilterRule(test.rule)
+		if test.valid && err != nil {
+			t.Errorf("expected %q to be valid, got %s", test.rule, err.Error())
+		}
+		if !test.valid && err == nil {
+			t.Errorf("expected %q to be invalid", test.rule)
+		}
+	}
+}
+
+func TestValidateTrafficFilters(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		TrafficFilters: []models.FlowInfo{
+			{FlowId: 1, FlowDescriptions: []string{"permit out 17 from any 5000 to any"}},
+			{FlowId: 2, FlowDescriptions: []string{"permit out 17 from any 5001 to any", "permit out udp from any to any"}},
+			{FlowId: 3},
+		},
+		EthTrafficFilters: []models.EthFlowDescription{{EthType: ""}},
+	}
+
+	err := validateTrafficFilters(data)
+	var problemErr *models.ProblemError
+	if !errors.As(err, &problemErr) {
+		t.Fatalf("expected a ProblemError, got %v", err)
+	}
+	if len(problemErr.Problem.InvalidParams) != 3 {
+		t.Fatalf("expected 3 invalid params, got %v", problemErr.Problem.InvalidParams)
+	}
+	if param := problemErr.Problem.InvalidParams[0].Param; param != "/trafficFilters/1/flowDescriptions/1" {
+		t.Errorf("got invalid param %s, wanted /trafficFilters/1/flowDescriptions/1", param)
+	}
+	if param := problemErr.Problem.InvalidParams[1].Param; param != "/trafficFilters/2/flowDescriptions" {
+		t.Errorf("got invalid param %s, wanted /trafficFilters/2/flowDescriptions", param)
+	}
+	if param := problemErr.Problem.InvalidParams[2].Param; param != "/ethTrafficFilters/0/ethType" {
+		t.Errorf("got invalid param %s, wanted /ethTrafficFilters/0/ethType", param)
+	}
+}
+
+func TestTrafficFilters2MediaComponent(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		AfAppId: "app1",
+		TrafficFilters: []models.FlowInfo{
+			{FlowId: 1, FlowDescriptions: []string{"permit out 17 from any 5000 to any"}},
+			{FlowId: 3, FlowDescriptions: []string{"permit out 17 from any 5001 to any"}},
+		},
+		EthTrafficFilters: []models.EthFlowDescription{{EthType: "0800"}},
+	}
+
+	medComponent := trafficFilters2MediaComponent(data)
+	medSubComps := medComponent.GetMedSubComps()
+	if len(medSubComps) != 3 {
+		t.Fatalf("expected 3 media subcomponents, got %d", len(medSubComps))
+	}
+	if fDescs := medSubComps["3"].FDescs; len(fDescs) != 1 || fDescs[0] != "permit out 17 from any 5001 to any" {
+		t.Errorf("got flow descriptions %v for flow 3", fDescs)
+	}
+	if ethfDescs := medSubComps["4"].EthfDescs; len(ethfDescs) != 1 || ethfDescs[0].EthType != "0800" {
+		t.Errorf("expected the Ethernet filter numbered after the highest flowId, got %v", medSubComps)
+	}
+
+	prev := &models.TrafficInfluSub{
+		AfAppId: "app1",
+		TrafficFilters: []models.FlowInfo{
+			{FlowId: 1, FlowDescriptions: []string{"permit out 17 from any 5000 to any"}},
+			{FlowId: 2, FlowDescriptions: []string{"permit out 17 from any 5002 to any"}},
+		},
+	}
+	medComponentRm := trafficFilters2MediaComponentRm(prev, data)
+	removed := medComponentRm.GetMedSubComps()["2"]
+	if removed.GetFStatus().String == nil || *removed.GetFStatus().String != "REMOVED" {
+		t.Errorf("expected flow 2 to be removed, got %v", removed)
+	}
+}
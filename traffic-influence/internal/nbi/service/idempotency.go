@@ -0,0 +1,74 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// ------------------------------------------------------------------------------
+// idempotencyKeyOf picks the key identifying a creation request, the
+// Idempotency-Key header taking precedence over the afTransId, along with the
+// parameter reported on conflicts.
+func idempotencyKeyOf(idempotencyKey string, data *models.TrafficInfluSub) (string, string) {
+	if len(idempotencyKey) > 0 {
+		return idempotencyKey, "Idempotency-Key"
+	}
+	return data.AfTransId, "/afTransId"
+}
+
+// ------------------------------------------------------------------------------
+// requestHash digests the creation request as received from the AF, before
+// the NEF fills any of its fields.
+func requestHash(data *models.TrafficInfluSub) string {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// ------------------------------------------------------------------------------
+// idempotentReplay looks for a subscription of the AF created with the same
+// idempotency key. A retry carrying the same payload returns the original
+// subscription, a different payload is a conflict.
+func idempotentReplay(af *contexts.AppFunctionCtx, key string, keyParam string, reqHash string) (*contexts.TraffInflSubscriptionCtx, error) {
+	sub := af.GetIdempotentSubscription(key)
+	if sub == nil {
+		return nil, nil
+	}
+	if sub.RequestHash != reqHash {
+		return nil, &models.ProblemError{Problem: models.ProblemDetails{
+			Title:  "Conflicting request",
+			Detail: "idempotency key already used by " + sub.Location() + " with a different payload",
+			InvalidParams: []models.InvalidParam{{
+				Param:  keyParam,
+				Reason: "reused with a different payload",
+			}},
+		}}
+	}
+	return sub, nil
+}
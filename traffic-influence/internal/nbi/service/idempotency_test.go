@@ -0,0 +1,72 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"testing"
+
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+func TestIdempotencyKeyOf(t *testing.T) {
+	data := &models.TrafficInfluSub{AfTransId: "trans-1"}
+
+	if key, param := idempotencyKeyOf("key-1", data); key != "key-1" || param != "Idempotency-Key" {
+		t.Errorf("got %q/%q, wanted the Idempotency-Key header", key, param)
+	}
+	if key, param := idempotencyKeyOf("", data); key != "trans-1" || param != "/afTransId" {
+		t.Errorf("got %q/%q, wanted the afTransId", key, param)
+	}
+	if key, _ := idempotencyKeyOf("", &models.TrafficInfluSub{}); key != "" {
+		t.Errorf("got key %q, wanted none", key)
+	}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	af := contexts.NewAf("af-test")
+
+	req := &models.TrafficInfluSub{AfTransId: "trans-1", Dnn: "internet", Ipv4Addr: "12.1.1.2"}
+	reqHash := requestHash(req)
+	_, subCtx := af.NewAfSubscription(req)
+	subCtx.IdempotencyKey = "trans-1"
+	subCtx.RequestHash = reqHash
+
+	if sub, err := idempotentReplay(af, "trans-2", "/afTransId", reqHash); sub != nil || err != nil {
+		t.Errorf("got %v, %v for a new key, wanted no replay", sub, err)
+	}
+
+	retry := &models.TrafficInfluSub{AfTransId: "trans-1", Dnn: "internet", Ipv4Addr: "12.1.1.2"}
+	sub, err := idempotentReplay(af, "trans-1", "/afTransId", requestHash(retry))
+	if err != nil || sub != subCtx {
+		t.Errorf("got %v, %v for a retry, wanted the original subscription", sub, err)
+	}
+
+	conflict := &models.TrafficInfluSub{AfTransId: "trans-1", Dnn: "internet", Ipv4Addr: "12.1.1.3"}
+	_, err = idempotentReplay(af, "trans-1", "/afTransId", requestHash(conflict))
+	var problem *models.ProblemError
+	if !errors.As(err, &problem) {
+		t.Fatalf("got %v for a conflicting payload, wanted a ProblemError", err)
+	}
+	if len(problem.Problem.InvalidParams) != 1 || problem.Problem.InvalidParams[0].Param != "/afTransId" {
+		t.Errorf("got invalid params %v, wanted /afTransId", problem.Problem.InvalidParams)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// lifecycleManager schedules the transitions of the subscriptions, keyed by their location
+type lifecycleManager struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newLifecycleManager() *lifecycleManager {
+	return &lifecycleManager{timers: make(map[string]*time.Timer)}
+}
+
+// schedule calls fire at the given time, replacing any previous transition of the key
+func (l *lifecycleManager) schedule(key string, at time.Time, fire func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		l.mu.Lock()
+		if l.timers[key] != timer {
+			/*rescheduled or cancelled meanwhile*/
+			l.mu.Unlock()
+			return
+		}
+		delete(l.timers, key)
+		l.mu.Unlock()
+
+		fire()
+	})
+	l.timers[key] = timer
+}
+
+// cancel drops the scheduled transition of the key, if any
+func (l *lifecycleManager) cancel(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.timers[key]; ok {
+		timer.Stop()
+		delete(l.timers, key)
+	}
+}
+
+// scheduled tells whether a transition is pending for the key
+func (l *lifecycleManager) scheduled(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, ok := l.timers[key]
+	return ok
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+func TestAuthorizeTrafficInfluence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "afPolicy.yaml")
+	policies := `
+afs:
+  af1:
+    dnns: [internet]
+    snssais: [{sst: 1}]
+    dnais: [MEC1]
+    ueIpRanges: [12.1.1.0/24]
+`
+	if err := os.WriteFile(path, []byte(policies), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	registry, err := afpolicy.NewRegistry(path)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{}, policies: registry})
+
+	data := &models.TrafficInfluSub{
+		Ipv4Addr:      "12.1.1.2",
+		Dnn:           "internet",
+		Snssai:        models.Snssai{Sst: 1, Sd: "000001"},
+		TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}},
+	}
+	if err := s.authorizeTrafficInfluence("af1", data); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	data = &models.TrafficInfluSub{
+		Ipv4Addr:      "12.1.2.2",
+		Dnn:           "ims",
+		Snssai:        models.Snssai{Sst: 1},
+		TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}, {Dnai: "MEC2"}},
+	}
+	err = s.authorizeTrafficInfluence("af1", data)
+	var problem *models.ProblemError
+	if !errors.As(err, &problem) {
+		t.Fatalf("got %v, wanted a ProblemError", err)
+	}
+	if errorStatus(err, 0) != 403 {
+		t.Errorf("got status %d, wanted 403", errorStatus(err, 0))
+	}
+	params := []string{}
+	for _, invalidParam := range problem.Problem.InvalidParams {
+		params = append(params, invalidParam.Param)
+	}
+	want := []string{"/dnn", "/ipv4Addr", "/trafficRoutes/1/dnai"}
+	if len(params) != len(want) {
+		t.Fatalf("got invalid params %v, wanted %v", params, want)
+	}
+	for i := range want {
+		if params[i] != want[i] {
+			t.Errorf("got invalid params %v, wanted %v", params, want)
+		}
+	}
+
+	/*an AF limited to UE ranges may not target every UE of a dnn or a group*/
+	for param, data := range map[string]*models.TrafficInfluSub{
+		"/anyUeInd":        {AnyUeInd: true, Dnn: "internet", TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}}},
+		"/externalGroupId": {ExternalGroupId: "group1@nef", Dnn: "internet", TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}}},
+	} {
+		err := s.authorizeTrafficInfluence("af1", data)
+		if !errors.As(err, &problem) || len(problem.Problem.InvalidParams) != 1 || problem.Problem.InvalidParams[0].Param != param {
+			t.Errorf("got %v, wanted %s to be forbidden", err, param)
+		}
+	}
+
+	/*without registry, every AF is unrestricted*/
+	s = NewTraffInflService(&testApp{cfg: &config.AppConfig{}})
+	if err := s.authorizeTrafficInfluence("af2", data); err != nil {
+		t.Errorf("expected no errors without registry, got %s", err.Error())
+	}
+}
+
+func TestQuotaProblem(t *testing.T) {
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{Limits: config.LimitsConfig{
+		MaxSubscriptions: 2,
+		Afs:              map[string]config.AfLimitsConfig{"af2": {MaxSubscriptions: 5}},
+	}}})
+
+	if err := s.quotaProblem("af1", 1); err != nil {
+		t.Errorf("expected no errors below the quota, got %s", err.Error())
+	}
+	err := s.quotaProblem("af1", 2)
+	if errorStatus(err, 0) != 429 {
+		t.Errorf("got %v, wanted a 429 ProblemError", err)
+	}
+	if err := s.quotaProblem("af2", 2); err != nil {
+		t.Errorf("expected the af2 override to apply, got %s", err.Error())
+	}
+}
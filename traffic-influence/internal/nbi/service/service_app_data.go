@@ -0,0 +1,251 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// AppDataPath is where the NEF exposes the influence data of any UE and of the
+// groups of UEs, standing in for the Nudr application data consumed by the PCF.
+// It is served on the sbi listener only.
+const AppDataPath = "/nudr-dr/v2/application-data/influenceData"
+
+// UpPathNotificationsPath is the NEF endpoint the SMF reports the UP path
+// changes to, through the upPathChgNotifUri of the influence data or the
+// upPathChgSub of the PCF app session
+const UpPathNotificationsPath = "/3gpp-traffic-influence/v1/up-path-notifications"
+
+// ------------------------------------------------------------------------------
+// createGroupTrafficInfluenceSub provisions the influence data of any UE or of
+// an external group in the application data, instead of a PCF app session.
+func (s *Service) createGroupTrafficInfluenceSub(afId string, af *contexts.AppFunctionCtx, trafficInfluSub *models.TrafficInfluSub) (string, int, error) {
+
+	if err := validateInfluenceData(trafficInfluSub); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+	interGroupId, status, err := s.interGroupIdOf(trafficInfluSub)
+	if err != nil {
+		return "", status, err
+	}
+
+	tiLoc, subCtx := af.NewAfSubscription(trafficInfluSub)
+	subCtx.TrInflId = trafficInfluSub.Self
+	influData := s.trafficInfluence2InfluenceData(afId, subCtx.NotifToken, subCtx.TrInflId, interGroupId, trafficInfluSub)
+	if err := s.Connector().ProvisionInfluenceData(subCtx.TrInflId, influData); err != nil {
+		_ = af.DeleteAfscription(trafficInfluSub.Self)
+		return "", http.StatusInternalServerError, fmt.Errorf("could not provision influence data")
+	}
+	if err := af.SaveAfSubscription(subCtx); err != nil {
+		/*not kept over a restart, the influence data would be left behind*/
+		log.Printf("could not store subscription %s: %s", trafficInfluSub.Self, err)
+		if err := s.Connector().RemoveInfluenceData(subCtx.TrInflId); err != nil {
+			log.Printf("%s", err)
+		}
+		_ = af.DeleteAfscription(trafficInfluSub.Self)
+		return "", http.StatusInternalServerError, fmt.Errorf("could not store subscription")
+	}
+	return tiLoc, http.StatusCreated, nil
+}
+
+// ------------------------------------------------------------------------------
+// updateGroupTrafficInfluenceSub replaces the influence data of the
+// subscription, the targeted UEs cannot be changed.
+func (s *Service) updateGroupTrafficInfluenceSub(afId string, af *contexts.AppFunctionCtx, sub *contexts.TraffInflSubscriptionCtx, trafficInfluSub *models.TrafficInfluSub) (*models.TrafficInfluSub, int, error) {
+
+	if trafficInfluSub.AnyUeInd != sub.Data.AnyUeInd || trafficInfluSub.ExternalGroupId != sub.Data.ExternalGroupId {
+		return nil, http.StatusBadRequest, fmt.Errorf("anyUeInd and externalGroupId cannot be modified")
+	}
+	if err := validateInfluenceData(trafficInfluSub); err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	interGroupId, status, err := s.interGroupIdOf(trafficInfluSub)
+	if err != nil {
+		return nil, status, err
+	}
+
+	trafficInfluSub.Self = sub.Data.Self
+	influData := s.trafficInfluence2InfluenceData(afId, sub.NotifToken, sub.TrInflId, interGroupId, trafficInfluSub)
+	if err := s.Connector().ProvisionInfluenceData(sub.TrInflId, influData); err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not update influence data")
+	}
+	previous := sub.Data
+	sub.Data = trafficInfluSub
+	if err := af.SaveAfSubscription(sub); err != nil {
+		/*the stored subscription still holds the previous influence data*/
+		log.Printf("could not store subscription %s: %s", sub.Data.Self, err)
+		sub.Data = previous
+		influData = s.trafficInfluence2InfluenceData(afId, sub.NotifToken, sub.TrInflId, interGroupId, previous)
+		if err := s.Connector().ProvisionInfluenceData(sub.TrInflId, influData); err != nil {
+			log.Printf("could not revert influence data %s: %s", sub.TrInflId, err)
+		}
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not store subscription")
+	}
+	return sub.Data, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// interGroupIdOf returns the interGroupId the influence data are provisioned
+// for: AnyUE or the internal identifier of the external group.
+func (s *Service) interGroupIdOf(trafficInfluSub *models.TrafficInfluSub) (string, int, error) {
+	if trafficInfluSub.AnyUeInd {
+		return connector.AnyUeGroupId, http.StatusOK, nil
+	}
+	if !s.Connector().HasGroupStore() {
+		return "", http.StatusNotImplemented, fmt.Errorf("group store not configured")
+	}
+	interGroupId, err := s.Connector().GetInternalGroupId(trafficInfluSub.ExternalGroupId)
+	if err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("could not resolve externalGroupId")
+	}
+	if len(interGroupId) == 0 {
+		return "", http.StatusNotFound, fmt.Errorf("could not find externalGroupId")
+	}
+	return interGroupId, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) trafficInfluence2InfluenceData(afId string, notifToken string, influenceId string, interGroupId string, trafficInfluSub *models.TrafficInfluSub) *connector.TrafficInfluData {
+	/*Convert NEF model to UDR models*/
+	influData := &connector.TrafficInfluData{
+		AfAppId:           trafficInfluSub.AfAppId,
+		AppReloInd:        trafficInfluSub.AppReloInd,
+		Dnn:               trafficInfluSub.Dnn,
+		InterGroupId:      interGroupId,
+		TrafficFilters:    trafficInfluSub.TrafficFilters,
+		EthTrafficFilters: trafficInfluSub.EthTrafficFilters,
+		TrafficRoutes:     trafficInfluSub.TrafficRoutes,
+		TraffCorreInd:     trafficInfluSub.TfcCorrInd,
+		TempValidities:    trafficInfluSub.TempValidities,
+		AfAckInd:          trafficInfluSub.AfAckInd,
+		AddrPreserInd:     trafficInfluSub.AddrPreserInd,
+		SimConnInd:        trafficInfluSub.SimConnInd,
+		SimConnTerm:       trafficInfluSub.SimConnTerm,
+		MaxAllowedUpLat:   trafficInfluSub.MaxAllowedUpLat,
+		EasIpReplaceInfos: trafficInfluSub.EasIpReplaceInfos,
+		EasRedisInd:       trafficInfluSub.EasRedisInd,
+		ResUri:            s.callbackUri() + AppDataPath + "/" + influenceId,
+	}
+	if trafficInfluSub.Snssai != (models.Snssai{}) {
+		snssai := trafficInfluSub.Snssai
+		influData.Snssai = &snssai
+	}
+	/*the SMF reports the UP path changes to the NEF, which relays them to the AF*/
+	if subscribesUpPathChange(trafficInfluSub) {
+		influData.SubscribedEvents = trafficInfluSub.SubscribedEvents
+		influData.DnaiChgType = dnaiChgTypeOf(trafficInfluSub)
+		influData.UpPathChgNotifUri = s.upPathNotifUri(afId, trafficInfluSub.Self, notifToken)
+	}
+	return influData
+}
+
+// ------------------------------------------------------------------------------
+// QueryInfluenceData returns the influence data matching the Nudr query
+func (s *Service) QueryInfluenceData(filter *connector.InfluenceDataFilter) ([]*connector.TrafficInfluData, int, error) {
+	influData, err := s.Connector().QueryInfluenceData(filter)
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("could not query influence data")
+	}
+	return influData, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// CreateInfluenceDataSub subscribes a consumer to the changes of the matching
+// influence data
+func (s *Service) CreateInfluenceDataSub(sub *connector.InfluenceDataSub) (string, int, error) {
+	if err := AssertStringNotEmpty(sub.NotificationUri); err != nil {
+		return "", http.StatusBadRequest, fmt.Errorf("field NotificationUri not provided")
+	}
+	if !s.allowedNotificationUri(sub.NotificationUri) {
+		return "", http.StatusForbidden, fmt.Errorf("notificationUri not allowed")
+	}
+	subId := uuid.NewString()
+	if err := s.Connector().SubscribeInfluenceData(subId, sub); err != nil {
+		return "", http.StatusInternalServerError, fmt.Errorf("could not store influence data subscription")
+	}
+	return s.callbackUri() + AppDataPath + "/subs-to-notify/" + subId, http.StatusCreated, nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) DeleteInfluenceDataSub(subId string) (int, error) {
+	found, err := s.Connector().UnsubscribeInfluenceData(subId)
+	if err != nil {
+		return http.StatusInternalServerError, fmt.Errorf("could not remove influence data subscription")
+	}
+	if !found {
+		return http.StatusNotFound, fmt.Errorf("could not find subscriptionId")
+	}
+	return http.StatusNoContent, nil
+}
+
+// ------------------------------------------------------------------------------
+// allowedNotificationUri tells whether the influence data changes may be
+// notified to the uri: it must have the scheme and host of one of the
+// configured appDataNotifUris, or of the pcfSvc by default, and be under its path.
+func (s *Service) allowedNotificationUri(notificationUri string) bool {
+	uri, err := url.Parse(notificationUri)
+	if err != nil || uri.User != nil || len(uri.Host) == 0 {
+		return false
+	}
+	allowed := s.Cfg().Sbi.AppDataNotifUris
+	if len(allowed) == 0 && len(s.Cfg().Sbi.PcfSvc) > 0 {
+		allowed = []string{s.Cfg().Sbi.PcfSvc}
+	}
+	for _, prefix := range allowed {
+		p, err := url.Parse(prefix)
+		if err != nil || len(p.Host) == 0 {
+			continue
+		}
+		if strings.EqualFold(uri.Scheme, p.Scheme) && strings.EqualFold(uri.Host, p.Host) &&
+			strings.HasPrefix(uri.Path, p.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// ------------------------------------------------------------------------------
+// nbiUri is the nbi address the NEF is reached at
+func (s *Service) nbiUri() string {
+	scheme := "http"
+	if s.Cfg().Nbi.UseTLS {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, s.Cfg().Nbi.Fqdn, s.Cfg().Nbi.Port)
+}
+
+// ------------------------------------------------------------------------------
+// callbackUri is the sbi listener address the core reaches the NEF at
+func (s *Service) callbackUri() string {
+	base := s.Cfg().Sbi.CallbackUri
+	if len(base) == 0 {
+		base = fmt.Sprintf("http://%s:%d", s.Cfg().Nbi.Fqdn, s.Cfg().Sbi.Port)
+	}
+	return base
+}
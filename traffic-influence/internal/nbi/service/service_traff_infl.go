@@ -21,8 +21,12 @@ package service
 
 import (
 	"fmt"
+	"log"
 	"net/http"
+	"reflect"
+	"sync"
 
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
 	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
 	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
@@ -34,20 +38,26 @@ type app interface {
 	Cfg() *config.AppConfig
 	Connector() *connector.Connector
 	Ctx() *contexts.TraffInflAppCtx
+	Policies() *afpolicy.Registry
 }
 
 type Service struct {
 	app
+	/*AF acknowledgements awaited by the UP path change notifications*/
+	pendingAcks sync.Map
+	lifecycle   *lifecycleManager
 }
 
 func NewTraffInflService(app app) *Service {
-	svc := &Service{app: app}
+	svc := &Service{app: app, lifecycle: newLifecycleManager()}
 	return svc
 }
 
 // ------------------------------------------------------------------------------
-func (s *Service) CreateTrafficInfluenceSub(afId string, trafficInfluSub *models.TrafficInfluSub) (string, int, error) {
-	var loc string
+// CreateTrafficInfluenceSub creates the subscription of the AF. A retry of a
+// previous creation, identified by the Idempotency-Key header or else by the
+// afTransId, returns the original subscription instead of creating a new one.
+func (s *Service) CreateTrafficInfluenceSub(afId string, idempotencyKey string, trafficInfluSub *models.TrafficInfluSub) (string, int, error) {
 
 	af := s.Ctx().GetAf(afId)
 	if af == nil {
@@ -57,22 +67,68 @@ func (s *Service) CreateTrafficInfluenceSub(afId string, trafficInfluSub *models
 	af.Mu.Lock()
 	defer af.Mu.Unlock()
 
+	key, keyParam := idempotencyKeyOf(idempotencyKey, trafficInfluSub)
+	reqHash := requestHash(trafficInfluSub)
+	if sub, err := idempotentReplay(af, key, keyParam, reqHash); err != nil {
+		return "", http.StatusConflict, err
+	} else if sub != nil {
+		*trafficInfluSub = *sub.Data
+		return sub.Location(), http.StatusCreated, nil
+	}
+
+	if err := policyProblem(s.Policies().Policy(afId).CheckSubscriptions(len(af.GetAfSubscriptions()))); err != nil {
+		return "", http.StatusForbidden, err
+	}
+	if err := s.quotaProblem(afId, len(af.GetAfSubscriptions())); err != nil {
+		return "", http.StatusTooManyRequests, err
+	}
+	if err := s.authorizeTrafficInfluence(afId, trafficInfluSub); err != nil {
+		return "", http.StatusForbidden, err
+	}
+
+	loc, status, err := s.createTrafficInfluenceSub(afId, af, trafficInfluSub)
+	if err != nil || len(key) == 0 {
+		return loc, status, err
+	}
+	if subCtx := af.GetAfSubscription(trafficInfluSub.Self); subCtx != nil {
+		subCtx.IdempotencyKey = key
+		subCtx.RequestHash = reqHash
+		if err := af.SaveAfSubscription(subCtx); err != nil {
+			log.Printf("could not store subscription %s: %s", trafficInfluSub.Self, err)
+		}
+	}
+	return loc, status, nil
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) createTrafficInfluenceSub(afId string, af *contexts.AppFunctionCtx, trafficInfluSub *models.TrafficInfluSub) (string, int, error) {
+	var loc string
+
 	if len(trafficInfluSub.Gpsi) > 0 || len(trafficInfluSub.Ipv4Addr) > 0 || len(trafficInfluSub.Ipv6Addr) > 0 {
 		// Single UE, sent to PCF
-		pa_ctx, err := s.trafficInfluence2PolicyAuthorization(trafficInfluSub)
-		if err == nil {
+		tiLoc, subCtx := af.NewAfSubscription(trafficInfluSub)
+		pa_ctx, err := s.trafficInfluence2PolicyAuthorization(afId, subCtx.NotifToken, trafficInfluSub)
+		if err != nil {
+			_ = af.DeleteAfscription(trafficInfluSub.Self)
+			return "", errorStatus(err, http.StatusBadRequest), err
+		}
+		/*outside of its temporal validity, the app session is created later by the NEF*/
+		if s.inValidityWindow(trafficInfluSub) {
 			loc, err = s.Connector().CreatePolicyAuthzSubscription(*pa_ctx)
 			if err != nil {
+				_ = af.DeleteAfscription(trafficInfluSub.Self)
 				return "", http.StatusInternalServerError, fmt.Errorf("could not create PCF Policy Authorization context")
 			}
-			tiLoc, subCtx := af.NewAfSubscription(trafficInfluSub)
 			subCtx.AppSessId = loc
-			return tiLoc, http.StatusCreated, nil
-		} else {
-			return "", http.StatusBadRequest, err
 		}
+		if err := af.SaveAfSubscription(subCtx); err != nil {
+			log.Printf("could not store subscription %s: %s", trafficInfluSub.Self, err)
+		}
+		s.reconcileValidity(afId, af, subCtx)
+		return tiLoc, http.StatusCreated, nil
 	} else if len(trafficInfluSub.ExternalGroupId) > 0 || trafficInfluSub.AnyUeInd {
-		return "", http.StatusNotImplemented, fmt.Errorf("udr policies not supported yet")
+		// Any UE or group of UEs, provisioned in the application data
+		return s.createGroupTrafficInfluenceSub(afId, af, trafficInfluSub)
 	} else {
 		return "", http.StatusNotImplemented, fmt.Errorf("not in the single or multiple UE cases")
 	}
@@ -80,7 +136,7 @@ func (s *Service) CreateTrafficInfluenceSub(afId string, trafficInfluSub *models
 }
 
 // ------------------------------------------------------------------------------
-func (s *Service) UpdateTrafficInfluenceSub(afId string, subId string, trafficInfluSub *models.TrafficInfluSub) (int, error) {
+func (s *Service) UpdateTrafficInfluenceSub(afId string, subId string, trafficInfluSub *models.TrafficInfluSub) (*models.TrafficInfluSub, int, error) {
 
 	af := s.Ctx().GetAf(afId)
 
@@ -90,28 +146,73 @@ func (s *Service) UpdateTrafficInfluenceSub(afId string, subId string, trafficIn
 
 		sub := af.GetAfSubscription(subId)
 		if sub != nil {
-			if len(sub.AppSessId) > 0 {
-				// here we update the pcf subscription
-				if len(trafficInfluSub.Gpsi) > 0 || len(trafficInfluSub.Ipv4Addr) > 0 || len(trafficInfluSub.Ipv6Addr) > 0 {
-					// Single UE, sent to PCF
-					pa_ctx, err := s.trafficInfluence2PolicyAuthorization(trafficInfluSub)
-					if err == nil {
-						newPcfLoc, err := s.Connector().UpdatePolicyAuthzSubscription(sub.AppSessId, *pa_ctx)
-						if err != nil {
-							return http.StatusInternalServerError, fmt.Errorf("could not update PCF Policy Authorization context")
-						}
-						sub.AppSessId = newPcfLoc
-						sub.Data = trafficInfluSub
-						return http.StatusCreated, nil
-					} else {
-						return http.StatusBadRequest, err
-					}
-				}
+			if err := s.authorizeTrafficInfluence(afId, trafficInfluSub); err != nil {
+				return nil, http.StatusForbidden, err
+			}
+			if len(sub.TrInflId) > 0 {
+				return s.updateGroupTrafficInfluenceSub(afId, af, sub, trafficInfluSub)
+			}
+			return s.applyTrafficInfluenceUpdate(afId, af, sub, trafficInfluSub)
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+}
+
+// ------------------------------------------------------------------------------
+func (s *Service) ModifyTrafficInfluenceSub(afId string, subId string, patch *models.TrafficInfluSubPatch) (*models.TrafficInfluSub, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+
+	if af != nil {
+		af.Mu.Lock()
+		defer af.Mu.Unlock()
+
+		sub := af.GetAfSubscription(subId)
+		if sub != nil {
+			trafficInfluSub := mergeTrafficInfluencePatch(sub.Data, patch)
+			if err := s.authorizeTrafficInfluence(afId, trafficInfluSub); err != nil {
+				return nil, http.StatusForbidden, err
+			}
+			if len(sub.TrInflId) > 0 {
+				return s.updateGroupTrafficInfluenceSub(afId, af, sub, trafficInfluSub)
 			}
+			return s.applyTrafficInfluenceUpdate(afId, af, sub, trafficInfluSub)
+		}
+	}
+	return nil, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+}
+
+// ------------------------------------------------------------------------------
+// applyTrafficInfluenceUpdate modifies the PCF app session in place and only
+// stores the new subscription data once the PCF accepted the modification, so
+// that the stored data always reflects what the PCF holds.
+func (s *Service) applyTrafficInfluenceUpdate(afId string, af *contexts.AppFunctionCtx, sub *contexts.TraffInflSubscriptionCtx, trafficInfluSub *models.TrafficInfluSub) (*models.TrafficInfluSub, int, error) {
+
+	/*UE identity, dnn and slice identify the PCF app session and cannot be changed*/
+	if !sameInfluenceTarget(sub.Data, trafficInfluSub) {
+		return nil, http.StatusBadRequest, fmt.Errorf("UE address, dnn and snssai cannot be modified")
+	}
+
+	patch, err := s.trafficInfluence2PolicyAuthzUpdate(afId, sub.NotifToken, sub.Data, trafficInfluSub)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	/*an app session scheduled by the NEF may not exist yet*/
+	if patch != nil && len(sub.AppSessId) > 0 {
+		err = s.Connector().ModifyPolicyAuthzSubscription(sub.AppSessId, *patch)
+		if err != nil {
+			return nil, http.StatusInternalServerError, fmt.Errorf("could not modify PCF Policy Authorization context")
 		}
 	}
-	return http.StatusNotFound, fmt.Errorf("could not find af/subId")
 
+	trafficInfluSub.Self = sub.Data.Self
+	sub.Data = trafficInfluSub
+	if err := af.SaveAfSubscription(sub); err != nil {
+		log.Printf("could not store subscription %s: %s", sub.Data.Self, err)
+	}
+	s.reconcileValidity(afId, af, sub)
+	return sub.Data, http.StatusOK, nil
 }
 
 // ------------------------------------------------------------------------------
@@ -125,13 +226,19 @@ func (s *Service) DeleteTrafficInfluenceSub(afId string, subId string) error {
 
 		sub := af.GetAfSubscription(subId)
 		if sub != nil {
-			if len(sub.AppSessId) > 0 {
-				err := s.Connector().RemovePolicyAuthzSubscription(sub.AppSessId)
-				if err == nil {
-					err = af.DeleteAfscription(subId)
+			/*the validity timers are kept while the influence may still be removed
+			 * by a later request or by their expiry*/
+			if len(sub.TrInflId) > 0 {
+				if err := s.Connector().RemoveInfluenceData(sub.TrInflId); err != nil {
+					return err
+				}
+			} else if len(sub.AppSessId) > 0 {
+				if err := s.Connector().RemovePolicyAuthzSubscription(sub.AppSessId); err != nil {
+					return err
 				}
-				return err
 			}
+			s.lifecycle.cancel(sub.Location())
+			return af.DeleteAfscription(subId)
 		}
 	}
 	return fmt.Errorf("could not find af/subId")
@@ -176,21 +283,23 @@ func (s *Service) GetAllTrafficInfluenceSub(afId string) ([]*models.TrafficInflu
 }
 
 // ------------------------------------------------------------------------------
-func (s *Service) trafficInfluence2PolicyAuthorization(trafficInfluSub *models.TrafficInfluSub) (*pcfclient.AppSessionContext, error) {
+func (s *Service) trafficInfluence2PolicyAuthorization(afId string, notifToken string, trafficInfluSub *models.TrafficInfluSub) (*pcfclient.AppSessionContext, error) {
 
 	/*Convert NEF model to PCF models*/
 	err := validateSubscriptionData(trafficInfluSub)
 	if err != nil {
 		return nil, err
 	}
+	err = s.validateValidity(trafficInfluSub)
+	if err != nil {
+		return nil, err
+	}
 
 	req := pcfclient.AppSessionContextReqData{
 		AfAppId: &trafficInfluSub.AfAppId,
 		AfRoutReq: &pcfclient.AfRoutingRequirement{
 			AppReloc: &trafficInfluSub.AppReloInd,
 		},
-		Supi:     pcfclient.PtrString(trafficInfluSub.Gpsi),
-		UeIpv4:   pcfclient.PtrString(trafficInfluSub.Ipv4Addr),
 		UeMac:    pcfclient.PtrString(trafficInfluSub.MacAddr),
 		NotifUri: trafficInfluSub.NotificationDestination,
 		SuppFeat: s.Cfg().SupportedFeat,
@@ -201,8 +310,171 @@ func (s *Service) trafficInfluence2PolicyAuthorization(trafficInfluSub *models.T
 		},
 	}
 
+	if len(trafficInfluSub.Ipv4Addr) > 0 {
+		req.SetUeIpv4(trafficInfluSub.Ipv4Addr)
+	}
+	if len(trafficInfluSub.Ipv6Addr) > 0 {
+		req.SetUeIpv6(*pcfclient.NewIpv6Addr(trafficInfluSub.Ipv6Addr))
+	}
+
+	/* The GPSI is resolved to the SUPI and not sent to the core, the UE address
+	 * and DNN default to the ones of its PDU session */
+	if len(trafficInfluSub.Gpsi) > 0 {
+		supi, session, err := s.resolveGpsi(afId, trafficInfluSub)
+		if err != nil {
+			return nil, err
+		}
+		req.SetSupi(supi)
+		if session != nil {
+			if err := s.authorizeUeSession(afId, session); err != nil {
+				return nil, err
+			}
+			req.SetUeIpv4(session.Ipv4)
+			req.SetDnn(session.Dnn)
+			req.SliceInfo = &pcfclient.Snssai{
+				Sst: session.Snssai.Sst,
+				Sd:  pcfclient.PtrString(session.Snssai.Sd),
+			}
+		}
+	}
+
 	/* RouteToLocs */
-	for _, routeToLoc := range trafficInfluSub.TrafficRoutes {
+	req.AfRoutReq.RouteToLocs = trafficRoutes2RouteToLocs(trafficInfluSub.TrafficRoutes)
+
+	/* Traffic filters, each one a media subcomponent */
+	req.SetMedComponents(map[string]pcfclient.MediaComponent{"1": trafficFilters2MediaComponent(trafficInfluSub)})
+
+	if tfcCorreInfo := trafficCorreInfo2Pcf(trafficInfluSub.TfcCorreInfo); tfcCorreInfo != nil {
+		req.AfRoutReq.TfcCorreInfo.Set(tfcCorreInfo)
+	}
+
+	/* UP path change events reported by the SMF to the NEF */
+	if subscribesUpPathChange(trafficInfluSub) {
+		req.AfRoutReq.UpPathChgSub.Set(s.upPathChgSubOf(afId, notifToken, trafficInfluSub))
+	}
+
+	/* EAS relocation and IP replacement */
+	easRelocation2RoutingRequirement(trafficInfluSub, req.AfRoutReq)
+
+	/* Temporal and spatial validity */
+	if !s.nefEnforcesValidity(trafficInfluSub) {
+		req.AfRoutReq.TempVals = tempValidities2Pcf(trafficInfluSub.TempValidities)
+	}
+	if len(trafficInfluSub.ValidGeoZoneIds) > 0 {
+		req.AfRoutReq.SpVal = &pcfclient.SpatialValidity{PresenceInfoList: s.geoZones2PresenceInfos(trafficInfluSub.ValidGeoZoneIds)}
+	}
+
+	ctx := &pcfclient.AppSessionContext{}
+	ctx.SetAscReqData(req)
+	return ctx, nil
+}
+
+// ------------------------------------------------------------------------------
+// resolveGpsi retrieves the SUPI of the GPSI of the AF through the identity
+// service and, when the AF did not give the UE address, its PDU session on the
+// requested dnn/slice through the profile service.
+func (s *Service) resolveGpsi(afId string, data *models.TrafficInfluSub) (string, *models.PduSessionInfo, error) {
+
+	supi, err := s.Connector().LookupGpsi(afId, data.Gpsi)
+	if err != nil {
+		log.Printf("%s", err)
+		return "", nil, fmt.Errorf("could not resolve Gpsi")
+	}
+	if hasUeAddress(data) {
+		return supi, nil, nil
+	}
+
+	ueProfile, err := s.Connector().GetUeProfile(supi)
+	if err != nil {
+		log.Printf("%s", err)
+		return "", nil, fmt.Errorf("could not find an active PDU session for Gpsi")
+	}
+	session := ueProfile.GetSession(data.Dnn, data.Snssai)
+	if session == nil {
+		return "", nil, fmt.Errorf("could not find an active PDU session for Gpsi")
+	}
+	return supi, session, nil
+}
+
+// ------------------------------------------------------------------------------
+// trafficInfluence2PolicyAuthzUpdate builds the AfRoutingRequirementRm and
+// media components delta between the stored and the new subscription, nil
+// when the PCF app session is not affected by the change.
+func (s *Service) trafficInfluence2PolicyAuthzUpdate(afId string, notifToken string, prev *models.TrafficInfluSub, trafficInfluSub *models.TrafficInfluSub) (*pcfclient.AppSessionContextUpdateDataPatch, error) {
+	/*Convert NEF model to PCF update models*/
+
+	err := validateSubscriptionData(trafficInfluSub)
+	if err != nil {
+		return nil, err
+	}
+	err = s.validateValidity(trafficInfluSub)
+	if err != nil {
+		return nil, err
+	}
+
+	req := pcfclient.AppSessionContextUpdateData{}
+	req.SetAfAppId(trafficInfluSub.AfAppId)
+
+	routReq := pcfclient.AfRoutingRequirementRm{}
+	routChanged := false
+	if prev.AppReloInd != trafficInfluSub.AppReloInd {
+		routReq.SetAppReloc(trafficInfluSub.AppReloInd)
+		routChanged = true
+	}
+	if !reflect.DeepEqual(prev.TrafficRoutes, trafficInfluSub.TrafficRoutes) {
+		routReq.RouteToLocs = trafficRoutes2RouteToLocs(trafficInfluSub.TrafficRoutes)
+		routChanged = true
+	}
+	if !reflect.DeepEqual(prev.TfcCorreInfo, trafficInfluSub.TfcCorreInfo) {
+		routReq.TfcCorreInfo.Set(trafficCorreInfo2Pcf(trafficInfluSub.TfcCorreInfo))
+		routChanged = true
+	}
+	if !sameUpPathChgSub(prev, trafficInfluSub) {
+		if subscribesUpPathChange(trafficInfluSub) {
+			routReq.UpPathChgSub.Set(s.upPathChgSubOf(afId, notifToken, trafficInfluSub))
+		} else {
+			routReq.UpPathChgSub.Set(nil)
+		}
+		routChanged = true
+	}
+	if easRelocation2RoutingRequirementRm(prev, trafficInfluSub, &routReq) {
+		routChanged = true
+	}
+	if !s.nefEnforcesValidity(trafficInfluSub) && !reflect.DeepEqual(prev.TempValidities, trafficInfluSub.TempValidities) {
+		routReq.TempVals = tempValidities2Pcf(trafficInfluSub.TempValidities)
+		routChanged = true
+	}
+	if !reflect.DeepEqual(prev.ValidGeoZoneIds, trafficInfluSub.ValidGeoZoneIds) {
+		if len(trafficInfluSub.ValidGeoZoneIds) > 0 {
+			routReq.SpVal.Set(&pcfclient.SpatialValidityRm{PresenceInfoList: s.geoZones2PresenceInfos(trafficInfluSub.ValidGeoZoneIds)})
+		} else {
+			routReq.SpVal.Set(nil)
+		}
+		routChanged = true
+	}
+	if routChanged {
+		req.SetAfRoutReq(routReq)
+	}
+
+	filtersChanged := !reflect.DeepEqual(prev.TrafficFilters, trafficInfluSub.TrafficFilters) ||
+		!reflect.DeepEqual(prev.EthTrafficFilters, trafficInfluSub.EthTrafficFilters)
+	if filtersChanged {
+		req.SetMedComponents(map[string]pcfclient.MediaComponentRm{"1": trafficFilters2MediaComponentRm(prev, trafficInfluSub)})
+	}
+
+	if !routChanged && !filtersChanged {
+		return nil, nil
+	}
+
+	patch := &pcfclient.AppSessionContextUpdateDataPatch{}
+	patch.SetAscReqData(req)
+	return patch, nil
+}
+
+// ------------------------------------------------------------------------------
+func trafficRoutes2RouteToLocs(trafficRoutes []models.RouteToLocation) []pcfclient.RouteToLocation {
+	routeToLocs := []pcfclient.RouteToLocation{}
+	for _, routeToLoc := range trafficRoutes {
 		rToL := pcfclient.RouteToLocation{}
 		rToL.SetDnai(routeToLoc.Dnai)
 		if routeToLoc.RouteProfId != nil {
@@ -215,51 +487,157 @@ func (s *Service) trafficInfluence2PolicyAuthorization(trafficInfluSub *models.T
 			}
 			rToL.SetRouteInfo(routeInfo)
 		}
-		req.AfRoutReq.RouteToLocs = append(req.AfRoutReq.RouteToLocs, rToL)
+		routeToLocs = append(routeToLocs, rToL)
 	}
+	return routeToLocs
+}
 
-	for _, flow := range trafficInfluSub.TrafficFilters {
-		tfcInfo := &pcfclient.TrafficCorrelationInfo{}
-		tfcInfo.TfcCorrId = pcfclient.PtrString(flow.FlowDescriptions[0])
-		req.AfRoutReq.TfcCorreInfo.Set(tfcInfo)
-		break
+// ------------------------------------------------------------------------------
+// mergeTrafficInfluencePatch returns a copy of the stored subscription with the
+// patch merged on top of it (RFC 7396): the attributes present in the patch
+// replace the stored ones, those set to null being reset.
+func mergeTrafficInfluencePatch(data *models.TrafficInfluSub, patch *models.TrafficInfluSubPatch) *models.TrafficInfluSub {
+	merged := *data
+
+	if patch.Provided("appReloInd", patch.AppReloInd != nil) {
+		merged.AppReloInd = valueOrZero(patch.AppReloInd)
+	}
+	if patch.Provided("trafficFilters", len(patch.TrafficFilters) > 0) {
+		merged.TrafficFilters = patch.TrafficFilters
+	}
+	if patch.Provided("ethTrafficFilters", len(patch.EthTrafficFilters) > 0) {
+		merged.EthTrafficFilters = patch.EthTrafficFilters
+	}
+	if patch.Provided("trafficRoutes", len(patch.TrafficRoutes) > 0) {
+		merged.TrafficRoutes = patch.TrafficRoutes
+	}
+	if patch.Provided("sfcIdDl", patch.SfcIdDl != nil) {
+		merged.SfcIdDl = valueOrZero(patch.SfcIdDl)
+	}
+	if patch.Provided("sfcIdUl", patch.SfcIdUl != nil) {
+		merged.SfcIdUl = valueOrZero(patch.SfcIdUl)
+	}
+	if patch.Provided("metadata", patch.Metadata != nil) {
+		merged.Metadata = patch.Metadata
+	}
+	if patch.Provided("tfcCorrInd", patch.TfcCorrInd != nil) {
+		merged.TfcCorrInd = valueOrZero(patch.TfcCorrInd)
+	}
+	if patch.Provided("tempValidities", patch.TempValidities != nil) {
+		merged.TempValidities = valueOrZero(patch.TempValidities)
 	}
+	if patch.Provided("validGeoZoneIds", patch.ValidGeoZoneIds != nil) {
+		merged.ValidGeoZoneIds = valueOrZero(patch.ValidGeoZoneIds)
+	}
+	if patch.Provided("geoAreas", patch.GeoAreas != nil) {
+		merged.GeoAreas = valueOrZero(patch.GeoAreas)
+	}
+	if patch.Provided("afAckInd", patch.AfAckInd != nil) {
+		merged.AfAckInd = valueOrZero(patch.AfAckInd)
+	}
+	if patch.Provided("addrPreserInd", patch.AddrPreserInd != nil) {
+		merged.AddrPreserInd = valueOrZero(patch.AddrPreserInd)
+	}
+	if patch.Provided("simConnInd", patch.SimConnInd) {
+		merged.SimConnInd = patch.SimConnInd
+	}
+	if patch.Provided("simConnTerm", patch.SimConnTerm > 0) {
+		merged.SimConnTerm = patch.SimConnTerm
+	}
+	if patch.Provided("maxAllowedUpLat", patch.MaxAllowedUpLat != nil) {
+		merged.MaxAllowedUpLat = valueOrZero(patch.MaxAllowedUpLat)
+	}
+	if patch.Provided("easIpReplaceInfos", patch.EasIpReplaceInfos != nil) {
+		merged.EasIpReplaceInfos = valueOrZero(patch.EasIpReplaceInfos)
+	}
+	if patch.Provided("easRedisInd", patch.EasRedisInd) {
+		merged.EasRedisInd = patch.EasRedisInd
+	}
+	if patch.Provided("notificationDestination", len(patch.NotificationDestination) > 0) {
+		merged.NotificationDestination = patch.NotificationDestination
+	}
+	if patch.Provided("eventReq", !reflect.DeepEqual(patch.EventReq, models.ReportingInformation{})) {
+		merged.EventReq = patch.EventReq
+	}
+	if patch.Provided("tfcCorreInfo", patch.TfcCorreInfo != nil) {
+		merged.TfcCorreInfo = patch.TfcCorreInfo
+	}
+	return &merged
+}
 
-	ctx := &pcfclient.AppSessionContext{}
-	ctx.SetAscReqData(req)
-	return ctx, nil
+// valueOrZero returns the patched value, the zero value for null
+func valueOrZero[T any](v *T) T {
+	var zero T
+	if v == nil {
+		return zero
+	}
+	return *v
+}
+
+// ------------------------------------------------------------------------------
+func sameInfluenceTarget(a *models.TrafficInfluSub, b *models.TrafficInfluSub) bool {
+	return a.Ipv4Addr == b.Ipv4Addr &&
+		a.Ipv6Addr == b.Ipv6Addr &&
+		a.MacAddr == b.MacAddr &&
+		a.Gpsi == b.Gpsi &&
+		a.Dnn == b.Dnn &&
+		a.Snssai == b.Snssai
 }
 
 func validateSubscriptionData(data *models.TrafficInfluSub) error {
 
 	var err error
-	err = AssertStringNotEmpty(data.Ipv4Addr)
-	if err != nil {
-		return fmt.Errorf("field Ipv4Addr not provided")
+	if !hasUeAddress(data) && len(data.Gpsi) == 0 {
+		return fmt.Errorf("field Ipv4Addr, Ipv6Addr or Gpsi not provided")
 	}
 	err = AssertStringNotEmpty(data.NotificationDestination)
 	if err != nil {
 		return fmt.Errorf("field NotificationDestination not provided")
 	}
-	err = AssertStringNotEmpty(data.Dnn)
+	/*the dnn of a GPSI only subscription is the one of the UE PDU session*/
+	if hasUeAddress(data) || len(data.Gpsi) == 0 {
+		err = AssertStringNotEmpty(data.Dnn)
+		if err != nil {
+			return fmt.Errorf("field Dnn not provided")
+		}
+	}
+
+	return validateTrafficSteering(data)
+}
+
+// hasUeAddress tells whether the AF targets the UE by its IP address
+func hasUeAddress(data *models.TrafficInfluSub) bool {
+	return len(data.Ipv4Addr) > 0 || len(data.Ipv6Addr) > 0
+}
+
+// validateInfluenceData checks the subscriptions of any UE or of a group of
+// UEs, the notification destination is only needed for the subscribed events
+func validateInfluenceData(data *models.TrafficInfluSub) error {
+	err := AssertStringNotEmpty(data.Dnn)
 	if err != nil {
 		return fmt.Errorf("field Dnn not provided")
 	}
 
-	if len(data.TrafficFilters) == 0 {
+	if len(data.SubscribedEvents) > 0 {
+		err = AssertStringNotEmpty(data.NotificationDestination)
+		if err != nil {
+			return fmt.Errorf("field NotificationDestination not provided")
+		}
+	}
+
+	return validateTrafficSteering(data)
+}
+
+func validateTrafficSteering(data *models.TrafficInfluSub) error {
+	var err error
+
+	if len(data.TrafficFilters) == 0 && len(data.EthTrafficFilters) == 0 {
 		return fmt.Errorf("field TrafficFilters not provided")
 	}
 
-	for _, flow := range data.TrafficFilters {
-		if len(flow.FlowDescriptions) == 0 {
-			return fmt.Errorf("no FlowDescription provided")
-		}
-		for _, flowD := range flow.FlowDescriptions {
-			err = AssertStringNotEmpty(flowD)
-			if err != nil {
-				return fmt.Errorf("invalid flow descriptor")
-			}
-		}
+	err = validateTrafficFilters(data)
+	if err != nil {
+		return err
 	}
 
 	if len(data.TrafficRoutes) == 0 {
@@ -272,7 +650,7 @@ func validateSubscriptionData(data *models.TrafficInfluSub) error {
 			return fmt.Errorf("invalid route info")
 		}
 	}
-	return nil
+	return validateEasRelocation(data)
 }
 
 func AssertStringNotEmpty(s string) error {
@@ -20,12 +20,31 @@
 package service
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"testing"
 
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
 )
 
+type testApp struct {
+	cfg       *config.AppConfig
+	policies  *afpolicy.Registry
+	connector *connector.Connector
+	ctx       *contexts.TraffInflAppCtx
+}
+
+func (a *testApp) Cfg() *config.AppConfig          { return a.cfg }
+func (a *testApp) Connector() *connector.Connector { return a.connector }
+func (a *testApp) Ctx() *contexts.TraffInflAppCtx  { return a.ctx }
+func (a *testApp) Policies() *afpolicy.Registry    { return a.policies }
+
 func TestAsSessionWithQoSValidation(t *testing.T) {
 	data := &models.TrafficInfluSub{
 		Ipv4Addr:                "12.1.1.1",
@@ -34,7 +53,7 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		TrafficRoutes:           []models.RouteToLocation{{Dnai: "DNAI1"}},
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
@@ -49,12 +68,12 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		TrafficRoutes:           []models.RouteToLocation{{Dnai: "DNAI1"}},
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
 	err = validateSubscriptionData(dataNoIp)
-	expectedErr := fmt.Errorf("field Ipv4Addr not provided")
+	expectedErr := fmt.Errorf("field Ipv4Addr, Ipv6Addr or Gpsi not provided")
 	if err == nil {
 		t.Errorf("expected %s, no error", expectedErr.Error())
 	} else if err.Error() != expectedErr.Error() {
@@ -67,7 +86,7 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		TrafficRoutes: []models.RouteToLocation{{Dnai: "DNAI1"}},
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
@@ -85,7 +104,7 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		TrafficRoutes:           []models.RouteToLocation{{Dnai: "DNAI1"}},
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
@@ -97,13 +116,28 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
 	}
 
+	dataGpsiNoDnn := &models.TrafficInfluSub{
+		Gpsi:                    "msisdn-0123456789",
+		NotificationDestination: "http://notifications",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "DNAI1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
+		},
+		},
+	}
+	err = validateSubscriptionData(dataGpsiNoDnn)
+	if err != nil {
+		t.Errorf("expected no errors for a gpsi without dnn, got %s", err.Error())
+	}
+
 	dataNoRoute := &models.TrafficInfluSub{
 		Ipv4Addr:                "12.1.1.1",
 		NotificationDestination: "http://notifications",
 		Dnn:                     "internet",
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
@@ -122,7 +156,7 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		TrafficRoutes:           []models.RouteToLocation{{Dnai: ""}},
 		TrafficFilters: []models.FlowInfo{{
 			FlowId:           1,
-			FlowDescriptions: []string{"permit ip 0.0.0.0 0.0.0.0"},
+			FlowDescriptions: []string{"permit out ip from any to 12.1.1.1"},
 		},
 		},
 	}
@@ -158,11 +192,10 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 		},
 	}
 	err = validateSubscriptionData(dataNoFlowDesc)
-	expectedErr = fmt.Errorf("no FlowDescription provided")
-	if err == nil {
-		t.Errorf("expected %s, no error", expectedErr.Error())
-	} else if err.Error() != expectedErr.Error() {
-		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
+	var problemErr *models.ProblemError
+	if !errors.As(err, &problemErr) || len(problemErr.Problem.InvalidParams) != 1 ||
+		problemErr.Problem.InvalidParams[0].Param != "/trafficFilters/0/flowDescriptions" {
+		t.Errorf("expected /trafficFilters/0/flowDescriptions to be invalid, got %v", err)
 	}
 	dataInvalidFlowDesc := &models.TrafficInfluSub{
 		Ipv4Addr:                "12.1.1.1",
@@ -177,11 +210,301 @@ func TestAsSessionWithQoSValidation(t *testing.T) {
 	}
 
 	err = validateSubscriptionData(dataInvalidFlowDesc)
-	expectedErr = fmt.Errorf("no FlowDescription provided")
+	if !errors.As(err, &problemErr) || len(problemErr.Problem.InvalidParams) != 1 ||
+		problemErr.Problem.InvalidParams[0].Param != "/trafficFilters/0/flowDescriptions" {
+		t.Errorf("expected /trafficFilters/0/flowDescriptions to be invalid, got %v", err)
+	}
+
+}
+
+func TestTrafficInfluence2PolicyAuthorizationUeIpv6(t *testing.T) {
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{}})
+	data := &models.TrafficInfluSub{
+		Ipv6Addr:                "2001:db8::1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 2001:db8:1::1 to any"},
+		}},
+	}
+
+	pa_ctx, err := s.trafficInfluence2PolicyAuthorization("af1", "", data)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	body, _ := json.Marshal(pa_ctx)
+	var sent struct {
+		AscReqData map[string]any `json:"ascReqData"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil || sent.AscReqData["ueIpv6"] != "2001:db8::1" {
+		t.Errorf("expected the ueIpv6 address in %s", body)
+	}
+}
+
+func TestInfluenceDataValidation(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		AnyUeInd:      true,
+		Dnn:           "internet",
+		TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 10.0.0.1 to any"},
+		},
+		},
+	}
+	if err := validateInfluenceData(data); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	data.SubscribedEvents = []models.SubscribedEvent{"UP_PATH_CHANGE"}
+	err := validateInfluenceData(data)
+	expectedErr := fmt.Errorf("field NotificationDestination not provided")
+	if err == nil {
+		t.Errorf("expected %s, no error", expectedErr.Error())
+	} else if err.Error() != expectedErr.Error() {
+		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
+	}
+
+	data.NotificationDestination = "http://notifications"
+	data.Dnn = ""
+	err = validateInfluenceData(data)
+	expectedErr = fmt.Errorf("field Dnn not provided")
 	if err == nil {
 		t.Errorf("expected %s, no error", expectedErr.Error())
 	} else if err.Error() != expectedErr.Error() {
 		t.Errorf("expected %s, got %s", expectedErr.Error(), err.Error())
 	}
+}
+
+// failingStore rejects the subscriptions once fail is set
+type failingStore struct {
+	*contexts.MemoryStore
+	fail bool
+}
+
+func (f *failingStore) Save(record *contexts.SubscriptionRecord) error {
+	if f.fail {
+		return errors.New("store down")
+	}
+	return f.MemoryStore.Save(record)
+}
+
+func TestGroupSubscriptionStoreFailure(t *testing.T) {
+	app := &testApp{cfg: &config.AppConfig{}}
+	app.connector = connector.NewConnector(app)
+	store := &failingStore{MemoryStore: contexts.NewMemoryStore()}
+	app.ctx = contexts.NewTraffInflCtx(app, store)
+	s := NewTraffInflService(app)
+	af := app.ctx.AddAf("af1")
+	data := &models.TrafficInfluSub{
+		AnyUeInd:                true,
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 10.0.0.1 to any"},
+		}},
+	}
+
+	/*the influence data of a subscription that could not be stored are removed*/
+	store.fail = true
+	created := *data
+	if _, status, err := s.createTrafficInfluenceSub("af1", af, &created); err == nil || status != http.StatusInternalServerError {
+		t.Fatalf("got status %d, wanted a failure", status)
+	}
+	if influData, _ := app.connector.QueryInfluenceData(&connector.InfluenceDataFilter{}); len(influData) != 0 {
+		t.Errorf("got influence data %v, wanted none", influData)
+	}
+	if subs := af.GetAfSubscriptions(); len(subs) != 0 {
+		t.Errorf("got %d subscriptions, wanted none", len(subs))
+	}
+
+	/*an update that could not be stored is reverted*/
+	store.fail = false
+	created = *data
+	if _, status, err := s.createTrafficInfluenceSub("af1", af, &created); err != nil {
+		t.Fatalf("got status %d, error %s", status, err.Error())
+	}
+	sub := af.GetAfSubscription(created.Self)
+	store.fail = true
+	updated := *data
+	updated.Dnn = "ims"
+	if _, status, err := s.updateGroupTrafficInfluenceSub("af1", af, sub, &updated); err == nil || status != http.StatusInternalServerError {
+		t.Fatalf("got status %d, wanted a failure", status)
+	}
+	if sub.Data.Dnn != "internet" {
+		t.Errorf("got dnn %s, wanted the previous internet", sub.Data.Dnn)
+	}
+	if influData, _ := app.connector.QueryInfluenceData(&connector.InfluenceDataFilter{}); len(influData) != 1 || influData[0].Dnn != "internet" {
+		t.Errorf("got influence data %v, wanted the previous internet ones", influData)
+	}
+}
+
+func TestAllowedNotificationUri(t *testing.T) {
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{}})
+	if s.allowedNotificationUri("http://pcf.core/notify") {
+		t.Errorf("notificationUri allowed without pcfSvc nor appDataNotifUris")
+	}
+
+	s.Cfg().Sbi.PcfSvc = "http://pcf.core"
+	for uri, allowed := range map[string]bool{
+		"http://pcf.core/npcf-callback/v1/notify": true,
+		"http://PCF.core/notify":                  true,
+		"https://pcf.core/notify":                 false,
+		"http://pcf.core.attacker.org/notify":     false,
+		"http://pcf.core@attacker.org/notify":     false,
+		"http://169.254.169.254/latest/meta-data": false,
+		"/notify": false,
+	} {
+		if got := s.allowedNotificationUri(uri); got != allowed {
+			t.Errorf("allowedNotificationUri(%s) = %v, wanted %v", uri, got, allowed)
+		}
+	}
+
+	s.Cfg().Sbi.AppDataNotifUris = []string{"http://pcf1.core:8080/callbacks"}
+	if s.allowedNotificationUri("http://pcf.core/notify") || s.allowedNotificationUri("http://pcf1.core:8080/notify") {
+		t.Errorf("notificationUri outside of the appDataNotifUris allowed")
+	}
+	if !s.allowedNotificationUri("http://pcf1.core:8080/callbacks/1") {
+		t.Errorf("notificationUri of the appDataNotifUris rejected")
+	}
+}
+
+func TestMergeTrafficInfluencePatch(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		Self:                    "sub1",
+		AfAppId:                 "app1",
+		Ipv4Addr:                "12.1.1.1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 10.0.0.1 to any"},
+		},
+		},
+	}
+
+	appReloInd := true
+	patch := &models.TrafficInfluSubPatch{
+		AppReloInd:    &appReloInd,
+		TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC2"}},
+	}
+
+	merged := mergeTrafficInfluencePatch(data, patch)
+	if merged == data {
+		t.Errorf("expected a copy of the stored subscription, got the same reference")
+	}
+	if !merged.AppReloInd {
+		t.Errorf("got appReloInd %t, wanted %t", merged.AppReloInd, appReloInd)
+	}
+	if len(merged.TrafficRoutes) != 1 || merged.TrafficRoutes[0].Dnai != "MEC2" {
+		t.Errorf("got trafficRoutes %v, wanted %v", merged.TrafficRoutes, patch.TrafficRoutes)
+	}
+	if data.AppReloInd || data.TrafficRoutes[0].Dnai != "MEC1" {
+		t.Errorf("stored subscription must not be modified by the merge")
+	}
+	if merged.Ipv4Addr != data.Ipv4Addr || merged.Self != data.Self {
+		t.Errorf("attributes not present in the patch must be kept")
+	}
+	if !sameInfluenceTarget(data, merged) {
+		t.Errorf("expected same influence target after a routing only patch")
+	}
 
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{}})
+	update, err := s.trafficInfluence2PolicyAuthzUpdate("af1", "", data, merged)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if update == nil {
+		t.Fatalf("expected an AfRoutingRequirementRm delta, got none")
+	}
+	routReq := update.AscReqData.AfRoutReq.Get()
+	if routReq == nil || routReq.AppReloc == nil || !*routReq.AppReloc {
+		t.Errorf("expected appReloc in the delta")
+	}
+	if routReq != nil && (len(routReq.RouteToLocs) != 1 || routReq.RouteToLocs[0].GetDnai() != "MEC2") {
+		t.Errorf("expected routeToLocs in the delta, got %v", routReq.RouteToLocs)
+	}
+	if routReq != nil && routReq.TfcCorreInfo.IsSet() {
+		t.Errorf("unchanged traffic filters must not be part of the delta")
+	}
+
+	update, err = s.trafficInfluence2PolicyAuthzUpdate("af1", "", merged, merged)
+	if err != nil || update != nil {
+		t.Errorf("expected no delta for an unchanged subscription")
+	}
+}
+
+func TestMergeTrafficInfluencePatchResets(t *testing.T) {
+	metadata := "meta1"
+	data := &models.TrafficInfluSub{
+		Self:          "sub1",
+		Ipv4Addr:      "12.1.1.1",
+		TrafficRoutes: []models.RouteToLocation{{Dnai: "MEC1"}},
+		SimConnInd:    true,
+		SimConnTerm:   10,
+		EasRedisInd:   true,
+		Metadata:      &metadata,
+	}
+
+	patch := &models.TrafficInfluSubPatch{}
+	body := `{"simConnInd": false, "easRedisInd": false, "simConnTerm": null, "metadata": null}`
+	if err := json.Unmarshal([]byte(body), patch); err != nil {
+		t.Fatalf("could not decode patch: %s", err)
+	}
+
+	merged := mergeTrafficInfluencePatch(data, patch)
+	if merged.SimConnInd || merged.EasRedisInd {
+		t.Errorf("explicit false must reset simConnInd and easRedisInd, got %t %t", merged.SimConnInd, merged.EasRedisInd)
+	}
+	if merged.SimConnTerm != 0 || merged.Metadata != nil {
+		t.Errorf("null must remove simConnTerm and metadata, got %d %v", merged.SimConnTerm, merged.Metadata)
+	}
+	if len(merged.TrafficRoutes) != 1 || merged.TrafficRoutes[0].Dnai != "MEC1" {
+		t.Errorf("attributes absent from the patch must be kept, got trafficRoutes %v", merged.TrafficRoutes)
+	}
+	if !data.SimConnInd || !data.EasRedisInd || data.Metadata == nil {
+		t.Errorf("stored subscription must not be modified by the merge")
+	}
+}
+
+func TestUpPathEvent2EventNotification(t *testing.T) {
+	data := &models.TrafficInfluSub{
+		AfTransId:        "tr1",
+		Ipv4Addr:         "12.1.1.1",
+		SubscribedEvents: []models.SubscribedEvent{"UP_PATH_CHANGE"},
+	}
+	if !subscribesUpPathChange(data) {
+		t.Errorf("expected a UP path change subscription")
+	}
+
+	event := &connector.SmfEventNotification{
+		Event:            connector.UpPathChEvent,
+		SourceDnai:       "MEC1",
+		TargetDnai:       "MEC2",
+		TargetTraRouting: &models.RouteToLocation{Dnai: "MEC2"},
+	}
+	report := upPathEvent2EventNotification(data, event)
+	if report.SubscribedEvent != "UP_PATH_CHANGE" || report.AfTransId != "tr1" {
+		t.Errorf("got subscribedEvent %s and afTransId %s, wanted UP_PATH_CHANGE and tr1", report.SubscribedEvent, report.AfTransId)
+	}
+	if report.SourceDnai != "MEC1" || report.TargetDnai != "MEC2" || report.TargetTrafficRoute.Dnai != "MEC2" {
+		t.Errorf("got source %s and target %s DNAIs, wanted MEC1 and MEC2", report.SourceDnai, report.TargetDnai)
+	}
+	if report.DnaiChgType != "EARLY_LATE" {
+		t.Errorf("got dnaiChgType %s, wanted the EARLY_LATE default", report.DnaiChgType)
+	}
+	if report.SrcUeIpv4Addr != "12.1.1.1" {
+		t.Errorf("got srcUeIpv4Addr %s, wanted the subscription address", report.SrcUeIpv4Addr)
+	}
+
+	changed := *data
+	changed.AfAckInd = true
+	if sameUpPathChgSub(data, &changed) {
+		t.Errorf("expected the UP path change subscription to change with afAckInd")
+	}
 }
@@ -0,0 +1,305 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+const upPathChangeEvent models.SubscribedEvent = "UP_PATH_CHANGE"
+
+// defaultDnaiChgType is requested to the SMF when the AF subscribed to the UP
+// path changes without a dnaiChgType
+const defaultDnaiChgType models.DnaiChangeType = "EARLY_LATE"
+
+// afAckTimeout bounds the time the SMF notification is held waiting for the
+// AF acknowledgements, answered to the notifications or sent on the afAckUri
+const afAckTimeout = 5 * time.Second
+
+// ------------------------------------------------------------------------------
+// UpPathChangeNotification relays the UP path changes reported by the SMF to
+// the AF of the subscription. When the AF acknowledgement is requested, the
+// acknowledgements are returned to be answered back to the SMF.
+func (s *Service) UpPathChangeNotification(afId string, subId string, token string, notif *connector.NsmfEventExposureNotification) ([]connector.AckOfNotify, int, error) {
+
+	reports, data, status, err := s.recordUpPathChange(afId, subId, token, notif)
+	if err != nil {
+		return nil, status, err
+	}
+	if len(reports) == 0 {
+		return nil, http.StatusNoContent, nil
+	}
+
+	if !data.AfAckInd {
+		go func() {
+			for i := range reports {
+				if _, err := s.Connector().NotifyUpPathChange(data.NotificationDestination, &reports[i]); err != nil {
+					log.Printf("%s", err)
+				}
+			}
+		}()
+		return nil, http.StatusNoContent, nil
+	}
+
+	/*the reports are notified at once, the SMF waiting at most afAckTimeout for all of them*/
+	deadline := time.Now().Add(afAckTimeout)
+	received := make([]*models.AfAckInfo, len(reports))
+	var wg sync.WaitGroup
+	for i := range reports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := afAckKey(afId, subId, notif.NotifId, ueOfReport(&reports[i]))
+			received[i] = s.waitAfAck(key, data.NotificationDestination, &reports[i], deadline)
+		}()
+	}
+	wg.Wait()
+
+	acks := []connector.AckOfNotify{}
+	for i, ack := range received {
+		if ack == nil {
+			log.Printf("no acknowledgement from af %s for subscription %s", afId, subId)
+			continue
+		}
+		gpsi := ack.Gpsi
+		if len(gpsi) == 0 {
+			gpsi = reports[i].Gpsi
+		}
+		acks = append(acks, connector.AckOfNotify{NotifId: notif.NotifId, AckResult: ack.AckResult, Gpsi: gpsi})
+	}
+	if len(acks) == 0 {
+		return nil, http.StatusNoContent, nil
+	}
+	return acks, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// AcknowledgeUpPathChange delivers the acknowledgement the AF sent on the
+// afAckUri to the pending SMF notification of the UE.
+func (s *Service) AcknowledgeUpPathChange(afId string, subId string, notifId string, ue string, ack *models.AfAckInfo) (int, error) {
+	waiter, ok := s.pendingAcks.Load(afAckKey(afId, subId, notifId, ue))
+	if !ok {
+		return http.StatusNotFound, fmt.Errorf("no UP path change notification pending acknowledgement")
+	}
+	select {
+	case waiter.(chan *models.AfAckInfo) <- ack:
+	default:
+	}
+	return http.StatusNoContent, nil
+}
+
+// ------------------------------------------------------------------------------
+// recordUpPathChange translates the SMF events into traffic influence event
+// notifications and keeps them as the eventReports of the subscription.
+func (s *Service) recordUpPathChange(afId string, subId string, token string, notif *connector.NsmfEventExposureNotification) ([]models.EventNotification, models.TrafficInfluSub, int, error) {
+
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return nil, models.TrafficInfluSub{}, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil || !validNotifToken(sub, token) {
+		return nil, models.TrafficInfluSub{}, http.StatusNotFound, fmt.Errorf("could not find af/subId")
+	}
+	if !subscribesUpPathChange(sub.Data) {
+		return nil, models.TrafficInfluSub{}, http.StatusNotFound, fmt.Errorf("no UP path change subscription")
+	}
+
+	reports := []models.EventNotification{}
+	for _, event := range notif.EventNotifs {
+		if event.Event != connector.UpPathChEvent {
+			continue
+		}
+		report := upPathEvent2EventNotification(sub.Data, &event)
+		if sub.Data.AfAckInd {
+			report.AfAckUri = s.afAckUri(afId, subId, notif.NotifId, ueOfReport(&report))
+		}
+		reports = append(reports, report)
+	}
+	if len(reports) > 0 {
+		/*the fetched subscriptions are encoded outside of the AF lock, the
+		 * stored data is replaced and never modified in place*/
+		data := *sub.Data
+		data.EventReports = reports
+		sub.Data = &data
+		if err := af.SaveAfSubscription(sub); err != nil {
+			log.Printf("could not store subscription %s: %s", sub.Data.Self, err)
+		}
+	}
+	return reports, *sub.Data, http.StatusOK, nil
+}
+
+// ------------------------------------------------------------------------------
+// waitAfAck notifies the AF and returns its acknowledgement, answered in the
+// notification response or sent on the afAckUri before the deadline.
+func (s *Service) waitAfAck(key string, notificationDestination string, report *models.EventNotification, deadline time.Time) *models.AfAckInfo {
+	waiter := make(chan *models.AfAckInfo, 1)
+	s.pendingAcks.Store(key, waiter)
+	defer s.pendingAcks.CompareAndDelete(key, waiter)
+
+	answered := make(chan *models.AfAckInfo, 1)
+	go func() {
+		ack, err := s.Connector().NotifyUpPathChange(notificationDestination, report)
+		if err != nil {
+			log.Printf("%s", err)
+		}
+		answered <- ack
+	}()
+
+	timeout := time.After(time.Until(deadline))
+	for {
+		select {
+		case ack := <-answered:
+			if ack != nil {
+				return ack
+			}
+			/*no acknowledgement in the response, it may still come on the afAckUri*/
+			answered = nil
+		case ack := <-waiter:
+			return ack
+		case <-timeout:
+			return nil
+		}
+	}
+}
+
+// ------------------------------------------------------------------------------
+// afAckKey identifies the acknowledgement awaited for a UE of an SMF notification
+func afAckKey(afId string, subId string, notifId string, ue string) string {
+	return afId + "/" + subId + "/" + notifId + "/" + ue
+}
+
+// ------------------------------------------------------------------------------
+// ueOfReport returns the identifier of the UE a report is about: its GPSI,
+// else its address
+func ueOfReport(report *models.EventNotification) string {
+	switch {
+	case len(report.Gpsi) > 0:
+		return report.Gpsi
+	case len(report.SrcUeIpv4Addr) > 0:
+		return report.SrcUeIpv4Addr
+	case len(report.SrcUeIpv6Prefix) > 0:
+		return string(report.SrcUeIpv6Prefix)
+	}
+	return report.UeMac
+}
+
+// ------------------------------------------------------------------------------
+func upPathEvent2EventNotification(data *models.TrafficInfluSub, event *connector.SmfEventNotification) models.EventNotification {
+	report := models.EventNotification{
+		AfTransId:          data.AfTransId,
+		DnaiChgType:        event.DnaiChgType,
+		SubscribedEvent:    upPathChangeEvent,
+		SourceDnai:         event.SourceDnai,
+		TargetDnai:         event.TargetDnai,
+		SourceTrafficRoute: event.SourceTraRouting,
+		TargetTrafficRoute: event.TargetTraRouting,
+		EasRediscoverInd:   event.EasRediscoverInd,
+		Gpsi:               data.Gpsi,
+		SrcUeIpv4Addr:      event.SourceUeIpv4Addr,
+		SrcUeIpv6Prefix:    event.SourceUeIpv6Prefix,
+		TgtUeIpv4Addr:      event.TargetUeIpv4Addr,
+		TgtUeIpv6Prefix:    event.TargetUeIpv6Prefix,
+		UeMac:              event.UeMac,
+	}
+	if len(report.DnaiChgType) == 0 {
+		report.DnaiChgType = dnaiChgTypeOf(data)
+	}
+	if len(report.Gpsi) == 0 {
+		report.Gpsi = event.Gpsi
+	}
+	/*the SMF omits the UE address when it is not changed by the new path*/
+	if len(report.SrcUeIpv4Addr) == 0 && len(report.SrcUeIpv6Prefix) == 0 {
+		report.SrcUeIpv4Addr = data.Ipv4Addr
+	}
+	return report
+}
+
+// ------------------------------------------------------------------------------
+// upPathChgSubOf returns the UP path change subscription of the PCF app
+// session, notified by the SMF on the NEF.
+func (s *Service) upPathChgSubOf(afId string, notifToken string, trafficInfluSub *models.TrafficInfluSub) *pcfclient.UpPathChgEvent {
+	dnaiChgType := string(dnaiChgTypeOf(trafficInfluSub))
+	upPathChgSub := pcfclient.NewUpPathChgEvent(
+		s.upPathNotifUri(afId, trafficInfluSub.Self, notifToken),
+		trafficInfluSub.Self,
+		pcfclient.DnaiChangeType{String: &dnaiChgType},
+	)
+	if trafficInfluSub.AfAckInd {
+		upPathChgSub.SetAfAckInd(true)
+	}
+	return upPathChgSub
+}
+
+// ------------------------------------------------------------------------------
+// upPathNotifUri is the sbi listener uri the SMF notifies the UP path changes
+// of the subscription to, carrying the random token of the subscription
+func (s *Service) upPathNotifUri(afId string, subId string, notifToken string) string {
+	return s.callbackUri() + UpPathNotificationsPath + "/" + afId + "/subscriptions/" + subId + "/" + notifToken
+}
+
+// ------------------------------------------------------------------------------
+// afAckUri is the nbi uri the AF acknowledges the UP path change of a UE on,
+// under its own subscription
+func (s *Service) afAckUri(afId string, subId string, notifId string, ue string) string {
+	query := url.Values{"notifId": {notifId}, "ue": {ue}}
+	return s.nbiUri() + "/3gpp-traffic-influence/v1/" + afId + "/subscriptions/" + subId + "/ack?" + query.Encode()
+}
+
+// ------------------------------------------------------------------------------
+// validNotifToken tells whether an SMF callback carries the token of the subscription
+func validNotifToken(sub *contexts.TraffInflSubscriptionCtx, token string) bool {
+	return len(sub.NotifToken) > 0 && subtle.ConstantTimeCompare([]byte(sub.NotifToken), []byte(token)) == 1
+}
+
+// ------------------------------------------------------------------------------
+func subscribesUpPathChange(data *models.TrafficInfluSub) bool {
+	return slices.Contains(data.SubscribedEvents, upPathChangeEvent)
+}
+
+// ------------------------------------------------------------------------------
+func dnaiChgTypeOf(data *models.TrafficInfluSub) models.DnaiChangeType {
+	if len(data.DnaiChgType) == 0 {
+		return defaultDnaiChgType
+	}
+	return data.DnaiChgType
+}
+
+// ------------------------------------------------------------------------------
+func sameUpPathChgSub(a *models.TrafficInfluSub, b *models.TrafficInfluSub) bool {
+	return subscribesUpPathChange(a) == subscribesUpPathChange(b) &&
+		dnaiChgTypeOf(a) == dnaiChgTypeOf(b) &&
+		a.AfAckInd == b.AfAckInd
+}
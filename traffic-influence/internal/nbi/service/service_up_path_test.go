@@ -0,0 +1,125 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+func TestWaitAfAck(t *testing.T) {
+	/*the AF acknowledges later on the afAckUri of each report*/
+	ackUris := make(chan string, 2)
+	af := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := models.EventNotification{}
+		_ = json.NewDecoder(r.Body).Decode(&report)
+		ackUris <- report.AfAckUri
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer af.Close()
+
+	app := &testApp{cfg: &config.AppConfig{}}
+	app.connector = connector.NewConnector(app)
+	s := NewTraffInflService(app)
+
+	reports := []models.EventNotification{{Gpsi: "msisdn-100"}, {SrcUeIpv4Addr: "12.1.1.2"}}
+	for i := range reports {
+		reports[i].AfAckUri = s.afAckUri("af1", "sub1", "n1", ueOfReport(&reports[i]))
+	}
+	go func() {
+		for range reports {
+			uri, err := url.Parse(<-ackUris)
+			if err != nil {
+				t.Errorf("unexpected afAckUri: %v", err)
+				return
+			}
+			ue := uri.Query().Get("ue")
+			status, _ := s.AcknowledgeUpPathChange("af1", "sub1", uri.Query().Get("notifId"), ue,
+				&models.AfAckInfo{AckResult: models.AfResultInfo{AfStatus: models.AfResultStatus(ue)}})
+			if status != http.StatusNoContent {
+				t.Errorf("got status %d acknowledging %s", status, ue)
+			}
+		}
+	}()
+
+	start := time.Now()
+	deadline := start.Add(afAckTimeout)
+	received := make([]*models.AfAckInfo, len(reports))
+	var wg sync.WaitGroup
+	for i := range reports {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ue := ueOfReport(&reports[i])
+			received[i] = s.waitAfAck(afAckKey("af1", "sub1", "n1", ue), af.URL, &reports[i], deadline)
+		}()
+	}
+	wg.Wait()
+	for i, ack := range received {
+		if ack == nil || string(ack.AckResult.AfStatus) != ueOfReport(&reports[i]) {
+			t.Errorf("got acknowledgement %+v for %s", ack, ueOfReport(&reports[i]))
+		}
+	}
+	if time.Since(start) >= afAckTimeout {
+		t.Errorf("acknowledged notifications waited for the timeout")
+	}
+
+	if status, _ := s.AcknowledgeUpPathChange("af1", "sub1", "n2", "msisdn-100", &models.AfAckInfo{}); status != http.StatusNotFound {
+		t.Errorf("got status %d acknowledging another notification, wanted 404", status)
+	}
+}
+
+func TestRecordUpPathChange(t *testing.T) {
+	app := &testApp{cfg: &config.AppConfig{}}
+	app.ctx = contexts.NewTraffInflCtx(app, contexts.NewMemoryStore())
+	s := NewTraffInflService(app)
+	af := app.ctx.AddAf("af1")
+	_, sub := af.NewAfSubscription(&models.TrafficInfluSub{SubscribedEvents: []models.SubscribedEvent{upPathChangeEvent}})
+
+	fetched, _, err := s.GetIndividualTrafficInfluenceSub("af1", sub.Data.Self)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	notif := &connector.NsmfEventExposureNotification{
+		NotifId:     "n1",
+		EventNotifs: []connector.SmfEventNotification{{Event: connector.UpPathChEvent, SourceDnai: "MEC1", TargetDnai: "MEC2"}},
+	}
+	reports, _, status, err := s.recordUpPathChange("af1", sub.Data.Self, sub.NotifToken, notif)
+	if err != nil || len(reports) != 1 {
+		t.Fatalf("got status %d, reports %v, error %v", status, reports, err)
+	}
+	/*a subscription already fetched is not modified by the notification*/
+	if len(fetched.EventReports) != 0 {
+		t.Errorf("got fetched eventReports %v, wanted none", fetched.EventReports)
+	}
+	if len(sub.Data.EventReports) != 1 || sub.Data.EventReports[0].TargetDnai != "MEC2" {
+		t.Errorf("got stored eventReports %v, wanted the MEC2 report", sub.Data.EventReports)
+	}
+}
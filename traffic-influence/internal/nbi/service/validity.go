@@ -0,0 +1,239 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	pcfclient "gitlab.eurecom.fr/open-exposure/nef/pcfclient"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	models "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+)
+
+// tempValidityByNef has the NEF create and release the app sessions at the
+// bounds of the temporal validities, instead of sending them to the PCF
+const tempValidityByNef = "nef"
+
+// validityRetryInterval delays the next attempt to create the app session
+// of a subscription entering its validity when the PCF failed
+const validityRetryInterval = 30 * time.Second
+
+// ------------------------------------------------------------------------------
+// nefEnforcesValidity tells whether the NEF schedules the app session of the
+// subscription itself.
+func (s *Service) nefEnforcesValidity(data *models.TrafficInfluSub) bool {
+	return s.Cfg().TempValidityEnforcement == tempValidityByNef && len(data.TempValidities) > 0
+}
+
+// ------------------------------------------------------------------------------
+// inValidityWindow tells whether the app session of the subscription is to
+// exist now, always when the PCF enforces the temporal validities.
+func (s *Service) inValidityWindow(data *models.TrafficInfluSub) bool {
+	if !s.nefEnforcesValidity(data) {
+		return true
+	}
+	active, _ := validityAt(data.TempValidities, time.Now())
+	return active
+}
+
+// ------------------------------------------------------------------------------
+// validityAt tells whether one of the temporal validities covers the given
+// time, and returns the next time a validity starts or stops, zero if none.
+func validityAt(tempValidities []models.TemporalValidity, now time.Time) (bool, time.Time) {
+	active := false
+	var next time.Time
+	for _, tempValidity := range tempValidities {
+		started := tempValidity.StartTime.IsZero() || !now.Before(tempValidity.StartTime)
+		stopped := !tempValidity.StopTime.IsZero() && !now.Before(tempValidity.StopTime)
+		if started && !stopped {
+			active = true
+		}
+		for _, bound := range []time.Time{tempValidity.StartTime, tempValidity.StopTime} {
+			if bound.After(now) && (next.IsZero() || bound.Before(next)) {
+				next = bound
+			}
+		}
+	}
+	return active, next
+}
+
+// ------------------------------------------------------------------------------
+// applyValidity creates or releases the app session of the subscription
+// according to its temporal validities, called at each scheduled transition.
+func (s *Service) applyValidity(afId string, subId string) {
+	af := s.Ctx().GetAf(afId)
+	if af == nil {
+		return
+	}
+
+	af.Mu.Lock()
+	defer af.Mu.Unlock()
+
+	sub := af.GetAfSubscription(subId)
+	if sub == nil {
+		return
+	}
+	s.reconcileValidity(afId, af, sub)
+}
+
+// ------------------------------------------------------------------------------
+// reconcileValidity aligns the app session of the subscription with its
+// temporal validities and arms the next transition, the af lock being held.
+func (s *Service) reconcileValidity(afId string, af *contexts.AppFunctionCtx, sub *contexts.TraffInflSubscriptionCtx) {
+	if len(sub.TrInflId) > 0 || !s.nefEnforcesValidity(sub.Data) {
+		s.lifecycle.cancel(sub.Location())
+		return
+	}
+
+	now := time.Now()
+	active, next := validityAt(sub.Data.TempValidities, now)
+	appSessId := sub.AppSessId
+	if active && len(sub.AppSessId) == 0 {
+		log.Printf("subscription %s entering its temporal validity", sub.Location())
+		pa_ctx, err := s.trafficInfluence2PolicyAuthorization(afId, sub.NotifToken, sub.Data)
+		if err == nil {
+			sub.AppSessId, err = s.Connector().CreatePolicyAuthzSubscription(*pa_ctx)
+		}
+		if err != nil {
+			log.Printf("could not create PCF Policy Authorization context: %s", err)
+			if retry := now.Add(validityRetryInterval); next.IsZero() || retry.Before(next) {
+				next = retry
+			}
+		}
+	} else if !active && len(sub.AppSessId) > 0 {
+		log.Printf("subscription %s leaving its temporal validity", sub.Location())
+		if err := s.Connector().RemovePolicyAuthzSubscription(sub.AppSessId); err != nil {
+			log.Printf("%s", err)
+		}
+		sub.AppSessId = ""
+	}
+	if sub.AppSessId != appSessId {
+		if err := af.SaveAfSubscription(sub); err != nil {
+			log.Printf("could not store subscription %s: %s", sub.Data.Self, err)
+		}
+	}
+
+	if next.IsZero() {
+		s.lifecycle.cancel(sub.Location())
+		return
+	}
+	subId := sub.Data.Self
+	s.lifecycle.schedule(sub.Location(), next, func() {
+		s.applyValidity(afId, subId)
+	})
+}
+
+// ------------------------------------------------------------------------------
+// RestoreTrafficInfluenceSubs reloads the stored subscriptions and aligns the
+// app sessions scheduled by the NEF with the temporal validities.
+func (s *Service) RestoreTrafficInfluenceSubs() error {
+	if err := s.Ctx().Restore(); err != nil {
+		return err
+	}
+	for afId, af := range s.Ctx().AppFunc {
+		for _, sub := range af.GetAfSubscriptions() {
+			s.applyValidity(afId, sub.Data.Self)
+		}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+// validateValidity checks the temporal validities and the spatial validity,
+// each offending entry is reported as InvalidParam.
+func (s *Service) validateValidity(data *models.TrafficInfluSub) error {
+	invalidParams := []models.InvalidParam{}
+	for i, tempValidity := range data.TempValidities {
+		if !tempValidity.StartTime.IsZero() && !tempValidity.StopTime.IsZero() && !tempValidity.StopTime.After(tempValidity.StartTime) {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/tempValidities/%d/stopTime", i),
+				Reason: "stopTime must be after startTime",
+			})
+		}
+	}
+	for i, geoZoneId := range data.ValidGeoZoneIds {
+		if _, ok := s.Cfg().GeoZones[geoZoneId]; !ok {
+			invalidParams = append(invalidParams, models.InvalidParam{
+				Param:  fmt.Sprintf("/validGeoZoneIds/%d", i),
+				Reason: fmt.Sprintf("unknown geozone %s", geoZoneId),
+			})
+		}
+	}
+	if len(data.GeoAreas) > 0 {
+		invalidParams = append(invalidParams, models.InvalidParam{
+			Param:  "/geoAreas",
+			Reason: "geographical areas cannot be resolved to tracking areas, use validGeoZoneIds",
+		})
+	}
+
+	if len(invalidParams) > 0 {
+		return &models.ProblemError{Problem: models.ProblemDetails{
+			Title:         "Invalid validity conditions",
+			Detail:        "invalid temporal or spatial validity",
+			InvalidParams: invalidParams,
+		}}
+	}
+	return nil
+}
+
+// ------------------------------------------------------------------------------
+func tempValidities2Pcf(tempValidities []models.TemporalValidity) []pcfclient.TemporalValidity {
+	tempVals := []pcfclient.TemporalValidity{}
+	for _, tempValidity := range tempValidities {
+		tempVal := pcfclient.TemporalValidity{}
+		if !tempValidity.StartTime.IsZero() {
+			tempVal.SetStartTime(tempValidity.StartTime)
+		}
+		if !tempValidity.StopTime.IsZero() {
+			tempVal.SetStopTime(tempValidity.StopTime)
+		}
+		tempVals = append(tempVals, tempVal)
+	}
+	return tempVals
+}
+
+// ------------------------------------------------------------------------------
+// geoZones2PresenceInfos resolves the validGeoZoneIds to presence areas from
+// the geozone catalogue, keyed by their praId or geozone identifier.
+func (s *Service) geoZones2PresenceInfos(geoZoneIds []string) map[string]pcfclient.PresenceInfo {
+	presenceInfos := make(map[string]pcfclient.PresenceInfo)
+	for _, geoZoneId := range geoZoneIds {
+		geoZone, ok := s.Cfg().GeoZones[geoZoneId]
+		if !ok {
+			continue
+		}
+		presenceInfo := pcfclient.PresenceInfo{}
+		key := geoZoneId
+		if len(geoZone.PraId) > 0 {
+			presenceInfo.SetPraId(geoZone.PraId)
+			key = geoZone.PraId
+		}
+		for _, tai := range geoZone.Tais {
+			presenceInfo.TrackingAreaList = append(presenceInfo.TrackingAreaList, pcfclient.Tai{
+				PlmnId: pcfclient.PlmnId{Mcc: tai.Mcc, Mnc: tai.Mnc},
+				Tac:    tai.Tac,
+			})
+		}
+		presenceInfos[key] = presenceInfo
+	}
+	return presenceInfos
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
+	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/models"
+	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/pkg/config"
+)
+
+func TestValidityAt(t *testing.T) {
+	now := time.Date(2026, 6, 14, 18, 0, 0, 0, time.UTC)
+	match := models.TemporalValidity{StartTime: now.Add(time.Hour), StopTime: now.Add(3 * time.Hour)}
+
+	active, next := validityAt([]models.TemporalValidity{match}, now)
+	if active || !next.Equal(match.StartTime) {
+		t.Errorf("got active %t and next %s, wanted inactive until %s", active, next, match.StartTime)
+	}
+	active, next = validityAt([]models.TemporalValidity{match}, now.Add(2*time.Hour))
+	if !active || !next.Equal(match.StopTime) {
+		t.Errorf("got active %t and next %s, wanted active until %s", active, next, match.StopTime)
+	}
+	active, next = validityAt([]models.TemporalValidity{match}, now.Add(4*time.Hour))
+	if active || !next.IsZero() {
+		t.Errorf("got active %t and next %s, wanted inactive for good", active, next)
+	}
+
+	openEnded := models.TemporalValidity{StartTime: now.Add(-time.Hour)}
+	active, next = validityAt([]models.TemporalValidity{openEnded, match}, now)
+	if !active || !next.Equal(match.StartTime) {
+		t.Errorf("got active %t and next %s, wanted active with a transition at %s", active, next, match.StartTime)
+	}
+}
+
+func TestValidateValidity(t *testing.T) {
+	s := NewTraffInflService(&testApp{cfg: &config.AppConfig{
+		GeoZones: map[string]config.GeoZoneConfig{
+			"stadium": {Tais: []config.TaiConfig{{Mcc: "001", Mnc: "01", Tac: "0001"}}},
+		},
+	}})
+	now := time.Now()
+	data := &models.TrafficInfluSub{
+		TempValidities:  []models.TemporalValidity{{StartTime: now, StopTime: now.Add(time.Hour)}},
+		ValidGeoZoneIds: []string{"stadium"},
+	}
+	if err := s.validateValidity(data); err != nil {
+		t.Errorf("expected no errors, got %s", err.Error())
+	}
+
+	presenceInfos := s.geoZones2PresenceInfos(data.ValidGeoZoneIds)
+	if tais := presenceInfos["stadium"].TrackingAreaList; len(tais) != 1 || tais[0].Tac != "0001" {
+		t.Errorf("got presence infos %v, wanted the stadium tracking area", presenceInfos)
+	}
+
+	data.TempValidities[0].StopTime = now.Add(-time.Hour)
+	data.ValidGeoZoneIds = []string{"stadium", "harbour"}
+	err := s.validateValidity(data)
+	var problemErr *models.ProblemError
+	if !errors.As(err, &problemErr) {
+		t.Fatalf("expected a ProblemError, got %v", err)
+	}
+	if len(problemErr.Problem.InvalidParams) != 2 {
+		t.Fatalf("expected 2 invalid params, got %v", problemErr.Problem.InvalidParams)
+	}
+	if param := problemErr.Problem.InvalidParams[1].Param; param != "/validGeoZoneIds/1" {
+		t.Errorf("got invalid param %s, wanted /validGeoZoneIds/1", param)
+	}
+}
+
+func TestTempValidityEnforcement(t *testing.T) {
+	cfg := &config.AppConfig{}
+	s := NewTraffInflService(&testApp{cfg: cfg})
+	now := time.Now()
+	data := &models.TrafficInfluSub{
+		Self:                    "sub1",
+		Ipv4Addr:                "12.1.1.1",
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 10.0.0.1 to any"},
+		},
+		},
+		TempValidities: []models.TemporalValidity{{StartTime: now.Add(time.Hour), StopTime: now.Add(2 * time.Hour)}},
+	}
+
+	pa_ctx, err := s.trafficInfluence2PolicyAuthorization("af1", "", data)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if tempVals := pa_ctx.AscReqData.Get().AfRoutReq.TempVals; len(tempVals) != 1 {
+		t.Errorf("expected the temporal validity sent to the PCF, got %v", tempVals)
+	}
+	if !s.inValidityWindow(data) {
+		t.Errorf("expected the app session to be created when the PCF enforces the validity")
+	}
+
+	cfg.TempValidityEnforcement = "nef"
+	pa_ctx, err = s.trafficInfluence2PolicyAuthorization("af1", "", data)
+	if err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if tempVals := pa_ctx.AscReqData.Get().AfRoutReq.TempVals; len(tempVals) != 0 {
+		t.Errorf("expected no temporal validity sent to the PCF, got %v", tempVals)
+	}
+	if s.inValidityWindow(data) {
+		t.Errorf("expected the app session to wait for its temporal validity")
+	}
+}
+
+func TestLifecycleManager(t *testing.T) {
+	l := newLifecycleManager()
+	fired := make(chan bool, 1)
+
+	l.schedule("sub1", time.Now().Add(time.Hour), func() { fired <- true })
+	if !l.scheduled("sub1") {
+		t.Errorf("expected a transition scheduled for sub1")
+	}
+	l.schedule("sub1", time.Now().Add(10*time.Millisecond), func() { fired <- true })
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Errorf("expected the rescheduled transition to fire")
+	}
+	if l.scheduled("sub1") {
+		t.Errorf("expected no transition left for sub1")
+	}
+
+	l.schedule("sub2", time.Now().Add(10*time.Millisecond), func() { fired <- true })
+	l.cancel("sub2")
+	select {
+	case <-fired:
+		t.Errorf("expected the cancelled transition not to fire")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDeleteGroupSubscriptionWithValidity(t *testing.T) {
+	app := &testApp{cfg: &config.AppConfig{TempValidityEnforcement: "nef"}}
+	app.connector = connector.NewConnector(app)
+	app.ctx = contexts.NewTraffInflCtx(app, contexts.NewMemoryStore())
+	s := NewTraffInflService(app)
+	now := time.Now()
+	data := &models.TrafficInfluSub{
+		AnyUeInd:                true,
+		NotificationDestination: "http://notifications",
+		Dnn:                     "internet",
+		TrafficRoutes:           []models.RouteToLocation{{Dnai: "MEC1"}},
+		TrafficFilters: []models.FlowInfo{{
+			FlowId:           1,
+			FlowDescriptions: []string{"permit out ip from 10.0.0.1 to any"},
+		}},
+		TempValidities: []models.TemporalValidity{{StartTime: now.Add(time.Hour), StopTime: now.Add(2 * time.Hour)}},
+	}
+
+	af := app.ctx.AddAf("af1")
+	if _, status, err := s.createTrafficInfluenceSub("af1", af, data); err != nil {
+		t.Fatalf("got status %d, error %s", status, err.Error())
+	}
+	sub := af.GetAfSubscription(data.Self)
+	s.lifecycle.schedule(sub.Location(), now.Add(time.Hour), func() {
+		t.Errorf("unexpected transition of the deleted subscription")
+	})
+
+	if err := s.DeleteTrafficInfluenceSub("af1", data.Self); err != nil {
+		t.Fatalf("expected no errors, got %s", err.Error())
+	}
+	if s.lifecycle.scheduled(sub.Location()) {
+		t.Errorf("expected the transition of the deleted subscription to be cancelled")
+	}
+}
+
+func TestDeleteSubscriptionRemovalFailure(t *testing.T) {
+	app := &testApp{cfg: &config.AppConfig{TempValidityEnforcement: "nef"}}
+	app.connector = connector.NewConnector(app)
+	app.ctx = contexts.NewTraffInflCtx(app, contexts.NewMemoryStore())
+	s := NewTraffInflService(app)
+	af := app.ctx.AddAf("af1")
+	_, sub := af.NewAfSubscription(&models.TrafficInfluSub{Ipv4Addr: "12.1.1.1"})
+	/*no PCF is bound to the app session, its removal fails*/
+	sub.AppSessId = "sess1"
+	s.lifecycle.schedule(sub.Location(), time.Now().Add(time.Hour), func() {})
+
+	if err := s.DeleteTrafficInfluenceSub("af1", sub.Data.Self); err == nil {
+		t.Fatalf("expected the removal of the app session to fail")
+	}
+	if !s.lifecycle.scheduled(sub.Location()) {
+		t.Errorf("expected the transition of the subscription still in place to be kept")
+	}
+	if af.GetAfSubscription(sub.Data.Self) == nil {
+		t.Errorf("expected the subscription to be kept")
+	}
+	s.lifecycle.cancel(sub.Location())
+}
@@ -21,6 +21,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -28,6 +29,7 @@ import (
 	"syscall"
 
 	"github.com/google/uuid"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/afpolicy"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/connector"
 	contexts "gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/context"
 	"gitlab.eurecom.fr/open-exposure/nef/traffic-influence/internal/nbi"
@@ -49,6 +51,7 @@ type AppCtx struct {
 
 	//contexts
 	traffInflCtx *contexts.TraffInflAppCtx
+	policies     *afpolicy.Registry
 }
 
 func InitApplication(name string, configPath string) (*AppCtx, error) {
@@ -59,15 +62,38 @@ func InitApplication(name string, configPath string) (*AppCtx, error) {
 		appId:   uuid.New().String(),
 	}
 
-	appInstance.server, _ = nbi.NewNorthbound(appInstance)
+	policies, err := afpolicy.NewRegistry(appInstance.config.AfPolicyFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not load AF policies: %w", err)
+	}
+	appInstance.policies = policies
+
+	server, err := nbi.NewNorthbound(appInstance)
+	if err != nil {
+		return nil, fmt.Errorf("could not start the northbound: %w", err)
+	}
+	appInstance.server = server
 	appInstance.service = service.NewTraffInflService(appInstance)
 	appInstance.connector = connector.NewConnector(appInstance)
 
-	appInstance.traffInflCtx = contexts.NewTraffInflCtx(appInstance)
+	appInstance.traffInflCtx = contexts.NewTraffInflCtx(appInstance, newSubscriptionStore(appInstance.config))
+	if err := appInstance.service.RestoreTrafficInfluenceSubs(); err != nil {
+		return nil, fmt.Errorf("could not restore subscriptions: %w", err)
+	}
 
 	return appInstance, nil
 }
 
+// newSubscriptionStore selects where the subscriptions are persisted
+func newSubscriptionStore(cfg *config.AppConfig) contexts.SubscriptionStore {
+	switch cfg.SubsStore {
+	case "redis":
+		return contexts.NewRedisStore(cfg.Sbi.RedisSvc, "traffic-influence")
+	default:
+		return contexts.NewMemoryStore()
+	}
+}
+
 func (app *AppCtx) Cfg() *config.AppConfig {
 	return app.config
 }
@@ -84,6 +110,10 @@ func (app *AppCtx) Ctx() *contexts.TraffInflAppCtx {
 	return app.traffInflCtx
 }
 
+func (app *AppCtx) Policies() *afpolicy.Registry {
+	return app.policies
+}
+
 func (app *AppCtx) Nbi() *nbi.NbiServer {
 	return app.server
 }
@@ -95,6 +125,7 @@ func (app *AppCtx) Run() {
 
 	app.wg.Add(1)
 	go app.listenShutdownEvent()
+	go app.policies.Watch(app.ctx, afpolicy.ReloadInterval)
 
 	log.Printf("starting: %s (%s)", app.appName, app.appId)
 	log.Printf("running config: \n%s", app.config.Dumps())
@@ -28,13 +28,60 @@ import (
 
 type AppConfig struct {
 	/* Basic configuration parameters */
-	Sbi           SbiConfig `yaml:"sbi"`
-	Nbi           NbiConfig `yaml:"nbi"`
-	CapifSvc      string    `yaml:"capifSvc"`
-	SupportedFeat string    `yaml:"supportedFeatures"`
+	Sbi           SbiConfig    `yaml:"sbi"`
+	Nbi           NbiConfig    `yaml:"nbi"`
+	CapifSvc      string       `yaml:"capifSvc"`
+	SupportedFeat string       `yaml:"supportedFeatures"`
+	SubsStore     string       `yaml:"subscriptionStore"` /*memory (default) or redis, stored in sbi redisSvc*/
+	AfPolicyFile  string       `yaml:"afPolicyFile"`      /*optional per-AF authorization policies, reloaded on change*/
+	Limits        LimitsConfig `yaml:"limits"`            /*per-AF request rate and subscription quota*/
 
 	/* Custom configuration parameters */
+	GeoZones                map[string]GeoZoneConfig `yaml:"geoZones"`                /*tracking areas of the validGeoZoneIds*/
+	TempValidityEnforcement string                   `yaml:"tempValidityEnforcement"` /*pcf (default), tempVals sent to the PCF, or nef, app sessions scheduled by the NEF*/
+}
+
+// GeoZoneConfig is the presence area a geozone identifier resolves to
+type GeoZoneConfig struct {
+	PraId string      `yaml:"praId"` /*optional presence reporting area identifier*/
+	Tais  []TaiConfig `yaml:"tais"`
+}
+
+type TaiConfig struct {
+	Mcc string `yaml:"mcc"`
+	Mnc string `yaml:"mnc"`
+	Tac string `yaml:"tac"`
+}
+
+// LimitsConfig bounds the request rate and the active subscriptions of the AFs
+type LimitsConfig struct {
+	Rate             float64                   `yaml:"rate"`             /*requests per second of an AF, 0 for unlimited*/
+	Burst            int                       `yaml:"burst"`            /*requests accepted at once, defaults to one second worth*/
+	MaxSubscriptions int                       `yaml:"maxSubscriptions"` /*active subscriptions of an AF, 0 for unlimited*/
+	Afs              map[string]AfLimitsConfig `yaml:"afs"`              /*per AF overrides, unset values inheriting the above*/
+}
 
+type AfLimitsConfig struct {
+	Rate             float64 `yaml:"rate"`
+	Burst            int     `yaml:"burst"`
+	MaxSubscriptions int     `yaml:"maxSubscriptions"`
+}
+
+// Of returns the limits of the AF
+func (l LimitsConfig) Of(afId string) AfLimitsConfig {
+	limits := AfLimitsConfig{Rate: l.Rate, Burst: l.Burst, MaxSubscriptions: l.MaxSubscriptions}
+	if af, ok := l.Afs[afId]; ok {
+		if af.Rate > 0 {
+			limits.Rate = af.Rate
+		}
+		if af.Burst > 0 {
+			limits.Burst = af.Burst
+		}
+		if af.MaxSubscriptions > 0 {
+			limits.MaxSubscriptions = af.MaxSubscriptions
+		}
+	}
+	return limits
 }
 
 type NbiConfig struct {
@@ -48,7 +95,16 @@ type SbiConfig struct {
 	NrfSvc      string `yaml:"nrfSvc"`
 	UseNrf      bool   `yaml:"useNrf"`
 	PcfSvc      string `yaml:"pcfSvc"`
+	BsfSvc      string `yaml:"bsfSvc"`      /*optional, PCF bindings are looked up by UE address before NRF discovery*/
+	NrfCacheTtl int    `yaml:"nrfCacheTtl"` /*seconds the discovered PCF profiles are kept, defaults to 60*/
+	IdentitySvc string `yaml:"identitySvc"` /*GPSI to SUPI resolution*/
+	ProfileSvc  string `yaml:"profileSvc"`  /*PDU sessions of the GPSI targeted UEs*/
+	RedisSvc    string `yaml:"redisSvc"`    /*group store, external groups are resolved from group-id:<extGroupId> keys*/
+	Port        uint16 `yaml:"port"`        /*listen port of the application data and core callbacks, apart from the nbi port, defaults to 8081*/
+	CallbackUri string `yaml:"callbackUri"` /*NEF address reachable by the core, defaults to nbi fqdn and sbi port*/
 	Httpversion int    `yaml:"httpVersion"`
+
+	AppDataNotifUris []string `yaml:"appDataNotifUris"` /*uri prefixes allowed as influence data subs-to-notify notificationUri, defaults to the pcfSvc*/
 }
 
 func InitConfig(configPath string) *AppConfig {
@@ -63,6 +119,12 @@ func InitConfig(configPath string) *AppConfig {
 	if err != nil {
 		log.Fatalf("error: %v", err)
 	}
+	if cfg.Sbi.Port == 0 {
+		cfg.Sbi.Port = 8081
+	}
+	if cfg.Sbi.Port == cfg.Nbi.Port {
+		log.Fatalf("sbi port must differ from the nbi port, application data and core callbacks are not exposed to the AFs")
+	}
 	return &cfg
 }
 
@@ -8,5 +8,8 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
 	gitlab.eurecom.fr/open-exposure/nef/libcapif v1.2.0
+	gitlab.eurecom.fr/open-exposure/nef/libnbi v1.0.0
 	gopkg.in/yaml.v3 v3.0.1
 )
+
+replace gitlab.eurecom.fr/open-exposure/nef/libnbi => ../libnbi
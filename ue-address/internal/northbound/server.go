@@ -27,6 +27,8 @@ import (
 
 	"github.com/gorilla/mux"
 	"gitlab.eurecom.fr/open-exposure/nef/libcapif"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/invoker"
+	"gitlab.eurecom.fr/open-exposure/nef/libnbi/ratelimit"
 	"gitlab.eurecom.fr/open-exposure/nef/ue-address/internal/models"
 	"gitlab.eurecom.fr/open-exposure/nef/ue-address/internal/northbound/service"
 	"gitlab.eurecom.fr/open-exposure/nef/ue-address/pkg/config"
@@ -42,17 +44,21 @@ type NbiServer struct {
 	appCtx
 	router   *mux.Router
 	capifCtx *libcapif.CapifConnector
+	limiter  *ratelimit.Limiter
 	server   *http.Server
 }
 
 func NewNorthbound(app appCtx) (*NbiServer, error) {
 	nbi := &NbiServer{appCtx: app}
+	nbi.limiter = newRateLimiter(nbi.Cfg().Limits)
 
 	UeIdAPIService := NewDefaultAPIService(nbi)
 	UeIdAPIController := NewDefaultAPIController(UeIdAPIService)
 
 	nbi.router = models.NewRouter(UeIdAPIController)
 
+	/*only the invoker of a token validated by CAPIF is trusted*/
+	nbi.router.Use(invoker.Strip)
 	if len(nbi.Cfg().CapifSvc) > 0 {
 		nbi.capifCtx = libcapif.NewConnector(nbi.Cfg().CapifSvc)
 		nbi.capifCtx.InstantiateConnector(nbi.AppName(), "v1", "HTTP_1_1")
@@ -67,10 +73,42 @@ func NewNorthbound(app appCtx) (*NbiServer, error) {
 		nbi.capifCtx.AddInterface(nbi.Cfg().Nbi.Fqdn, int32(nbi.Cfg().Nbi.Port))
 	}
 
+	/*after the CAPIF middleware, which sets the InvokerId of the request*/
+	nbi.router.Use(invoker.Bind)
+	nbi.router.Use(nbi.rateLimitMiddleware)
+
 	nbi.server = &http.Server{Addr: ":" + strconv.FormatUint(uint64(nbi.Cfg().Nbi.Port), 10), Handler: nbi.router}
 	return nbi, nil
 }
 
+// newRateLimiter builds the token buckets of the configured limits
+func newRateLimiter(limits config.LimitsConfig) *ratelimit.Limiter {
+	overrides := make(map[string]ratelimit.Limit)
+	for client := range limits.Clients {
+		limit := limits.Of(client)
+		overrides[client] = ratelimit.Limit{Rate: limit.Rate, Burst: limit.Burst}
+	}
+	return ratelimit.NewLimiter(ratelimit.Limit{Rate: limits.Rate, Burst: limits.Burst}, overrides)
+}
+
+// rateLimitMiddleware rejects the requests of the client above its rate with a 429
+// telling when to retry
+func (n *NbiServer) rateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := n.limiter.Allow(ratelimit.ClientOf(r, "")); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(wait)))
+			status := http.StatusTooManyRequests
+			_ = models.EncodeJSONResponse(models.ProblemDetails{
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "request rate of the client exceeded",
+			}, &status, w)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (n *NbiServer) startListening(wg *sync.WaitGroup) {
 	defer func() {
 		_ = recover()
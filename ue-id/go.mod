@@ -10,4 +10,9 @@ require (
 	gopkg.in/yaml.v3 v3.0.1
 )
 
-require gitlab.eurecom.fr/open-exposure/nef/libcapif v1.2.0
+require (
+	gitlab.eurecom.fr/open-exposure/nef/libcapif v1.2.0
+	gitlab.eurecom.fr/open-exposure/nef/libnbi v1.0.0
+)
+
+replace gitlab.eurecom.fr/open-exposure/nef/libnbi => ../libnbi
@@ -28,15 +28,43 @@ import (
 
 type AppConfig struct {
 	/* Basic configuration parameters */
-	Sbi           SbiConfig `yaml:"sbi"`
-	Nbi           NbiConfig `yaml:"nbi"`
-	CapifSvc      string    `yaml:"capifSvc"`
-	SupportedFeat string    `yaml:"supportedFeatures"`
+	Sbi           SbiConfig    `yaml:"sbi"`
+	Nbi           NbiConfig    `yaml:"nbi"`
+	CapifSvc      string       `yaml:"capifSvc"`
+	SupportedFeat string       `yaml:"supportedFeatures"`
+	Limits        LimitsConfig `yaml:"limits"` /*per client request rate*/
 
 	/* Custom configuration parameters */
 
 }
 
+// LimitsConfig bounds the request rate of the clients, identified by their
+// CAPIF API invoker or else by their address
+type LimitsConfig struct {
+	Rate    float64                       `yaml:"rate"`    /*requests per second of a client, 0 for unlimited*/
+	Burst   int                           `yaml:"burst"`   /*requests accepted at once, defaults to one second worth*/
+	Clients map[string]ClientLimitsConfig `yaml:"clients"` /*per client overrides, unset values inheriting the above*/
+}
+
+type ClientLimitsConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
+}
+
+// Of returns the limits of the client
+func (l LimitsConfig) Of(client string) ClientLimitsConfig {
+	limits := ClientLimitsConfig{Rate: l.Rate, Burst: l.Burst}
+	if c, ok := l.Clients[client]; ok {
+		if c.Rate > 0 {
+			limits.Rate = c.Rate
+		}
+		if c.Burst > 0 {
+			limits.Burst = c.Burst
+		}
+	}
+	return limits
+}
+
 type NbiConfig struct {
 	HttpVersion uint16 `yaml:"httpVersion"`
 	UseTLS      bool   `yaml:"useTLS"`
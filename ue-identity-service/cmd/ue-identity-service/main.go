@@ -83,15 +83,34 @@ func main() {
 	// HTTP endpoint for testing
 	http.HandleFunc("/resolve", func(w http.ResponseWriter, r *http.Request) {
 		externalId := r.URL.Query().Get("externalId")
+		msisdn := r.URL.Query().Get("msisdn")
+		// Optional AF the externalId must have been issued to
+		scope := r.URL.Query().Get("afId")
 
-		if externalId == "" {
-			http.Error(w, "missing 'externalId' query param", http.StatusBadRequest)
+		if externalId == "" && msisdn == "" {
+			http.Error(w, "missing 'externalId' or 'msisdn' query param", http.StatusBadRequest)
 			return
 		}
 
 		var supi string
 		var err error
 
+		if msisdn != "" {
+			supi, err = resolver.LookupSupiByMsisdn(msisdn)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+
+			err = json.NewEncoder(w).Encode(map[string]string{
+				"Supi": supi,
+			})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
 		// Check if externalId is in format "afId:encodedIdentifier" or plain IMSI
 		extId := strings.Split(externalId, ":")
 		if len(extId) == 2 {
@@ -106,10 +125,14 @@ func main() {
 				return
 			}
 
-			if afId != afIdDecoded {
+			if afId != afIdDecoded || (scope != "" && afId != scope) {
 				http.Error(w, "afId mismatch", http.StatusNotFound)
 				return
 			}
+		} else if scope != "" {
+			// A plain IMSI is never resolved for an AF
+			http.Error(w, "externalId not issued to the afId", http.StatusNotFound)
+			return
 		} else {
 			// Plain IMSI format - use directly as SUPI
 			supi = externalId
@@ -123,6 +146,30 @@ func main() {
 		}
 	})
 
+	// HTTP endpoint for the External ID of a SUPI, issued to an AF
+	http.HandleFunc("/externalId", func(w http.ResponseWriter, r *http.Request) {
+		supi := r.URL.Query().Get("supi")
+		afId := r.URL.Query().Get("afId")
+
+		if supi == "" || afId == "" {
+			http.Error(w, "missing 'supi' or 'afId' query param", http.StatusBadRequest)
+			return
+		}
+		encSupi, err := utils.EncodeIMSIWithAfID(supi, afId, key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		err = json.NewEncoder(w).Encode(struct {
+			ExternalId string `json:"externalId"`
+		}{
+			ExternalId: fmt.Sprintf("%s:%s", afId, encSupi),
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+
 	// HTTP endpoint for MSISDN lookup by IP
 	http.HandleFunc("/msisdn", func(w http.ResponseWriter, r *http.Request) {
 		ip := r.URL.Query().Get("ip")
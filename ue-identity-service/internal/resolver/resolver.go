@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 
 	"gitlab.eurecom.fr/open-exposure/nef/ue-identity-service/internal/models"
@@ -109,6 +110,21 @@ func (r *Resolver) LookupMsisdn(ip string) (string, error) {
 	return "", fmt.Errorf("MSISDN not available for SUPI %s", supi)
 }
 
+// LookupSupiByMsisdn returns the SUPI associated with a given MSISDN (GPSI)
+func (r *Resolver) LookupSupiByMsisdn(msisdn string) (string, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	target := strings.TrimPrefix(strings.TrimPrefix(msisdn, "msisdn-"), "+")
+	for supi, gpsi := range r.gpsiCache {
+		if strings.TrimPrefix(strings.TrimPrefix(gpsi, "msisdn-"), "+") == target {
+			return supi, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not find UE for MSISDN %s", msisdn)
+}
+
 // SetGpsi associates a GPSI (MSISDN) with a SUPI
 func (r *Resolver) SetGpsi(supi, gpsi string) {
 	r.lock.Lock()